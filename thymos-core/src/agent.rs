@@ -128,7 +128,9 @@ impl Agent {
         &self.id
     }
 
-    /// Get agent description
+    /// Get the description the agent was built with
+    ///
+    /// See `current_description` for the description including runtime changes.
     pub fn description(&self) -> &str {
         &self.description
     }
@@ -161,6 +163,70 @@ impl Agent {
         Ok(())
     }
 
+    /// Get the current agent description
+    ///
+    /// Returns the description set with `set_description`, falling back to
+    /// the one the agent was built with.
+    pub async fn current_description(&self) -> String {
+        self.state_string("description")
+            .await
+            .unwrap_or_else(|| self.description.clone())
+    }
+
+    /// Set the agent description, stored in the state properties under
+    /// `description`
+    ///
+    /// An empty description restores the one the agent was built with.
+    pub async fn set_description(&self, description: impl Into<String>) -> Result<()> {
+        self.set_state_string("description", description.into())
+            .await
+    }
+
+    /// Get the agent persona (structured system context), if set
+    ///
+    /// Unlike the description, which is a human-readable label, the persona is
+    /// intended as system context for LLM-driven operations.
+    pub async fn persona(&self) -> Option<String> {
+        self.state_string("persona").await
+    }
+
+    /// Set the agent persona, stored in the state properties under `persona`
+    ///
+    /// An empty persona clears it.
+    pub async fn set_persona(&self, persona: impl Into<String>) -> Result<()> {
+        self.set_state_string("persona", persona.into()).await
+    }
+
+    /// Get a string state property, if set
+    async fn state_string(&self, key: &str) -> Option<String> {
+        self.state
+            .read()
+            .await
+            .properties
+            .get(key)
+            .and_then(|p| p.as_str())
+            .map(str::to_string)
+    }
+
+    /// Set a string state property; an empty value removes it
+    async fn set_state_string(&self, key: &str, value: String) -> Result<()> {
+        let mut state = self.state.write().await;
+        if !state.properties.is_object() {
+            state.properties = serde_json::json!({});
+        }
+        let properties = state
+            .properties
+            .as_object_mut()
+            .expect("properties is an object");
+        if value.is_empty() {
+            properties.remove(key);
+        } else {
+            properties.insert(key.to_string(), serde_json::Value::String(value));
+        }
+        state.last_active = Utc::now();
+        Ok(())
+    }
+
     /// Get the tools registered with this agent
     pub fn tools(&self) -> &[Arc<dyn Tool>] {
         &self.tools
@@ -374,6 +440,11 @@ impl Agent {
         self.memory.get_memory(id).await
     }
 
+    /// Delete a memory by ID, returning `false` if it does not exist
+    pub async fn forget(&self, id: &str) -> Result<bool> {
+        self.memory.forget(id).await
+    }
+
     /// Get the LLM provider (if configured)
     pub fn llm_provider(&self) -> Option<&Arc<dyn LLMProvider>> {
         self.llm_provider.as_ref()
@@ -1014,4 +1085,61 @@ mod tests {
         assert!(agent.embedding_provider().is_none());
         assert!(agent.concept_extractor().is_none());
     }
+
+    async fn test_agent(temp_dir: &tempfile::TempDir) -> Agent {
+        let config = MemoryConfig {
+            mode: crate::config::MemoryMode::Embedded {
+                data_dir: temp_dir.path().to_path_buf(),
+            },
+            ..Default::default()
+        };
+
+        Agent::builder()
+            .id("test_agent")
+            .with_memory_config(config)
+            .build()
+            .await
+            .expect("Failed to create agent")
+    }
+
+    #[tokio::test]
+    async fn test_agent_forget() {
+        let temp_dir = tempfile::TempDir::new().expect("Failed to create temp dir");
+        let agent = test_agent(&temp_dir).await;
+
+        let id = agent
+            .remember("Alice met Bob in Paris")
+            .await
+            .expect("Failed to remember");
+
+        assert!(agent.forget(&id).await.expect("Failed to forget"));
+        let memory = agent.get_memory(&id).await.expect("Failed to get");
+        assert!(memory.is_none());
+        // Forgetting again reports that nothing was deleted
+        assert!(!agent.forget(&id).await.expect("Failed to forget"));
+    }
+
+    #[tokio::test]
+    async fn test_agent_persona() {
+        let temp_dir = tempfile::TempDir::new().expect("Failed to create temp dir");
+        let agent = test_agent(&temp_dir).await;
+
+        assert_eq!(agent.persona().await, None);
+
+        agent
+            .set_persona("A helpful archivist")
+            .await
+            .expect("Failed to set persona");
+        assert_eq!(
+            agent.persona().await.as_deref(),
+            Some("A helpful archivist")
+        );
+
+        // An empty persona clears it
+        agent
+            .set_persona("")
+            .await
+            .expect("Failed to clear persona");
+        assert_eq!(agent.persona().await, None);
+    }
 }
@@ -270,6 +270,13 @@ pub struct EmbeddingsConfig {
     /// Base URL (for custom endpoints, e.g., Ollama)
     #[serde(skip_serializing_if = "Option::is_none")]
     pub base_url: Option<String>,
+
+    /// Expected embedding dimensions (optional)
+    ///
+    /// When set, creating the provider fails if the model produces vectors of
+    /// a different size.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub dimensions: Option<usize>,
 }
 
 /// Pub/sub configuration
@@ -494,6 +501,7 @@ impl ThymosConfig {
                 model,
                 api_key: None,
                 base_url: std::env::var("THYMOS_EMBEDDINGS_BASE_URL").ok(),
+                dimensions: None,
             });
         }
 
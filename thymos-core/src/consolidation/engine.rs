@@ -179,6 +179,50 @@ Respond in a structured format."#,
         Ok(vec![insight])
     }
 
+    /// Consolidate memories about a topic into a single summary paragraph.
+    ///
+    /// The persona, if any, is used as the system prompt so the summary is
+    /// written in the agent's voice. Returns an empty summary without calling
+    /// the LLM when there are no memories.
+    pub async fn summarize(
+        &self,
+        topic: &str,
+        memories: &[Memory],
+        persona: Option<&str>,
+    ) -> Result<String> {
+        if memories.is_empty() {
+            return Ok(String::new());
+        }
+
+        let memories_text: Vec<String> = memories
+            .iter()
+            .map(|m| format!("- {}", m.content))
+            .collect();
+
+        let system_prompt = persona.unwrap_or(
+            "You are an AI assistant that consolidates memories into concise summaries.",
+        );
+
+        let user_prompt = format!(
+            r#"Summarize the following memories about "{}" into a single concise paragraph. Keep every distinct fact and drop repetition.
+
+MEMORIES:
+{}"#,
+            topic,
+            memories_text.join("\n")
+        );
+
+        let request = LLMRequest::with_system_prompt(system_prompt, user_prompt);
+
+        let response = self
+            .llm
+            .generate_request(&request)
+            .await
+            .map_err(|e| ThymosError::Agent(format!("LLM error: {}", e)))?;
+
+        Ok(response.content.trim().to_string())
+    }
+
     /// Update importance scores for memories based on patterns.
     async fn update_importance_scores(&self, memories: &[Memory]) -> Result<()> {
         // Calculate a simple importance boost based on:
@@ -20,8 +20,25 @@ impl EmbeddingProviderFactory {
     ///
     /// # Errors
     ///
-    /// Returns an error if the provider cannot be created
+    /// Returns an error if the provider cannot be created, or if it does not
+    /// produce the configured `dimensions`
     pub async fn create(config: &EmbeddingsConfig) -> Result<Arc<dyn EmbeddingProvider>> {
+        let provider = Self::create_provider(config)?;
+        if let Some(expected) = config.dimensions
+            && provider.dimension() != expected
+        {
+            return Err(crate::error::ThymosError::Configuration(format!(
+                "Embedding model '{}' produces {} dimensions, expected {}",
+                config.model,
+                provider.dimension(),
+                expected
+            )));
+        }
+        Ok(provider)
+    }
+
+    /// Create the provider selected by `config.provider`
+    fn create_provider(config: &EmbeddingsConfig) -> Result<Arc<dyn EmbeddingProvider>> {
         match config.provider {
             #[cfg(feature = "embeddings-local")]
             EmbeddingProviderType::Local => {
@@ -34,6 +34,10 @@ pub enum ThymosError {
     #[error("Configuration error: {0}")]
     Configuration(String),
 
+    /// Operation requires hybrid memory mode
+    #[error("Hybrid mode required: {0}")]
+    NotHybrid(String),
+
     /// Invalid relevance context
     #[error("Invalid relevance context: {0}")]
     InvalidContext(String),
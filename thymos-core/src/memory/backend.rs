@@ -57,6 +57,14 @@ pub struct StoreOptions {
     /// Pre-computed embedding (if available)
     #[serde(skip_serializing_if = "Option::is_none")]
     pub embedding: Option<Vec<f32>>,
+
+    /// ID to store the memory under (the backend assigns one if unset)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub id: Option<String>,
+
+    /// Additional properties to store with the memory
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub properties: Option<serde_json::Value>,
 }
 
 /// Options for searching memories
@@ -83,6 +83,14 @@ impl HybridMemorySystem {
         self.shared.store(content, None).await
     }
 
+    /// Delete a memory from shared backend
+    ///
+    /// Returns true if the memory existed and was deleted.
+    pub async fn delete_shared(&self, id: &str) -> Result<bool> {
+        use super::backend::MemoryBackend;
+        self.shared.delete(id).await
+    }
+
     /// Store a memory in private backend with optional embedding
     ///
     /// Embeddings must be 1024 dimensions (BGE-M3 compatible) for vector search to work.
@@ -125,6 +133,74 @@ impl HybridMemorySystem {
         self.shared.store(content, options).await
     }
 
+    /// Store a memory in private backend with tags and optional embedding
+    pub async fn remember_private_with_tags(
+        &self,
+        content: String,
+        tags: Vec<String>,
+        embedding: Option<Vec<f32>>,
+    ) -> Result<String> {
+        if tags.is_empty() {
+            return self
+                .remember_private_with_embedding(content, embedding)
+                .await;
+        }
+        if let Some(ref emb) = embedding {
+            if emb.len() != 1024 {
+                return Err(ThymosError::Memory(format!(
+                    "Embedding dimension mismatch: expected 1024 dimensions (BGE-M3 compatible), but got {}",
+                    emb.len()
+                )));
+            }
+        }
+
+        self.private
+            .manager()
+            .add_memory_with_options(&content, |builder| {
+                let tag_refs: Vec<&str> = tags.iter().map(|s| s.as_str()).collect();
+                let mut b = builder.tags(tag_refs);
+                if let Some(emb) = embedding {
+                    b = b.embedding(emb);
+                }
+                b
+            })
+            .await
+            .map_err(|e| ThymosError::Memory(e.to_string()))
+    }
+
+    /// Store a memory in shared backend with tags and optional embedding
+    pub async fn remember_shared_with_tags(
+        &self,
+        content: String,
+        tags: Vec<String>,
+        embedding: Option<Vec<f32>>,
+    ) -> Result<String> {
+        use super::backend::{MemoryBackend, StoreOptions};
+        let options = StoreOptions {
+            tags,
+            embedding,
+            ..Default::default()
+        };
+        self.shared.store(content, Some(options)).await
+    }
+
+    /// Copy a memory to shared backend under the same ID
+    ///
+    /// Tags, properties and embedding are carried over with the content.
+    pub async fn copy_to_shared(&self, memory: &Memory) -> Result<String> {
+        use super::backend::{MemoryBackend, StoreOptions};
+        let options = StoreOptions {
+            tags: memory.tags.clone(),
+            embedding: memory.embedding.clone(),
+            id: Some(memory.id.clone()),
+            properties: Some(memory.properties.clone()),
+            ..Default::default()
+        };
+        self.shared
+            .store(memory.content.clone(), Some(options))
+            .await
+    }
+
     /// Store a memory with automatic routing based on tags
     pub async fn remember_with_tags(&self, content: String, tags: Vec<String>) -> Result<String> {
         let scope = self.routing.route(&tags);
@@ -372,6 +448,11 @@ impl HybridMemorySystem {
         self.lifecycle.calculate_strength(memory)
     }
 
+    /// Project memory strength to time `at`, assuming no further access
+    pub fn calculate_strength_at(&self, memory: &Memory, at: chrono::DateTime<chrono::Utc>) -> f64 {
+        self.lifecycle.calculate_strength_at(memory, at)
+    }
+
     /// Get the private Locai instance
     pub fn private_locai(&self) -> &Locai {
         &self.private
@@ -76,12 +76,16 @@ impl InMemoryBackend {
 #[async_trait]
 impl MemoryBackend for InMemoryBackend {
     async fn store(&self, content: String, options: Option<StoreOptions>) -> Result<String> {
-        let id = self.generate_id();
         let timestamp = Utc::now().to_rfc3339();
 
+        let mut id = None;
         let mut properties = serde_json::Map::new();
 
         if let Some(opts) = options {
+            id = opts.id;
+            if let Some(serde_json::Value::Object(extra)) = opts.properties {
+                properties = extra;
+            }
             if let Some(memory_type) = opts.memory_type {
                 properties.insert("type".to_string(), serde_json::json!(memory_type));
             }
@@ -93,6 +97,7 @@ impl MemoryBackend for InMemoryBackend {
             }
         }
 
+        let id = id.unwrap_or_else(|| self.generate_id());
         let record = MemoryRecord {
             id: id.clone(),
             content,
@@ -218,7 +223,7 @@ mod tests {
             memory_type: Some("fact".to_string()),
             tags: vec!["important".to_string(), "science".to_string()],
             priority: Some(10),
-            embedding: None,
+            ..Default::default()
         };
 
         let id = backend
@@ -358,6 +358,7 @@ impl MemorySystem {
                     tags: options.tags,
                     priority: options.priority,
                     embedding: options.embedding,
+                    ..Default::default()
                 };
                 backend.store(content, Some(store_options)).await
             }
@@ -365,12 +366,12 @@ impl MemorySystem {
                 match options.memory_type {
                     Some(MemoryTypeHint::Fact) => {
                         hybrid
-                            .remember_shared_with_embedding(content, options.embedding)
+                            .remember_shared_with_tags(content, options.tags, options.embedding)
                             .await
                     }
                     _ => {
                         hybrid
-                            .remember_private_with_embedding(content, options.embedding)
+                            .remember_private_with_tags(content, options.tags, options.embedding)
                             .await
                     }
                 }
@@ -381,7 +382,7 @@ impl MemorySystem {
     /// Store a memory in private backend (hybrid mode only)
     pub async fn remember_private(&self, content: String) -> Result<String> {
         match self {
-            Self::Single { .. } | Self::Server { .. } => Err(ThymosError::Configuration(
+            Self::Single { .. } | Self::Server { .. } => Err(ThymosError::NotHybrid(
                 "remember_private only available in hybrid mode".to_string(),
             )),
             Self::Hybrid { hybrid, .. } => hybrid.remember_private(content).await,
@@ -391,7 +392,7 @@ impl MemorySystem {
     /// Store a memory in shared backend (hybrid mode only)
     pub async fn remember_shared(&self, content: String) -> Result<String> {
         match self {
-            Self::Single { .. } | Self::Server { .. } => Err(ThymosError::Configuration(
+            Self::Single { .. } | Self::Server { .. } => Err(ThymosError::NotHybrid(
                 "remember_shared only available in hybrid mode".to_string(),
             )),
             Self::Hybrid { hybrid, .. } => hybrid.remember_shared(content).await,
@@ -448,7 +449,7 @@ impl MemorySystem {
         embedding: Option<Vec<f32>>,
     ) -> Result<String> {
         match self {
-            Self::Single { .. } | Self::Server { .. } => Err(ThymosError::Configuration(
+            Self::Single { .. } | Self::Server { .. } => Err(ThymosError::NotHybrid(
                 "remember_private_with_embedding only available in hybrid mode".to_string(),
             )),
             Self::Hybrid { hybrid, .. } => {
@@ -466,7 +467,7 @@ impl MemorySystem {
         embedding: Option<Vec<f32>>,
     ) -> Result<String> {
         match self {
-            Self::Single { .. } | Self::Server { .. } => Err(ThymosError::Configuration(
+            Self::Single { .. } | Self::Server { .. } => Err(ThymosError::NotHybrid(
                 "remember_shared_with_embedding only available in hybrid mode".to_string(),
             )),
             Self::Hybrid { hybrid, .. } => {
@@ -604,6 +605,33 @@ impl MemorySystem {
         }
     }
 
+    /// Delete a memory by ID
+    ///
+    /// In hybrid mode the private backend is tried first, then the shared one.
+    /// Returns `false` if no memory has the ID.
+    pub async fn forget(&self, id: &str) -> Result<bool> {
+        match self {
+            Self::Single { locai, .. } => locai
+                .manager()
+                .delete_memory(id)
+                .await
+                .map_err(|e| ThymosError::Memory(e.to_string())),
+            Self::Server { backend, .. } => backend.delete(id).await,
+            Self::Hybrid { hybrid, .. } => {
+                let deleted = hybrid
+                    .private_locai()
+                    .manager()
+                    .delete_memory(id)
+                    .await
+                    .map_err(|e| ThymosError::Memory(e.to_string()))?;
+                if deleted {
+                    return Ok(true);
+                }
+                hybrid.delete_shared(id).await
+            }
+        }
+    }
+
     /// Calculate memory strength using forgetting curve
     pub fn calculate_strength(&self, memory: &Memory) -> f64 {
         match self {
@@ -614,6 +642,16 @@ impl MemorySystem {
         }
     }
 
+    /// Project memory strength to time `at`, assuming no further access
+    pub fn calculate_strength_at(&self, memory: &Memory, at: chrono::DateTime<chrono::Utc>) -> f64 {
+        match self {
+            Self::Single { lifecycle, .. } | Self::Server { lifecycle, .. } => {
+                lifecycle.calculate_strength_at(memory, at)
+            }
+            Self::Hybrid { hybrid, .. } => hybrid.calculate_strength_at(memory, at),
+        }
+    }
+
     /// Get the underlying Locai instance for advanced operations (embedded mode only)
     pub fn locai(&self) -> Result<&Locai> {
         match self {
@@ -855,23 +893,29 @@ impl MemoryLifecycle {
     /// - t = time since last access
     /// - S = stability (resistance to forgetting)
     pub fn calculate_strength(&self, memory: &Memory) -> f64 {
+        self.calculate_strength_at(memory, chrono::Utc::now())
+    }
+
+    /// Calculate memory strength as of `at`, assuming no access in between
+    ///
+    /// Used to project retention forward; never mutates the memory.
+    pub fn calculate_strength_at(&self, memory: &Memory, at: chrono::DateTime<chrono::Utc>) -> f64 {
         if !self.config.forgetting_curve_enabled {
             return 1.0;
         }
 
-        let hours_since_access = self.hours_since_access(memory);
+        let hours_since_access = self.hours_since_access(memory, at);
         let stability = self.calculate_stability(memory);
 
         // Forgetting curve: R = e^(-t/S)
         let time_decay = (-hours_since_access / stability).exp();
-        let age_decay = self.age_decay(memory);
+        let age_decay = self.age_decay(memory, at);
 
         (time_decay * age_decay).clamp(0.0, 1.0)
     }
 
     /// Calculate hours since last access
-    fn hours_since_access(&self, memory: &Memory) -> f64 {
-        let now = chrono::Utc::now();
+    fn hours_since_access(&self, memory: &Memory, now: chrono::DateTime<chrono::Utc>) -> f64 {
         let last_accessed = memory.last_accessed.unwrap_or(memory.created_at);
         let duration = now.signed_duration_since(last_accessed);
         duration.num_hours() as f64
@@ -895,9 +939,8 @@ impl MemoryLifecycle {
     }
 
     /// Calculate age-based decay
-    fn age_decay(&self, memory: &Memory) -> f64 {
+    fn age_decay(&self, memory: &Memory, now: chrono::DateTime<chrono::Utc>) -> f64 {
         let hours_since_creation = {
-            let now = chrono::Utc::now();
             let duration = now.signed_duration_since(memory.created_at);
             duration.num_hours() as f64
         };
@@ -1096,4 +1139,49 @@ mod tests {
         let scopes = memory_system.list_scopes().await;
         assert_eq!(scopes.len(), 3);
     }
+
+    fn test_lifecycle(forgetting_curve_enabled: bool) -> MemoryLifecycle {
+        MemoryLifecycle::new(LifecycleConfig {
+            forgetting_curve_enabled,
+            recency_decay_hours: 168.0,
+            access_count_weight: 0.1,
+            emotional_weight_multiplier: 1.5,
+            base_decay_rate: 0.01,
+        })
+    }
+
+    #[test]
+    fn test_calculate_strength_at() {
+        let lifecycle = test_lifecycle(true);
+        let memory = locai::models::MemoryBuilder::new_with_content("The sky is blue").build();
+        let start = memory.created_at;
+
+        // No time has passed, so nothing has decayed
+        assert_eq!(lifecycle.calculate_strength_at(&memory, start), 1.0);
+
+        // One day on: R = e^(-t/S) with S = (168 + 0.1) * 1.5, times the age decay
+        let day = lifecycle.calculate_strength_at(&memory, start + chrono::Duration::hours(24));
+        let expected = (-24.0 / 252.15_f64).exp() * (-24.0 * 0.01_f64).exp();
+        assert!((day - expected).abs() < 1e-9);
+
+        // Strength keeps falling the further ahead the projection looks
+        let week = lifecycle.calculate_strength_at(&memory, start + chrono::Duration::days(7));
+        assert!(week < day);
+
+        // Decay counts from the last access, not from creation
+        let mut accessed = memory.clone();
+        accessed.last_accessed = Some(start + chrono::Duration::days(6));
+        let since_access =
+            lifecycle.calculate_strength_at(&accessed, start + chrono::Duration::days(7));
+        assert!(since_access > week);
+    }
+
+    #[test]
+    fn test_calculate_strength_at_without_forgetting_curve() {
+        let lifecycle = test_lifecycle(false);
+        let memory = locai::models::MemoryBuilder::new_with_content("The sky is blue").build();
+
+        let at = memory.created_at + chrono::Duration::days(365);
+        assert_eq!(lifecycle.calculate_strength_at(&memory, at), 1.0);
+    }
 }
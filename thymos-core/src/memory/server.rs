@@ -161,6 +161,12 @@ impl MemoryBackend for ServerMemoryBackend {
         });
 
         if let Some(opts) = options {
+            if let Some(id) = opts.id {
+                json_body["id"] = serde_json::json!(id);
+            }
+            if let Some(properties) = opts.properties {
+                json_body["properties"] = properties;
+            }
             if let Some(memory_type) = opts.memory_type {
                 json_body["memory_type"] = serde_json::json!(memory_type);
             }
@@ -0,0 +1,13 @@
+//go:build !thymos_lib_dir
+
+package thymos
+
+// By default the library is linked from the Cargo target directories of this
+// checkout, which are also embedded as the runtime search path. Build with
+// the thymos_lib_dir tag to drop these paths and supply your own through
+// CGO_LDFLAGS; see "Custom Library Location" in the README.
+
+/*
+#cgo LDFLAGS: -L${SRCDIR}/../../target/debug -L${SRCDIR}/../../target/release -Wl,-rpath,${SRCDIR}/../../target/debug:${SRCDIR}/../../target/release
+*/
+import "C"
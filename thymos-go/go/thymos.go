@@ -26,63 +26,256 @@
 // are registered as a safety net, explicit Close() calls are recommended for
 // deterministic resource cleanup.
 //
+// Agents copy their MemoryConfig or Config when created and never refer to it
+// again, so a configuration can be closed at any time, including while agents
+// built from it are in use or being created.
+//
 // # Thread Safety
 //
 // All methods are thread-safe and can be called from multiple goroutines.
 package thymos
 
 /*
-#cgo LDFLAGS: -L${SRCDIR}/../../target/debug -L${SRCDIR}/../../target/release -lthymos_go -ldl -lm -lpthread -Wl,-rpath,${SRCDIR}/../../target/debug:${SRCDIR}/../../target/release
+#cgo LDFLAGS: -lthymos_go -ldl -lm -lpthread
 #include <stdlib.h>
 #include <stdint.h>
 #include <stdbool.h>
 
 // Error handling
-extern const char* thymos_get_last_error(void);
-extern void thymos_clear_error(void);
+typedef struct {
+    int code;
+    char* message;
+} ThymosError;
 
 // String utilities
 extern void thymos_free_string(char* s);
 
 // Configuration
 extern void* thymos_memory_config_new(void);
-extern void* thymos_memory_config_with_data_dir(const char* data_dir);
+extern void* thymos_memory_config_with_data_dir(const char* data_dir, ThymosError* out_error);
+extern int thymos_memory_config_set_forget_grace_period(void* config, uint64_t grace_ms, ThymosError* out_error);
+extern int thymos_memory_config_set_count_sampling(void* config, uint64_t interval_ms, ThymosError* out_error);
+extern int thymos_memory_config_set_query_cache(void* config, size_t size, uint64_t ttl_ms, ThymosError* out_error);
+extern int thymos_memory_config_set_max_blob_size(void* config, size_t max_bytes, ThymosError* out_error);
+extern int thymos_memory_config_set_track_access_timeline(void* config, bool enabled, ThymosError* out_error);
+extern int thymos_memory_config_set_max_memories(void* config, size_t max_memories, ThymosError* out_error);
+extern int thymos_memory_config_set_embedding_batch_size(void* config, size_t batch_size, ThymosError* out_error);
+extern int thymos_memory_config_set_decay_rate(void* config, double rate, ThymosError* out_error);
+extern int thymos_memory_config_set_forget_threshold(void* config, double threshold, ThymosError* out_error);
+extern int thymos_memory_config_validate(const void* config, ThymosError* out_error);
 extern void thymos_free_memory_config(void* handle);
 extern void* thymos_config_new(void);
-extern void* thymos_config_load(void);
-extern void* thymos_config_load_from_file(const char* path);
+extern void* thymos_config_load(ThymosError* out_error);
+extern void* thymos_config_load_from_file(const char* path, ThymosError* out_error);
+extern int thymos_config_set_embedding_model(void* config, const char* model, ThymosError* out_error);
+extern int thymos_config_set_embedding_dimensions(void* config, size_t dimensions, ThymosError* out_error);
+extern char* thymos_config_embedding_model(const void* config, ThymosError* out_error);
+extern int thymos_config_set_data_dir(void* config, const char* data_dir, ThymosError* out_error);
+extern int thymos_config_set_hybrid(void* config, const char* shared_url, const char* shared_api_key, ThymosError* out_error);
+extern int thymos_config_validate(const void* config, ThymosError* out_error);
 extern void thymos_free_config(void* handle);
 
 // Agent lifecycle
-extern void* thymos_agent_new(const char* agent_id);
-extern void* thymos_agent_new_with_memory_config(const char* agent_id, const void* config);
-extern void* thymos_agent_new_with_config(const char* agent_id, const void* config);
+extern void* thymos_agent_new(const char* agent_id, ThymosError* out_error);
+extern void* thymos_agent_new_with_memory_config(const char* agent_id, const void* config, ThymosError* out_error);
+extern void* thymos_agent_new_with_config(const char* agent_id, const void* config, ThymosError* out_error);
+extern void* thymos_agent_open(const char* agent_id, const char* data_dir, ThymosError* out_error);
+extern void* thymos_agent_open_read_only(const char* agent_id, const char* data_dir, ThymosError* out_error);
+extern void* thymos_agent_fork(const void* handle, const char* new_agent_id, ThymosError* out_error);
 extern void thymos_free_agent(void* handle);
 
 // Agent properties
-extern char* thymos_agent_id(const void* handle);
-extern char* thymos_agent_description(const void* handle);
-extern char* thymos_agent_status(const void* handle);
-extern int thymos_agent_set_status(const void* handle, const char* status);
-extern void* thymos_agent_state(const void* handle);
+extern char* thymos_agent_id(const void* handle, ThymosError* out_error);
+extern int thymos_agent_rename(void* handle, const char* new_agent_id, ThymosError* out_error);
+extern char* thymos_agent_description(const void* handle, ThymosError* out_error);
+extern int thymos_agent_set_description(const void* handle, const char* description, ThymosError* out_error);
+extern char* thymos_agent_persona(const void* handle, ThymosError* out_error);
+extern int thymos_agent_set_persona(const void* handle, const char* persona, ThymosError* out_error);
+extern char* thymos_agent_status(const void* handle, ThymosError* out_error);
+extern int thymos_agent_set_status(const void* handle, const char* status, ThymosError* out_error);
+extern void* thymos_agent_state(const void* handle, ThymosError* out_error);
+extern int thymos_agent_ping(const void* handle, ThymosError* out_error);
 extern void thymos_free_agent_state(void* state);
-extern int thymos_agent_is_hybrid(const void* handle);
+extern int thymos_agent_is_hybrid(const void* handle, ThymosError* out_error);
+extern char* thymos_agent_data_dir(const void* handle, ThymosError* out_error);
 
 // Memory operations
-extern char* thymos_agent_remember(const void* handle, const char* content);
-extern char* thymos_agent_remember_fact(const void* handle, const char* content);
-extern char* thymos_agent_remember_conversation(const void* handle, const char* content);
-extern char* thymos_agent_remember_private(const void* handle, const char* content);
-extern char* thymos_agent_remember_shared(const void* handle, const char* content);
+extern char* thymos_agent_remember(const void* handle, const char* content, ThymosError* out_error);
+extern char* thymos_agent_remember_fact(const void* handle, const char* content, ThymosError* out_error);
+extern char* thymos_agent_remember_conversation(const void* handle, const char* content, ThymosError* out_error);
+extern char* thymos_agent_remember_private(const void* handle, const char* content, ThymosError* out_error);
+extern char* thymos_agent_remember_shared(const void* handle, const char* content, ThymosError* out_error);
+extern char* thymos_agent_remember_with_properties(const void* handle, const char* content, const char* properties_json, ThymosError* out_error);
+extern char* thymos_agent_remember_cancelable(const void* handle, const char* content, const void* token, ThymosError* out_error);
+extern char* thymos_agent_remember_fact_cancelable(const void* handle, const char* content, const void* token, ThymosError* out_error);
+extern char* thymos_agent_remember_conversation_cancelable(const void* handle, const char* content, const void* token, ThymosError* out_error);
+extern char* thymos_agent_remember_private_cancelable(const void* handle, const char* content, const void* token, ThymosError* out_error);
+extern char* thymos_agent_remember_shared_cancelable(const void* handle, const char* content, const void* token, ThymosError* out_error);
+extern char* thymos_agent_remember_with_properties_cancelable(const void* handle, const char* content, const char* properties_json, const void* token, ThymosError* out_error);
+extern int64_t thymos_agent_remember_batch(const void* handle, const char* const* contents, size_t count, void** out_ids, void** out_errors, ThymosError* out_error);
+extern int thymos_agent_forget(const void* handle, const char* memory_id, ThymosError* out_error);
+extern int thymos_agent_update_memory(const void* handle, const char* memory_id, const char* content, ThymosError* out_error);
+
+// Bulk import
+extern int thymos_agent_import_file(const void* handle, const char* path, int format, const char* content_column, const char* const* property_columns, size_t property_count, size_t* out_error_line, size_t* out_imported, ThymosError* out_error);
 
 // Memory search
-extern void* thymos_agent_search_memories(const void* handle, const char* query, size_t limit);
-extern void* thymos_agent_search_private(const void* handle, const char* query, size_t limit);
-extern void* thymos_agent_search_shared(const void* handle, const char* query, size_t limit);
-extern void* thymos_agent_get_memory(const void* handle, const char* memory_id);
+extern void* thymos_agent_search_memories(const void* handle, const char* query, size_t limit, ThymosError* out_error);
+extern int thymos_agent_query_cache_stats(const void* handle, uint64_t* out_hits, uint64_t* out_misses, ThymosError* out_error);
+extern void* thymos_agent_search_memories_reinforced(const void* handle, const char* query, size_t limit, void** out_reinforced, ThymosError* out_error);
+extern void* thymos_agent_search_grouped(const void* handle, const char* query, size_t limit, const char* group_by, void** out_keys, ThymosError* out_error);
+extern void* thymos_agent_search_reranked(const void* handle, const char* query, size_t limit, const char* formula, ThymosError* out_error);
+extern void* thymos_agent_search_explained(const void* handle, const char* query, size_t limit, void** out_components, ThymosError* out_error);
+extern void* thymos_agent_search_hybrid(const void* handle, const char* query, size_t limit, double alpha, ThymosError* out_error);
+extern void* thymos_agent_search_private(const void* handle, const char* query, size_t limit, ThymosError* out_error);
+extern void* thymos_agent_search_shared(const void* handle, const char* query, size_t limit, ThymosError* out_error);
+extern void* thymos_agent_search_scoped(const void* handle, const char* query, size_t limit, ThymosError* out_error);
+extern void* thymos_cancel_token_new(void);
+extern void thymos_cancel_token_cancel(const void* token);
+extern void thymos_cancel_token_free(void* token);
+extern void* thymos_agent_search_memories_cancelable(const void* handle, const char* query, size_t limit, const void* token, ThymosError* out_error);
+extern void* thymos_agent_search_memories_threshold(const void* handle, const char* query, size_t limit, double min_score, ThymosError* out_error);
+extern void* thymos_agent_search_many(const void* handle, const char* const* queries, size_t count, size_t limit, size_t* out_counts, ThymosError* out_error);
+extern void* thymos_agent_search_within(const void* handle, const char* query, const char* const* allowed_ids, size_t allowed_count, size_t limit, ThymosError* out_error);
+extern void* thymos_agent_search_by_type(const void* handle, const char* query, int memory_type, size_t limit, ThymosError* out_error);
+extern void* thymos_agent_search_in_range(const void* handle, const char* query, int64_t since_ms, int64_t until_ms, size_t limit, ThymosError* out_error);
+extern void* thymos_agent_get_memory(const void* handle, const char* memory_id, ThymosError* out_error);
+extern int thymos_agent_has_memory(const void* handle, const char* memory_id, ThymosError* out_error);
+extern void* thymos_agent_get_memories(const void* handle, const char** memory_ids, size_t count, ThymosError* out_error);
+extern char* thymos_agent_fingerprint(const void* handle, const char* memory_id, ThymosError* out_error);
+extern void* thymos_agent_list_memories(const void* handle, size_t offset, size_t limit, ThymosError* out_error);
+extern void* thymos_agent_modified_since(const void* handle, int64_t since_ms, size_t limit, ThymosError* out_error);
+extern void* thymos_agent_deleted_since(const void* handle, int64_t since_ms, ThymosError* out_error);
+extern int64_t thymos_agent_memory_count(const void* handle, ThymosError* out_error);
+extern int64_t thymos_agent_memory_count_by_type(const void* handle, int memory_type, ThymosError* out_error);
 extern void thymos_free_memory(void* m);
 extern void thymos_free_search_results(void* results);
 
+// Blob attachments
+extern int thymos_agent_attach_blob(const void* handle, const char* memory_id, const char* name, const uint8_t* data, size_t len, ThymosError* out_error);
+extern uint8_t* thymos_agent_get_blob(const void* handle, const char* memory_id, const char* name, size_t* out_len, ThymosError* out_error);
+
+// Saved queries
+extern int thymos_agent_save_query(const void* handle, const char* name, const char* query_json, ThymosError* out_error);
+extern void* thymos_agent_run_saved_query(const void* handle, const char* name, size_t limit, ThymosError* out_error);
+extern void* thymos_agent_list_saved_queries(const void* handle, ThymosError* out_error);
+
+// Embedding repair
+extern void* thymos_agent_unembedded(const void* handle, size_t limit, ThymosError* out_error);
+extern int64_t thymos_agent_embed_pending(const void* handle, ThymosError* out_error);
+
+// Contradiction detection
+extern void* thymos_agent_contradictions(const void* handle, size_t limit, double** out_confidences, ThymosError* out_error);
+
+// Graph export
+extern char* thymos_agent_export_graph(const void* handle, ThymosError* out_error);
+
+// Concept index
+extern int thymos_agent_rebuild_concepts(const void* handle, size_t batch_size, uint64_t* out_done, uint64_t* out_total, ThymosError* out_error);
+extern char* thymos_agent_get_concepts(const void* handle, const char* memory_id, ThymosError* out_error);
+extern void* thymos_agent_related_memories(const void* handle, const char* memory_id, size_t limit, ThymosError* out_error);
+
+// Knowledge overlap
+extern int thymos_knowledge_overlap(const void* a, const void* b, double* out_score, ThymosError* out_error);
+
+// Embedding outliers
+extern void* thymos_agent_embedding_outliers(const void* handle, size_t limit, ThymosError* out_error);
+
+// Agent merge
+extern int64_t thymos_merge_agents(const void* dst, const void* src, int on_conflict, ThymosError* out_error);
+
+// Shared batches
+extern void* thymos_shared_batch_commit(const void* const* handles, const char* const* contents, size_t count, ThymosError* out_error);
+
+// Ingest benchmark
+extern char* thymos_agent_benchmark_ingest(const void* handle, const char* const* contents, size_t count, ThymosError* out_error);
+
+// Deallocation
+extern void thymos_free_string_list(void* list);
+extern void thymos_free_bytes(uint8_t* data, size_t len);
+extern void thymos_free_doubles(double* data, size_t len);
+extern void thymos_free_floats(float* data, size_t len);
+
+// Forgetting
+extern int64_t thymos_agent_prune(const void* handle, double threshold, ThymosError* out_error);
+extern void* thymos_agent_recently_forgotten(const void* handle, size_t limit, ThymosError* out_error);
+extern int thymos_agent_restore_memory(const void* handle, const char* memory_id, ThymosError* out_error);
+extern int64_t thymos_agent_compact(const void* handle, ThymosError* out_error);
+extern int thymos_agent_suggest_forgetting(const void* handle, uint64_t* out_reuse_interval_ms, double* out_decay_rate, double* out_retention_floor, size_t* out_sample_size, ThymosError* out_error);
+extern int thymos_agent_project_retention(const void* handle, const char* memory_id, int64_t at_ms, double* out_strength, ThymosError* out_error);
+extern int thymos_agent_reinforce(const void* handle, const char* memory_id, ThymosError* out_error);
+
+// Lifecycle state
+extern char* thymos_agent_export_lifecycle(const void* handle, ThymosError* out_error);
+extern int thymos_agent_import_lifecycle(const void* handle, const char* state_json, ThymosError* out_error);
+
+// Store analytics
+extern char* thymos_agent_count_history(const void* handle, int64_t since_ms, int64_t interval_ms, ThymosError* out_error);
+extern char* thymos_agent_access_timeline(const void* handle, const char* memory_id, ThymosError* out_error);
+extern int thymos_agent_age_histogram(const void* handle, const int64_t* boundaries_ms, size_t boundary_count, size_t* out_counts, ThymosError* out_error);
+extern char* thymos_agent_dominant_language(const void* handle, double* out_fraction, ThymosError* out_error);
+extern char* thymos_agent_term_frequencies(const void* handle, size_t top_n, ThymosError* out_error);
+
+// Pub/sub
+extern void* thymos_broker_new(ThymosError* out_error);
+extern int thymos_broker_publish(const void* broker, const char* sender, const char* topic, const char* message, ThymosError* out_error);
+extern void* thymos_subscription_new(void);
+extern uint64_t thymos_subscription_add(const void* subscription, const void* broker, const char* topic, ThymosError* out_error);
+extern int thymos_subscription_remove(const void* subscription, uint64_t id, ThymosError* out_error);
+extern void thymos_broker_free(void* broker);
+extern int thymos_subscription_next(const void* subscription, uint64_t timeout_ms, char** out_json, ThymosError* out_error);
+extern void thymos_subscription_free(void* subscription);
+
+// Memory import and export
+extern int thymos_agent_import_memories(const void* handle, const char* records_json, size_t* out_imported, ThymosError* out_error);
+extern void* thymos_agent_memory_cursor(const void* handle, ThymosError* out_error);
+extern void* thymos_agent_memory_cursor_next(const void* handle, void* cursor, size_t limit, ThymosError* out_error);
+extern void thymos_memory_cursor_free(void* cursor);
+
+// Conversations
+extern char* thymos_agent_start_conversation(const void* handle, ThymosError* out_error);
+extern char* thymos_agent_remember_in_conversation(const void* handle, const char* conversation_id, const char* content, ThymosError* out_error);
+extern void* thymos_agent_conversation_history(const void* handle, const char* conversation_id, ThymosError* out_error);
+
+// Agent stats
+extern char* thymos_agent_stats(const void* handle, ThymosError* out_error);
+
+// Memory expiry
+extern char* thymos_agent_remember_with_ttl(const void* handle, const char* content, uint64_t ttl_ms, ThymosError* out_error);
+extern char* thymos_agent_remember_with_ttl_cancelable(const void* handle, const char* content, uint64_t ttl_ms, const void* token, ThymosError* out_error);
+extern int thymos_agent_set_memory_ttl(const void* handle, const char* memory_id, uint64_t ttl_ms, ThymosError* out_error);
+
+// Tags
+extern char* thymos_agent_remember_with_tags(const void* handle, const char* content, const char** tags, size_t count, ThymosError* out_error);
+extern char* thymos_agent_remember_with_tags_cancelable(const void* handle, const char* content, const char** tags, size_t count, const void* token, ThymosError* out_error);
+extern void* thymos_agent_search_by_tags(const void* handle, const char** tags, size_t count, bool match_all, size_t limit, ThymosError* out_error);
+
+// Summarization
+extern char* thymos_agent_summarize(const void* handle, const char* query, size_t max_memories, bool store, ThymosError* out_error);
+
+// Embeddings
+extern float* thymos_agent_get_embedding(const void* handle, const char* memory_id, size_t* out_len, ThymosError* out_error);
+extern float* thymos_agent_embed_text(const void* handle, const char* text, size_t* out_len, ThymosError* out_error);
+
+// Clearing
+extern int64_t thymos_agent_clear(const void* handle, ThymosError* out_error);
+
+// Logging
+typedef void (*thymos_log_fn)(int level, const char* message);
+extern int thymos_set_log_callback(thymos_log_fn callback, ThymosError* out_error);
+extern void thymosGoLog(int level, char* message);
+
+// Scope changes
+extern int thymos_agent_promote_to_shared(const void* handle, const char* memory_id, ThymosError* out_error);
+extern int thymos_agent_demote_to_private(const void* handle, const char* memory_id, ThymosError* out_error);
+
+// Idempotent writes
+extern char* thymos_agent_remember_idempotent(const void* handle, const char* key, const char* content, ThymosError* out_error);
+
+// Deduplication
+extern void* thymos_agent_find_duplicates(const void* handle, double threshold, size_t limit, void** out_keys, ThymosError* out_error);
+extern int64_t thymos_agent_deduplicate(const void* handle, double threshold, ThymosError* out_error);
+
 // Utilities
 extern char* thymos_version(void);
 
@@ -93,6 +286,12 @@ typedef struct {
     char* properties_json;
     char* created_at;
     char* last_accessed;
+    double score;
+    int memory_type;
+    double strength;
+    char* tags_json;
+    int scope;
+    uint64_t access_count;
 } ThymosMemory;
 
 typedef struct {
@@ -101,6 +300,12 @@ typedef struct {
     size_t capacity;
 } ThymosSearchResults;
 
+typedef struct {
+    char** items;
+    size_t count;
+    size_t capacity;
+} ThymosStringList;
+
 typedef struct {
     char* status;
     char* started_at;
@@ -110,45 +315,199 @@ typedef struct {
 */
 import "C"
 import (
+	"cmp"
+	"context"
+	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"path"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
 	"unsafe"
+	"weak"
+
+	"github.com/blakebarnett/thymos-go/thymosapi"
+)
+
+// ErrorCode classifies an Error returned by the native library
+type ErrorCode int
+
+// Error codes reported by the native library. The values mirror the
+// THYMOS_ERROR_* codes documented in thymos.h.
+const (
+	ErrCodeUnknown         ErrorCode = 0 // No code was recorded
+	ErrCodeInternal        ErrorCode = 1 // Unexpected internal failure
+	ErrCodeInvalidArgument ErrorCode = 2 // Bad input (invalid UTF-8, malformed JSON, etc.)
+	ErrCodeStorage         ErrorCode = 3 // Storage or I/O failure
+	ErrCodeNotFound        ErrorCode = 4 // The requested item does not exist
+	ErrCodeNotHybrid       ErrorCode = 5 // Hybrid-only operation on a non-hybrid agent
+	ErrCodeConfiguration   ErrorCode = 6 // The operation is not enabled by the agent's configuration
+	ErrCodeCanceled        ErrorCode = 7 // The operation was canceled
+	ErrCodeReadOnly        ErrorCode = 8 // Write to an agent opened with OpenAgentReadOnly
+	ErrCodeNoLLM           ErrorCode = 9 // The operation needs an LLM provider and the agent has none
 )
 
 // Error represents a Thymos error
 type Error struct {
 	Message string
+	Code    ErrorCode
 }
 
 func (e *Error) Error() string {
 	return e.Message
 }
 
+// Is reports whether the error matches target, so that errors.Is(err,
+// ErrReadOnly) holds for writes the native library rejected as read-only
+func (e *Error) Is(target error) bool {
+	return target == ErrReadOnly && e.Code == ErrCodeReadOnly
+}
+
+// errorCode returns the code of a Thymos error, or ErrCodeUnknown
+func errorCode(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ErrCodeUnknown
+}
+
 // ErrNilHandle is returned when an operation is attempted on a closed agent
-var ErrNilHandle = errors.New("thymos: agent handle is nil (agent may be closed)")
+var ErrNilHandle = thymosapi.ErrNilHandle
+
+// ErrCountHistoryUnavailable is returned by CountHistory when count sampling
+// is not enabled (see MemoryConfig.SetCountSampling)
+var ErrCountHistoryUnavailable = errors.New("thymos: count history not available (enable MemoryConfig.SetCountSampling)")
+
+// ErrAccessTimelineUnavailable is returned by AccessTimeline when access
+// tracking is not enabled (see MemoryConfig.SetTrackAccessTimeline)
+var ErrAccessTimelineUnavailable = errors.New("thymos: access timeline not available (enable MemoryConfig.SetTrackAccessTimeline)")
+
+// ErrNoEmbedding is returned by GetEmbedding for a memory stored without an
+// embedding (see UnembeddedMemories)
+var ErrNoEmbedding = errors.New("thymos: memory has no embedding")
+
+// ErrSummarizationUnavailable is returned by Summarize and
+// SummarizeAndRemember when the agent has no LLM provider to summarize with
+var ErrSummarizationUnavailable = errors.New("thymos: summarization not available (no LLM provider configured)")
+
+// ErrNilConfig is returned when a setter is called on a closed configuration
+var ErrNilConfig = errors.New("thymos: config handle is nil (config may be closed)")
+
+// ErrInvalidConfig is returned by Config.Validate and MemoryConfig.Validate
+// for a configuration that cannot create an agent
+var ErrInvalidConfig = errors.New("thymos: invalid configuration")
+
+// ErrInvalidStatus is returned by SetStatus for a value that is not one of
+// the Status constants
+var ErrInvalidStatus = errors.New("thymos: invalid status")
 
 // ErrNotHybridMode is returned when a hybrid-only operation is called on a non-hybrid agent
 var ErrNotHybridMode = errors.New("thymos: operation only available in hybrid mode")
 
-// getLastError retrieves the last error from the Rust side
-func getLastError() error {
-	errPtr := C.thymos_get_last_error()
-	if errPtr == nil {
+// ErrMemoryNotFound is returned when no memory has the requested ID
+var ErrMemoryNotFound = thymosapi.ErrMemoryNotFound
+
+// ErrStoreNotFound is returned by OpenAgent when there is no agent store at
+// the given path
+var ErrStoreNotFound = errors.New("thymos: no agent store found")
+
+// ErrReadOnly is returned by write methods of an agent opened with
+// OpenAgentReadOnly
+var ErrReadOnly = errors.New("thymos: agent is read-only")
+
+// ErrNoDataDir is returned by DataDir and SearchMemoriesByTags for agents in
+// server mode, which keep no local store
+var ErrNoDataDir = errors.New("thymos: agent has no local data directory (server mode)")
+
+// ErrSavedQueryNotFound is returned when no saved query has the requested name
+var ErrSavedQueryNotFound = errors.New("thymos: saved query not found")
+
+// ErrNilBroker is returned when an operation is attempted on a closed broker
+var ErrNilBroker = errors.New("thymos: broker handle is nil (broker may be closed)")
+
+// ErrNotRegistered is returned by Agent.Publish and Agent.Subscribe when the
+// agent has not been registered with a Broker
+var ErrNotRegistered = errors.New("thymos: agent is not registered with a broker")
+
+// ErrInvalidLimit is returned by SearchMemories and its variants for a
+// negative limit other than Unlimited
+var ErrInvalidLimit = thymosapi.ErrInvalidLimit
+
+// ErrContentTooLarge is returned, wrapped with the content's length, by the
+// methods that store content when it is longer than MaxContentLength
+var ErrContentTooLarge = thymosapi.ErrContentTooLarge
+
+// ErrBatchDone is returned when a SharedBatch is used after Commit or Discard
+var ErrBatchDone = errors.New("thymos: shared batch already committed or discarded")
+
+// MaxContentLength is the longest memory content, in bytes, that Remember and
+// the other write methods accept; use RememberChunked for longer text
+const MaxContentLength = thymosapi.MaxContentLength
+
+// nativeCall starts a function's native calls, which report their errors in
+// cErr, and returns the function that ends them
+//
+// Each native call that can fail takes a ThymosError that receives its own
+// error, so concurrent calls never see each other's errors. The returned
+// function frees any error message left in cErr that takeError did not
+// convert. Every function that makes such calls must start with
+//
+//	var cErr C.ThymosError
+//	defer nativeCall(&cErr)()
+//
+// While a logger is set (see SetLogger), it also logs the call's entry and,
+// from the returned function, its duration and error code.
+func nativeCall(cErr *C.ThymosError) func() {
+	log := logger.Load()
+	if log == nil {
+		return func() { freeError(cErr) }
+	}
+	name := "unknown"
+	if pc, _, _, ok := runtime.Caller(1); ok {
+		// Drop the package, whose base is "thymos-go", from "thymos-go.(*Agent).X"
+		_, name, _ = strings.Cut(path.Base(runtime.FuncForPC(pc).Name()), ".")
+	}
+	(*log)("debug", "thymos: enter "+name)
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		code := ErrorCode(cErr.code)
+		(*log)("debug", fmt.Sprintf("thymos: exit %s after %s (error code %d)", name, elapsed, code))
+		freeError(cErr)
+	}
+}
+
+// takeError converts the error a native call reported in cErr, or returns
+// nil if it reported none
+func takeError(cErr *C.ThymosError) error {
+	if cErr.message == nil {
 		return nil
 	}
-	errMsg := C.GoString(errPtr)
+	errMsg := C.GoString(cErr.message)
+	freeError(cErr)
 	if errMsg == "" {
 		return nil
 	}
-	return &Error{Message: errMsg}
+	return &Error{Message: errMsg, Code: ErrorCode(cErr.code)}
 }
 
-// clearError clears the last error
-func clearError() {
-	C.thymos_clear_error()
+// freeError frees the message of the error in cErr, keeping its code
+func freeError(cErr *C.ThymosError) {
+	if cErr.message != nil {
+		C.thymos_free_string(cErr.message)
+		cErr.message = nil
+	}
 }
 
 // Version returns the Thymos library version
@@ -161,6 +520,141 @@ func Version() string {
 	return C.GoString(cVersion)
 }
 
+// SemVer is a parsed semantic version, such as 1.2.0-beta.1+build.5
+type SemVer struct {
+	Major, Minor, Patch int
+	// PreRelease is the part after "-", such as "beta.1"; empty for releases
+	PreRelease string
+	// Build is the build metadata after "+"; it never affects ordering
+	Build string
+}
+
+// VersionInfo returns the library version parsed from Version
+func VersionInfo() (SemVer, error) {
+	return ParseSemVer(Version())
+}
+
+// ParseSemVer parses a MAJOR.MINOR.PATCH version with optional
+// "-prerelease" and "+build" suffixes; a leading "v" is accepted
+func ParseSemVer(s string) (SemVer, error) {
+	var v SemVer
+	var hasPre, hasBuild bool
+	rest := strings.TrimPrefix(s, "v")
+	rest, v.Build, hasBuild = strings.Cut(rest, "+")
+	rest, v.PreRelease, hasPre = strings.Cut(rest, "-")
+	if hasPre && v.PreRelease == "" || hasBuild && v.Build == "" {
+		return SemVer{}, fmt.Errorf("thymos: invalid version %q: empty suffix", s)
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return SemVer{}, fmt.Errorf("thymos: invalid version %q: want MAJOR.MINOR.PATCH", s)
+	}
+	nums := [3]*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return SemVer{}, fmt.Errorf("thymos: invalid version %q: bad number %q", s, part)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// String formats the version as MAJOR.MINOR.PATCH[-prerelease][+build]
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 as v orders before, equal to or after o
+//
+// Ordering follows semantic versioning: a pre-release orders before its
+// release (1.2.0-beta < 1.2.0) and build metadata is ignored.
+func (v SemVer) Compare(o SemVer) int {
+	if c := cmp.Compare(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	switch {
+	case v.PreRelease == o.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case o.PreRelease == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.PreRelease, "."), strings.Split(o.PreRelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		an, aErr := strconv.Atoi(a[i])
+		bn, bErr := strconv.Atoi(b[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := cmp.Compare(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1 // numeric identifiers order before alphanumeric ones
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// AtLeast reports whether v is major.minor.patch or later
+//
+// A pre-release of that version is not at least it, matching Compare.
+func (v SemVer) AtLeast(major, minor, patch int) bool {
+	return v.Compare(SemVer{Major: major, Minor: minor, Patch: patch}) >= 0
+}
+
+// convertCStringList copies a ThymosStringList into a Go slice
+func convertCStringList(listPtr unsafe.Pointer) []string {
+	list := (*C.ThymosStringList)(listPtr)
+	if list.count == 0 {
+		return []string{}
+	}
+
+	items := (*[1 << 28]*C.char)(unsafe.Pointer(list.items))[:list.count:list.count]
+	strs := make([]string, 0, list.count)
+	for _, item := range items {
+		strs = append(strs, C.GoString(item))
+	}
+	return strs
+}
+
+// newCStringArray copies strs into C strings
+//
+// The returned free function must be called to release them.
+func newCStringArray(strs []string) ([]*C.char, func()) {
+	cStrs := make([]*C.char, len(strs))
+	for i, str := range strs {
+		cStrs[i] = C.CString(str)
+	}
+	return cStrs, func() {
+		for _, cStr := range cStrs {
+			C.free(unsafe.Pointer(cStr))
+		}
+	}
+}
+
 // ============================================================================
 // Configuration
 // ============================================================================
@@ -185,12 +679,15 @@ func NewMemoryConfig() *MemoryConfig {
 
 // NewMemoryConfigWithDataDir creates a memory configuration with a custom data directory
 func NewMemoryConfigWithDataDir(dataDir string) (*MemoryConfig, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
 	cDataDir := C.CString(dataDir)
 	defer C.free(unsafe.Pointer(cDataDir))
 
-	handle := C.thymos_memory_config_with_data_dir(cDataDir)
+	handle := C.thymos_memory_config_with_data_dir(cDataDir, &cErr)
 	if handle == nil {
-		return nil, getLastError()
+		return nil, takeError(&cErr)
 	}
 
 	config := &MemoryConfig{handle: handle}
@@ -198,6 +695,267 @@ func NewMemoryConfigWithDataDir(dataDir string) (*MemoryConfig, error) {
 	return config, nil
 }
 
+// SetForgetGracePeriod sets how long forgotten memories stay recoverable
+//
+// Memories removed by Prune can be listed with RecentlyForgotten and brought
+// back with RestoreMemory until the grace period elapses. A grace period of 0
+// purges them immediately. The default is one hour.
+func (c *MemoryConfig) SetForgetGracePeriod(grace time.Duration) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if grace < 0 {
+		return errors.New("thymos: forget grace period must not be negative")
+	}
+
+	result := C.thymos_memory_config_set_forget_grace_period(c.handle, C.uint64_t(grace.Milliseconds()), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// SetCountSampling records the memory count every interval for CountHistory
+//
+// Sampling is disabled by default; an interval of 0 disables it. Intervals
+// below one second are raised to one second.
+func (c *MemoryConfig) SetCountSampling(interval time.Duration) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if interval < 0 {
+		return errors.New("thymos: count sampling interval must not be negative")
+	}
+
+	result := C.thymos_memory_config_set_count_sampling(c.handle, C.uint64_t(interval.Milliseconds()), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// SetQueryCache caches up to size SearchMemories results by (query, limit)
+//
+// Cached results expire after ttl, and any write through the agent clears the
+// cache. Writes by other agents to a shared backend are not seen until entries
+// expire. A size of 0 disables the cache; otherwise ttl must be positive.
+func (c *MemoryConfig) SetQueryCache(size int, ttl time.Duration) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if size < 0 {
+		return errors.New("thymos: query cache size must not be negative")
+	}
+	if ttl < 0 {
+		return errors.New("thymos: query cache ttl must not be negative")
+	}
+
+	result := C.thymos_memory_config_set_query_cache(c.handle, C.size_t(size), ttlMillis(ttl), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// SetMaxBlobSize sets the largest blob AttachBlob accepts (default 16 MiB)
+func (c *MemoryConfig) SetMaxBlobSize(maxBytes int) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if maxBytes < 0 {
+		return errors.New("thymos: max blob size must not be negative")
+	}
+
+	result := C.thymos_memory_config_set_max_blob_size(c.handle, C.size_t(maxBytes), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// SetTrackAccessTimeline records a timestamp for every memory access, for
+// AccessTimeline
+//
+// Tracking is off by default; it keeps up to 10,000 timestamps per memory in
+// memory for the life of the agent.
+func (c *MemoryConfig) SetTrackAccessTimeline(enabled bool) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+
+	result := C.thymos_memory_config_set_track_access_timeline(c.handle, C.bool(enabled), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// SetMaxMemories caps the number of memories the agent keeps
+//
+// After an insert that takes the local store (the private backend in hybrid
+// mode) beyond n memories, its weakest memories beyond n are forgotten; they
+// stay recoverable with RestoreMemory for the forget grace period. Shared
+// memories are not counted. A value of 0 removes the cap.
+func (c *MemoryConfig) SetMaxMemories(n int) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if n < 0 {
+		return errors.New("thymos: max memories must not be negative")
+	}
+
+	result := C.thymos_memory_config_set_max_memories(c.handle, C.size_t(n), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// SetEmbeddingBatchSize sets how many contents RememberBatch, ImportFile and
+// KnowledgeOverlap embed per call to the embedding provider (default 32)
+//
+// Batching applies when the agent has an embedding provider and a local
+// store. Larger batches give batched and GPU-backed providers more work per
+// call, raising throughput, but hold a whole batch of embeddings in memory at
+// once, delay the first stored memory until its batch is embedded, and fail
+// every item of a batch whose embedding call fails. n must be at least 1.
+func (c *MemoryConfig) SetEmbeddingBatchSize(n int) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if n < 1 {
+		return errors.New("thymos: embedding batch size must be at least 1")
+	}
+
+	result := C.thymos_memory_config_set_embedding_batch_size(c.handle, C.size_t(n), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// SetDecayRate sets the base rate at which memory strength decays with age
+// (default 0.01 per hour)
+func (c *MemoryConfig) SetDecayRate(r float64) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if r < 0 || math.IsNaN(r) || math.IsInf(r, 0) {
+		return errors.New("thymos: decay rate must be a non-negative number")
+	}
+
+	result := C.thymos_memory_config_set_decay_rate(c.handle, C.double(r), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// SetForgetThreshold forgets memories whose strength falls below t after
+// inserts, as if Prune(t) ran automatically
+//
+// Strengths change slowly, so the check runs after an insert at most once a
+// minute. Forgotten memories stay recoverable with RestoreMemory for the
+// forget grace period. A threshold of 0 disables automatic forgetting.
+func (c *MemoryConfig) SetForgetThreshold(t float64) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if t < 0 || t > 1 || math.IsNaN(t) {
+		return errors.New("thymos: forget threshold must be between 0 and 1")
+	}
+
+	result := C.thymos_memory_config_set_forget_threshold(c.handle, C.double(t), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// Validate checks the configuration without creating an agent
+//
+// It returns an error wrapping ErrInvalidConfig that names the first problem,
+// such as "data_dir must not be empty". Servers and stores are not contacted,
+// so agent creation can still fail for a valid configuration.
+func (c *MemoryConfig) Validate() error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+
+	if C.thymos_memory_config_validate(c.handle, &cErr) != 0 {
+		return configError(takeError(&cErr))
+	}
+	return nil
+}
+
+// configError wraps a configuration validation failure in ErrInvalidConfig
+func configError(err error) error {
+	if errorCode(err) == ErrCodeConfiguration {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, err)
+	}
+	return err
+}
+
 // Close releases the memory configuration resources
 func (c *MemoryConfig) Close() {
 	c.mu.Lock()
@@ -232,9 +990,12 @@ func NewConfig() *Config {
 // Searches for thymos.toml, thymos.yaml, or thymos.json in standard locations.
 // Environment variables with THYMOS_ prefix override file settings.
 func LoadConfig() (*Config, error) {
-	handle := C.thymos_config_load()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	handle := C.thymos_config_load(&cErr)
 	if handle == nil {
-		return nil, getLastError()
+		return nil, takeError(&cErr)
 	}
 
 	config := &Config{handle: handle}
@@ -244,12 +1005,15 @@ func LoadConfig() (*Config, error) {
 
 // LoadConfigFromFile loads configuration from a specific file
 func LoadConfigFromFile(path string) (*Config, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
-	handle := C.thymos_config_load_from_file(cPath)
+	handle := C.thymos_config_load_from_file(cPath, &cErr)
 	if handle == nil {
-		return nil, getLastError()
+		return nil, takeError(&cErr)
 	}
 
 	config := &Config{handle: handle}
@@ -257,103 +1021,5164 @@ func LoadConfigFromFile(path string) (*Config, error) {
 	return config, nil
 }
 
-// Close releases the configuration resources
-func (c *Config) Close() {
+// SetEmbeddingModel selects the embedding model, e.g. "bge-small-en-v1.5"
+//
+// The local embedding provider is used unless the configuration file chose
+// another. An empty name removes the embeddings configuration, so agents are
+// created without an embedding provider.
+func (c *Config) SetEmbeddingModel(name string) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.handle != nil {
-		C.thymos_free_config(c.handle)
-		c.handle = nil
+	if c.handle == nil {
+		return ErrNilConfig
 	}
-}
 
-// ============================================================================
-// Agent
-// ============================================================================
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
 
-// Agent represents a Thymos agent with memory and lifecycle management
-type Agent struct {
-	handle unsafe.Pointer
-	mu     sync.RWMutex
+	result := C.thymos_config_set_embedding_model(c.handle, cName, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
 }
 
-// NewAgent creates a new agent with the given ID using default configuration
-func NewAgent(agentID string) (*Agent, error) {
-	cAgentID := C.CString(agentID)
-	defer C.free(unsafe.Pointer(cAgentID))
+// SetEmbeddingDimensions sets the vector size the embedding model must produce
+//
+// Agent creation fails if the model's dimensions differ, catching a model
+// mismatch before any memory is embedded. 0 accepts any size. Requires an
+// embedding model (see SetEmbeddingModel).
+func (c *Config) SetEmbeddingDimensions(d int) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
 
-	handle := C.thymos_agent_new(cAgentID)
-	if handle == nil {
-		return nil, getLastError()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if d < 0 {
+		return errors.New("thymos: embedding dimensions must not be negative")
 	}
 
-	agent := &Agent{handle: handle}
-	runtime.SetFinalizer(agent, (*Agent).Close)
-	return agent, nil
+	result := C.thymos_config_set_embedding_dimensions(c.handle, C.size_t(d), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
 }
 
-// NewAgentWithMemoryConfig creates a new agent with custom memory configuration
-func NewAgentWithMemoryConfig(agentID string, config *MemoryConfig) (*Agent, error) {
-	if config == nil || config.handle == nil {
-		return nil, errors.New("thymos: memory config is nil")
-	}
+// EmbeddingModel returns the configured embedding model, or "" if none
+func (c *Config) EmbeddingModel() (string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
 
-	cAgentID := C.CString(agentID)
-	defer C.free(unsafe.Pointer(cAgentID))
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	handle := C.thymos_agent_new_with_memory_config(cAgentID, config.handle)
-	if handle == nil {
-		return nil, getLastError()
+	if c.handle == nil {
+		return "", ErrNilConfig
 	}
 
-	agent := &Agent{handle: handle}
-	runtime.SetFinalizer(agent, (*Agent).Close)
-	return agent, nil
+	cModel := C.thymos_config_embedding_model(c.handle, &cErr)
+	if cModel == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cModel)
+
+	return C.GoString(cModel), nil
+}
+
+// SetDataDir sets where agents created from the configuration store memories
+//
+// In hybrid mode this is the private data directory. Returns an error in
+// server mode, which has no local storage.
+func (c *Config) SetDataDir(path string) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if path == "" {
+		return errors.New("thymos: data directory must not be empty")
+	}
+
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	result := C.thymos_config_set_data_dir(c.handle, cPath, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// SetHybridMode makes agents created from the configuration keep private
+// memories locally and share memories through the Locai server at sharedURL
+//
+// The current data directory becomes the private data directory. apiKey may
+// be "" if the server needs none.
+func (c *Config) SetHybridMode(sharedURL, apiKey string) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+	if sharedURL == "" {
+		return errors.New("thymos: shared URL must not be empty")
+	}
+
+	cURL := C.CString(sharedURL)
+	defer C.free(unsafe.Pointer(cURL))
+
+	var cAPIKey *C.char
+	if apiKey != "" {
+		cAPIKey = C.CString(apiKey)
+		defer C.free(unsafe.Pointer(cAPIKey))
+	}
+
+	result := C.thymos_config_set_hybrid(c.handle, cURL, cAPIKey, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// Validate checks the configuration without creating an agent
+//
+// Memory settings are checked as by MemoryConfig.Validate, then embedding and
+// LLM settings, e.g. "embedding_dimensions must be > 0". It returns an error
+// wrapping ErrInvalidConfig that names the first problem.
+func (c *Config) Validate() error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle == nil {
+		return ErrNilConfig
+	}
+
+	if C.thymos_config_validate(c.handle, &cErr) != 0 {
+		return configError(takeError(&cErr))
+	}
+	return nil
+}
+
+// Close releases the configuration resources
+func (c *Config) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.handle != nil {
+		C.thymos_free_config(c.handle)
+		c.handle = nil
+	}
+}
+
+// ============================================================================
+// Agent
+// ============================================================================
+
+// Agent represents a Thymos agent with memory and lifecycle management
+type Agent struct {
+	handle unsafe.Pointer
+	id     string
+	// liveID is the agent's key in liveAgents
+	liveID uint64
+	// readOnly makes write methods return ErrReadOnly; it is set before the
+	// agent is returned and never changes
+	readOnly bool
+	// mu guards handle, not the native agent: every call, reading or
+	// mutating, holds the read lock so it cannot race Close, which takes
+	// the write lock to free the handle. The native agent synchronizes its
+	// own state and serializes read-modify-write updates of a memory (see
+	// the Thread Safety notes in src/lib.rs).
+	mu      sync.RWMutex
+	pending atomic.Int64
+
+	maintMu sync.Mutex
+	maint   *maintenance
+
+	brokerMu sync.Mutex
+	broker   *Broker
+	inbox    *inbox
+
+	statusMu   sync.Mutex
+	statusFns  map[uint64]func(old, new Status)
+	statusNext uint64
+	statusStop chan struct{}
+
+	// notifyMu guards statusLast and the status changes waiting for callbacks
+	notifyMu        sync.Mutex
+	statusLast      Status
+	statusQueue     []statusChange
+	statusNotifying bool
+}
+
+// globalPending counts in-flight writes across all agents
+var globalPending atomic.Int64
+
+// trackWrite counts a write for PendingWrites and GlobalQueueDepth
+//
+// Call it before acquiring the agent lock so queued writes are included, and
+// call the returned function when the write completes.
+func (a *Agent) trackWrite() func() {
+	a.pending.Add(1)
+	globalPending.Add(1)
+	return func() {
+		a.pending.Add(-1)
+		globalPending.Add(-1)
+	}
+}
+
+// PendingWrites returns the number of writes queued or in progress on this agent
+func (a *Agent) PendingWrites() int {
+	return int(a.pending.Load())
+}
+
+// indexPollInterval is how often WaitForIndexing checks for in-flight writes
+const indexPollInterval = 10 * time.Millisecond
+
+// WaitForIndexing blocks until no writes are queued or in progress on this
+// agent, or ctx is done
+//
+// Embedding is not queued: Remember and the other writes embed the memory
+// (when the agent has an embedding provider) and index it before returning,
+// so a memory is searchable as soon as the call that stored it returns.
+// WaitForIndexing is for waiting on writes started by other goroutines, such
+// as a test that stores memories concurrently and then searches. Writes that
+// start after it returns are not waited for. A memory stored while the
+// embedder was unavailable stays unembedded and only matches by keyword until
+// EmbedPending repairs it.
+func (a *Agent) WaitForIndexing(ctx context.Context) error {
+	if a.PendingWrites() == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(indexPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if a.PendingWrites() == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// GlobalQueueDepth returns the number of writes queued or in progress across
+// all agents in the process
+//
+// It is a single counter read, cheap enough to call before every write as a
+// process-wide backpressure signal.
+func GlobalQueueDepth() int {
+	return int(globalPending.Load())
+}
+
+// openAgents counts agents created and not yet closed
+var openAgents atomic.Int64
+
+var (
+	leakMu      sync.Mutex
+	leakHandler func(agentID string)
+)
+
+// OpenAgentCount returns the number of agents created and not yet closed,
+// whether by Close or by the finalizer
+func OpenAgentCount() int {
+	return int(openAgents.Load())
+}
+
+// SetLeakHandler sets a function called when the garbage collector reclaims
+// an agent that was never closed
+//
+// The handler receives the agent's ID and runs on the finalizer goroutine
+// before the agent is freed, so it must not block. Pass nil to remove the
+// handler. Use it to find agents that are missing a Close call.
+func SetLeakHandler(handler func(agentID string)) {
+	leakMu.Lock()
+	defer leakMu.Unlock()
+	leakHandler = handler
+}
+
+// liveAgents holds every agent created and not yet closed, for Shutdown
+//
+// The pointers are weak so the registry does not keep leaked agents from
+// being finalized.
+var (
+	liveMu     sync.Mutex
+	liveNext   uint64
+	liveAgents = make(map[uint64]weak.Pointer[Agent])
+)
+
+// newAgent wraps a native agent handle and counts it as open
+func newAgent(handle unsafe.Pointer, agentID string) *Agent {
+	agent := &Agent{handle: handle, id: agentID}
+	openAgents.Add(1)
+
+	liveMu.Lock()
+	liveNext++
+	agent.liveID = liveNext
+	liveAgents[agent.liveID] = weak.Make(agent)
+	liveMu.Unlock()
+
+	runtime.SetFinalizer(agent, (*Agent).finalize)
+	return agent
+}
+
+// byLiveID returns agents sorted by liveID, the order in which calls that
+// lock several agents lock them
+func byLiveID(agents []*Agent) []*Agent {
+	sorted := slices.Clone(agents)
+	slices.SortFunc(sorted, func(a, b *Agent) int {
+		return cmp.Compare(a.liveID, b.liveID)
+	})
+	return sorted
+}
+
+// Shutdown closes every open agent, for a graceful process exit
+//
+// Each agent's Close waits for its in-flight calls to finish, so when
+// Shutdown returns nil every agent is closed and its store flushed. If ctx is
+// done first, Shutdown returns ctx.Err() and the remaining closes carry on in
+// the background. Agents created while Shutdown runs are not closed.
+func Shutdown(ctx context.Context) error {
+	liveMu.Lock()
+	agents := make([]*Agent, 0, len(liveAgents))
+	for _, p := range liveAgents {
+		if agent := p.Value(); agent != nil {
+			agents = append(agents, agent)
+		}
+	}
+	liveMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for _, agent := range agents {
+			wg.Go(agent.Close)
+		}
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// finalize reports an agent that was never closed, then closes it
+func (a *Agent) finalize() {
+	if a.handle != nil {
+		leakMu.Lock()
+		handler := leakHandler
+		leakMu.Unlock()
+		if handler != nil {
+			handler(a.id)
+		}
+	}
+	a.Close()
+}
+
+// NewAgent creates a new agent with the given ID using default configuration
+func NewAgent(agentID string) (*Agent, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	cAgentID := C.CString(agentID)
+	defer C.free(unsafe.Pointer(cAgentID))
+
+	handle := C.thymos_agent_new(cAgentID, &cErr)
+	if handle == nil {
+		return nil, takeError(&cErr)
+	}
+
+	return newAgent(handle, agentID), nil
+}
+
+// NewAgentWithMemoryConfig creates a new agent with custom memory configuration
+//
+// The agent takes a copy of config, so config may be closed or changed as
+// soon as NewAgentWithMemoryConfig returns without affecting the agent.
+func NewAgentWithMemoryConfig(agentID string, config *MemoryConfig) (*Agent, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if config == nil {
+		return nil, errors.New("thymos: memory config is nil")
+	}
+
+	// Hold the config lock so a concurrent Close can't free the handle while
+	// the native side copies it
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	if config.handle == nil {
+		return nil, errors.New("thymos: memory config is nil")
+	}
+
+	cAgentID := C.CString(agentID)
+	defer C.free(unsafe.Pointer(cAgentID))
+
+	handle := C.thymos_agent_new_with_memory_config(cAgentID, config.handle, &cErr)
+	if handle == nil {
+		return nil, takeError(&cErr)
+	}
+
+	return newAgent(handle, agentID), nil
 }
 
 // NewAgentWithConfig creates a new agent with full Thymos configuration
+//
+// The agent takes a copy of config, so config may be closed or changed as
+// soon as NewAgentWithConfig returns without affecting the agent.
 func NewAgentWithConfig(agentID string, config *Config) (*Agent, error) {
-	if config == nil || config.handle == nil {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if config == nil {
+		return nil, errors.New("thymos: config is nil")
+	}
+
+	// Hold the config lock so a concurrent Close can't free the handle while
+	// the native side copies it
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	if config.handle == nil {
 		return nil, errors.New("thymos: config is nil")
 	}
 
-	cAgentID := C.CString(agentID)
-	defer C.free(unsafe.Pointer(cAgentID))
+	cAgentID := C.CString(agentID)
+	defer C.free(unsafe.Pointer(cAgentID))
+
+	handle := C.thymos_agent_new_with_config(cAgentID, config.handle, &cErr)
+	if handle == nil {
+		return nil, takeError(&cErr)
+	}
+
+	return newAgent(handle, agentID), nil
+}
+
+// OpenAgent reopens an existing agent store in dataDir
+//
+// Use it to resume an agent after a restart without rebuilding its
+// configuration. Agents with local storage save their Config or MemoryConfig
+// settings in their data directory when created, and the agent is rebuilt
+// with them, so its embedding model, query cache and retention limits carry
+// over. API keys are not saved; providers read them from the environment.
+// Only the agent that creates a store saves its settings: later agents
+// created over the same directory leave them in place.
+//
+// Stores created before settings were saved open with the default
+// configuration; reopen those with the constructor that created them if
+// they need an embedding model or other settings. Returns an error wrapping
+// ErrStoreNotFound if dataDir is missing or empty, rather than creating a new
+// store there.
+func OpenAgent(agentID, dataDir string) (*Agent, error) {
+	return openAgent(agentID, dataDir, false)
+}
+
+// openAgent reopens the store in dataDir, for reading only if readOnly
+func openAgent(agentID, dataDir string, readOnly bool) (*Agent, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	cAgentID := C.CString(agentID)
+	defer C.free(unsafe.Pointer(cAgentID))
+	cDataDir := C.CString(dataDir)
+	defer C.free(unsafe.Pointer(cDataDir))
+
+	var handle unsafe.Pointer
+	if readOnly {
+		handle = C.thymos_agent_open_read_only(cAgentID, cDataDir, &cErr)
+	} else {
+		handle = C.thymos_agent_open(cAgentID, cDataDir, &cErr)
+	}
+	if handle == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrStoreNotFound, dataDir)
+		}
+		return nil, err
+	}
+
+	agent := newAgent(handle, agentID)
+	agent.readOnly = readOnly
+	return agent, nil
+}
+
+// OpenAgentReadOnly reopens an existing agent store in dataDir for reading
+//
+// The agent is opened as by OpenAgent, but methods that write to the store or
+// the agent state, such as the Remember methods, Forget, UpdateMemory,
+// SetStatus, Prune and Clear, return ErrReadOnly, as does MergeAgents into it
+// and SharedBatch.RememberShared for it. The native library enforces the same
+// rule for every write, and starts none of the background work that writes
+// to the store: expired memories are hidden from results but not deleted,
+// and no retention limits are applied. Searches are the exception: the store
+// itself records each match's last access time, as it does for any agent,
+// and Memory.AccessCount still counts accesses in memory. The contents,
+// tags and properties of memories are never changed.
+//
+// The store is opened the same way as for writing, because Locai has no
+// shared or read-only mode: it takes the store's exclusive lock, so only one
+// process at a time can open a data directory, read-only or not. Readers in
+// other processes should open a copy of the directory or go through a Locai
+// server instead.
+func OpenAgentReadOnly(agentID, dataDir string) (*Agent, error) {
+	return openAgent(agentID, dataDir, true)
+}
+
+// AgentBuilder assembles the configuration for a new agent
+//
+// Chain its With methods and finish with Build:
+//
+//	agent, err := thymos.NewAgentBuilder().
+//		WithDataDir("/var/lib/thymos").
+//		WithEmbeddingModel("bge-small-en-v1.5").
+//		WithInitialStatus(thymos.StatusListening).
+//		Build("my_agent")
+//
+// Settings that are not given keep the NewConfig defaults.
+type AgentBuilder struct {
+	dataDir        string
+	embeddingModel *string
+	sharedURL      string
+	sharedAPIKey   string
+	initialStatus  Status
+}
+
+// NewAgentBuilder returns a builder with default settings
+func NewAgentBuilder() *AgentBuilder {
+	return &AgentBuilder{}
+}
+
+// WithDataDir sets where the agent stores memories (the private directory in
+// hybrid mode)
+func (b *AgentBuilder) WithDataDir(path string) *AgentBuilder {
+	b.dataDir = path
+	return b
+}
+
+// WithEmbeddingModel selects the embedding model, as Config.SetEmbeddingModel
+func (b *AgentBuilder) WithEmbeddingModel(name string) *AgentBuilder {
+	b.embeddingModel = &name
+	return b
+}
+
+// WithHybridMode shares memories through the Locai server at sharedURL, as
+// Config.SetHybridMode
+func (b *AgentBuilder) WithHybridMode(sharedURL, apiKey string) *AgentBuilder {
+	b.sharedURL = sharedURL
+	b.sharedAPIKey = apiKey
+	return b
+}
+
+// WithInitialStatus sets the status the agent has when Build returns it
+func (b *AgentBuilder) WithInitialStatus(status Status) *AgentBuilder {
+	b.initialStatus = status
+	return b
+}
+
+// Build creates the agent
+//
+// The intermediate configuration is released before Build returns, and the
+// agent is closed again if its initial status cannot be set, so a failed
+// Build leaves nothing open.
+func (b *AgentBuilder) Build(agentID string) (*Agent, error) {
+	config := NewConfig()
+	if config == nil {
+		return nil, errors.New("thymos: failed to create config")
+	}
+	defer config.Close()
+
+	// Switch to hybrid mode first so the data directory becomes the private one
+	if b.sharedURL != "" {
+		if err := config.SetHybridMode(b.sharedURL, b.sharedAPIKey); err != nil {
+			return nil, err
+		}
+	}
+	if b.dataDir != "" {
+		if err := config.SetDataDir(b.dataDir); err != nil {
+			return nil, err
+		}
+	}
+	if b.embeddingModel != nil {
+		if err := config.SetEmbeddingModel(*b.embeddingModel); err != nil {
+			return nil, err
+		}
+	}
+
+	agent, err := NewAgentWithConfig(agentID, config)
+	if err != nil {
+		return nil, err
+	}
+	if b.initialStatus != "" {
+		if err := agent.SetStatus(b.initialStatus); err != nil {
+			agent.Close()
+			return nil, err
+		}
+	}
+	return agent, nil
+}
+
+// Fork creates an independent copy of the agent under a new ID
+//
+// The copy is eager: every memory, with its ID and timestamps, is copied into
+// a new data directory next to the agent's, named
+// "<data dir>.fork-<newAgentID>", along with attached blobs and saved
+// queries. Expect the fork to take about as much disk space as the parent
+// and the call to take time proportional to the store size. The fork keeps
+// the parent's embedding provider and memory options but shares no storage,
+// so later changes to either agent do not affect the other. Only embedded
+// mode agents can be forked.
+func (a *Agent) Fork(newAgentID string) (*Agent, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cAgentID := C.CString(newAgentID)
+	defer C.free(unsafe.Pointer(cAgentID))
+
+	handle := C.thymos_agent_fork(a.handle, cAgentID, &cErr)
+	if handle == nil {
+		return nil, takeError(&cErr)
+	}
+
+	return newAgent(handle, newAgentID), nil
+}
+
+// Close releases the agent resources
+//
+// After Close is called, all methods will return ErrNilHandle.
+// Close is idempotent and safe to call multiple times.
+func (a *Agent) Close() {
+	// Stop maintenance first: its tasks need the agent lock to finish
+	a.StopMaintenance()
+	a.stopStatusPoll()
+
+	a.mu.Lock()
+	if a.handle != nil {
+		C.thymos_free_agent(a.handle)
+		a.handle = nil
+		openAgents.Add(-1)
+
+		liveMu.Lock()
+		delete(liveAgents, a.liveID)
+		liveMu.Unlock()
+	}
+	a.mu.Unlock()
+
+	// Subscriptions don't use the agent handle, so they can stop after it is
+	// freed; Subscribe sees the nil handle and adds no more.
+	a.stopSubscriptions()
+}
+
+// IsReadOnly reports whether the agent was opened with OpenAgentReadOnly
+func (a *Agent) IsReadOnly() bool {
+	return a.readOnly
+}
+
+// IsClosed returns true if the agent has been closed
+func (a *Agent) IsClosed() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.handle == nil
+}
+
+// ID returns the agent's unique identifier
+func (a *Agent) ID() (string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	cID := C.thymos_agent_id(a.handle, &cErr)
+	if cID == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
+}
+
+// Rename changes the agent's ID in place, keeping its memories and storage
+//
+// Unlike Fork, nothing is copied: ID returns newID afterwards and the agent
+// keeps its data directory. Stores are found by data directory rather than by
+// ID, so the only store an ID names is the directory Fork(newID) would create,
+// "<data dir>.fork-<newID>"; Rename fails if it exists. Only embedded mode
+// agents can be renamed. Rename waits for in-flight calls on the agent to
+// finish and blocks new ones until it returns.
+func (a *Agent) Rename(newID string) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cAgentID := C.CString(newID)
+	defer C.free(unsafe.Pointer(cAgentID))
+
+	result := C.thymos_agent_rename(a.handle, cAgentID, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	a.id = newID
+	return nil
+}
+
+// Description returns the agent's description
+func (a *Agent) Description() (string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	cDesc := C.thymos_agent_description(a.handle, &cErr)
+	if cDesc == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cDesc)
+
+	return C.GoString(cDesc), nil
+}
+
+// SetDescription changes the agent's description
+//
+// The description is kept in the agent state, so it is also visible in
+// State().Properties. An empty string restores the description the agent was
+// created with.
+func (a *Agent) SetDescription(desc string) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cDesc := C.CString(desc)
+	defer C.free(unsafe.Pointer(cDesc))
+
+	result := C.thymos_agent_set_description(a.handle, cDesc, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// Persona returns the agent's persona, or "" if none is set
+//
+// The persona is structured system context for the agent, separate from the
+// human-readable Description. It is also visible in State().Properties.
+func (a *Agent) Persona() (string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	cPersona := C.thymos_agent_persona(a.handle, &cErr)
+	if cPersona == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cPersona)
+
+	return C.GoString(cPersona), nil
+}
+
+// SetPersona sets the agent's persona; an empty string clears it
+func (a *Agent) SetPersona(persona string) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cPersona := C.CString(persona)
+	defer C.free(unsafe.Pointer(cPersona))
+
+	result := C.thymos_agent_set_persona(a.handle, cPersona, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// IsHybrid returns true if the agent is using hybrid memory mode
+func (a *Agent) IsHybrid() (bool, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return false, ErrNilHandle
+	}
+
+	result := C.thymos_agent_is_hybrid(a.handle, &cErr)
+	if result < 0 {
+		return false, takeError(&cErr)
+	}
+	return result == 1, nil
+}
+
+// DataDir returns the absolute path of the agent's local store
+//
+// Useful for backups, and for finding where an agent built with the default
+// configuration keeps its data. In hybrid mode this is the private store.
+// Returns ErrNoDataDir in server mode.
+func (a *Agent) DataDir() (string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	cDir := C.thymos_agent_data_dir(a.handle, &cErr)
+	if cDir == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeConfiguration {
+			return "", ErrNoDataDir
+		}
+		return "", err
+	}
+	defer C.thymos_free_string(cDir)
+
+	return C.GoString(cDir), nil
+}
+
+// ============================================================================
+// Status and State
+// ============================================================================
+
+// Status represents the agent's operational status
+type Status string
+
+const (
+	StatusActive    Status = "Active"
+	StatusListening Status = "Listening"
+	StatusDormant   Status = "Dormant"
+	StatusArchived  Status = "Archived"
+)
+
+// statuses lists the valid statuses
+var statuses = []Status{StatusActive, StatusListening, StatusDormant, StatusArchived}
+
+// IsValid reports whether s is one of the Status constants, matched exactly
+func (s Status) IsValid() bool {
+	return slices.Contains(statuses, s)
+}
+
+// Status returns the current agent status
+func (a *Agent) Status() (Status, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	cStatus := C.thymos_agent_status(a.handle, &cErr)
+	if cStatus == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cStatus)
+
+	return Status(C.GoString(cStatus)), nil
+}
+
+// SetStatus sets the agent status
+//
+// Valid statuses: StatusActive, StatusListening, StatusDormant, StatusArchived.
+// Any other value, including a differently cased one such as "active",
+// returns an error wrapping ErrInvalidStatus. Callbacks registered with
+// OnStatusChange run before it returns, unless they are already running for
+// an earlier transition (see OnStatusChange).
+func (a *Agent) SetStatus(status Status) error {
+	if !status.IsValid() {
+		return fmt.Errorf("%w: %q (valid: %s, %s, %s, %s)", ErrInvalidStatus, status,
+			StatusActive, StatusListening, StatusDormant, StatusArchived)
+	}
+	if err := a.setStatus(status); err != nil {
+		return err
+	}
+	if a.watchingStatus() {
+		a.checkStatus()
+	}
+	return nil
+}
+
+func (a *Agent) setStatus(status Status) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cStatus := C.CString(string(status))
+	defer C.free(unsafe.Pointer(cStatus))
+
+	result := C.thymos_agent_set_status(a.handle, cStatus, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// State holds the full agent state
+//
+// It marshals to JSON with snake_case field names; an absent StartedAt is
+// written as null.
+type State struct {
+	Status     Status
+	StartedAt  *string
+	LastActive string
+	Properties map[string]interface{}
+}
+
+// stateJSON is the JSON form of State
+type stateJSON struct {
+	Status     Status                 `json:"status"`
+	StartedAt  *string                `json:"started_at"`
+	LastActive string                 `json:"last_active"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (s State) MarshalJSON() ([]byte, error) {
+	props := s.Properties
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	return json.Marshal(stateJSON{
+		Status:     s.Status,
+		StartedAt:  s.StartedAt,
+		LastActive: s.LastActive,
+		Properties: props,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, rejecting timestamps that are
+// not RFC 3339
+func (s *State) UnmarshalJSON(data []byte) error {
+	var raw stateJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := checkTimestamp("started_at", raw.StartedAt); err != nil {
+		return err
+	}
+	if err := checkTimestamp("last_active", &raw.LastActive); err != nil {
+		return err
+	}
+
+	*s = State{
+		Status:     raw.Status,
+		StartedAt:  raw.StartedAt,
+		LastActive: raw.LastActive,
+		Properties: raw.Properties,
+	}
+	if s.Properties == nil {
+		s.Properties = make(map[string]interface{})
+	}
+	return nil
+}
+
+// checkTimestamp returns an error unless value is nil, empty or RFC 3339
+func checkTimestamp(field string, value *string) error {
+	if value == nil || *value == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339Nano, *value); err != nil {
+		return fmt.Errorf("thymos: invalid %s timestamp: %w", field, err)
+	}
+	return nil
+}
+
+// State returns the full agent state
+func (a *Agent) State() (*State, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cState := C.thymos_agent_state(a.handle, &cErr)
+	if cState == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_agent_state(cState)
+
+	state := (*C.ThymosAgentState)(cState)
+
+	result := &State{
+		Status:     Status(C.GoString(state.status)),
+		LastActive: C.GoString(state.last_active),
+		Properties: make(map[string]interface{}),
+	}
+
+	if state.started_at != nil {
+		startedAt := C.GoString(state.started_at)
+		result.StartedAt = &startedAt
+	}
+
+	if state.properties_json != nil {
+		propsJSON := C.GoString(state.properties_json)
+		if err := json.Unmarshal([]byte(propsJSON), &result.Properties); err != nil {
+			result.Properties = make(map[string]interface{})
+		}
+	}
+
+	return result, nil
+}
+
+// Ping checks that the agent's store is open and writable
+//
+// It stores a probe memory, reads it back and deletes it, returning the
+// underlying storage error if the disk is full or the database is corrupt.
+// Suitable for readiness probes; the probe is briefly visible to concurrent
+// searches. An agent from OpenAgentReadOnly is only checked to be readable,
+// by counting its memories, so Ping never writes to its store.
+func (a *Agent) Ping() error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	result := C.thymos_agent_ping(a.handle, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// ============================================================================
+// Memory
+// ============================================================================
+
+// Memory represents a stored memory
+//
+// It is defined in package thymosapi, which does not use cgo, so code that
+// only handles memories can be built and tested without the native library.
+type Memory = thymosapi.Memory
+
+// MemoryType is the kind of a stored memory
+type MemoryType = thymosapi.MemoryType
+
+const (
+	// MemoryTypeEpisodic is a general memory stored by Remember
+	MemoryTypeEpisodic = thymosapi.MemoryTypeEpisodic
+	// MemoryTypeFact is durable knowledge stored by RememberFact
+	MemoryTypeFact = thymosapi.MemoryTypeFact
+	// MemoryTypeConversation is dialogue context stored by RememberConversation
+	MemoryTypeConversation = thymosapi.MemoryTypeConversation
+	// MemoryTypeOther is a memory type with no constant of its own, e.g. one
+	// written to the store by another client
+	MemoryTypeOther = thymosapi.MemoryTypeOther
+)
+
+// Scope is the backend a memory lives in
+type Scope = thymosapi.Scope
+
+const (
+	// ScopeDefault is the only backend of an agent not in hybrid mode
+	ScopeDefault = thymosapi.ScopeDefault
+	// ScopePrivate is a hybrid agent's own private backend
+	ScopePrivate = thymosapi.ScopePrivate
+	// ScopeShared is the hybrid backend shared with other agents
+	ScopeShared = thymosapi.ScopeShared
+)
+
+// MemoryAgent is the core memory API of an Agent
+//
+// Accept a MemoryAgent instead of an *Agent to test code with the in-memory
+// fake in package thymostest.
+type MemoryAgent = thymosapi.MemoryAgent
+
+var _ MemoryAgent = (*Agent)(nil)
+
+func convertCMemory(cMem *C.ThymosMemory) *Memory {
+	mem := &Memory{
+		ID:          C.GoString(cMem.id),
+		Content:     C.GoString(cMem.content),
+		CreatedAt:   C.GoString(cMem.created_at),
+		Properties:  make(map[string]interface{}),
+		Score:       float64(cMem.score),
+		Type:        MemoryType(cMem.memory_type),
+		Strength:    float64(cMem.strength),
+		Scope:       Scope(cMem.scope),
+		AccessCount: int(cMem.access_count),
+	}
+
+	if cMem.last_accessed != nil {
+		lastAccessed := C.GoString(cMem.last_accessed)
+		mem.LastAccessed = &lastAccessed
+	}
+
+	if cMem.properties_json != nil {
+		propsJSON := C.GoString(cMem.properties_json)
+		if err := json.Unmarshal([]byte(propsJSON), &mem.Properties); err != nil {
+			mem.Properties = make(map[string]interface{})
+		}
+	}
+
+	if cMem.tags_json != nil {
+		tagsJSON := C.GoString(cMem.tags_json)
+		if err := json.Unmarshal([]byte(tagsJSON), &mem.Tags); err != nil {
+			mem.Tags = nil
+		}
+	}
+
+	return mem
+}
+
+// resultBufferPooling makes searches reuse released result slices
+var resultBufferPooling atomic.Bool
+
+// resultBufferPool holds result slices returned by ReleaseResults
+var resultBufferPool = sync.Pool{
+	New: func() any { return new([]*Memory) },
+}
+
+// SetResultBufferPooling enables or disables reuse of search result slices
+//
+// When enabled, slices passed to ReleaseResults are recycled by later searches,
+// reducing allocation and GC pressure under high query rates. Pooling is off
+// by default.
+func SetResultBufferPooling(enabled bool) {
+	resultBufferPooling.Store(enabled)
+}
+
+// ReleaseResults returns a search result slice to the pool for reuse
+//
+// Only call it once done with the slice: with pooling enabled a later search
+// overwrites it, so neither the slice nor a subslice may be used afterwards.
+// Copy out any *Memory you need to keep first; the Memory values themselves
+// are never reused. ReleaseResults does nothing while pooling is disabled.
+func ReleaseResults(memories []*Memory) {
+	if !resultBufferPooling.Load() || cap(memories) == 0 {
+		return
+	}
+	clear(memories[:cap(memories)])
+	buf := memories[:0]
+	resultBufferPool.Put(&buf)
+}
+
+// newResultBuffer returns an empty slice with room for n memories, reusing a
+// released slice when pooling is enabled
+func newResultBuffer(n int) []*Memory {
+	if !resultBufferPooling.Load() {
+		return make([]*Memory, 0, n)
+	}
+	buf := *resultBufferPool.Get().(*[]*Memory)
+	if cap(buf) < n {
+		return make([]*Memory, 0, n)
+	}
+	return buf[:0]
+}
+
+// convertSearchResults copies a ThymosSearchResults into a Go slice
+func convertSearchResults(resultsPtr unsafe.Pointer) []*Memory {
+	results := (*C.ThymosSearchResults)(resultsPtr)
+	if results.count == 0 {
+		return []*Memory{}
+	}
+
+	memories := newResultBuffer(int(results.count))
+	memArray := (*[1 << 28]C.ThymosMemory)(unsafe.Pointer(results.memories))[:results.count:results.count]
+
+	for i := range memArray {
+		memories = append(memories, convertCMemory(&memArray[i]))
+	}
+
+	return memories
+}
+
+// Remember stores a memory and returns its ID
+//
+// The memory is embedded and indexed before Remember returns, so a search
+// made afterwards finds it; embedding latency is part of the call.
+func (a *Agent) Remember(content string) (string, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+
+	cID := C.thymos_agent_remember(a.handle, cContent, &cErr)
+	if cID == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
+}
+
+// RememberFact stores a fact memory (durable, context-independent knowledge)
+//
+// Facts are intended for knowledge like "Paris is the capital of France".
+func (a *Agent) RememberFact(content string) (string, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+
+	cID := C.thymos_agent_remember_fact(a.handle, cContent, &cErr)
+	if cID == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
+}
+
+// RememberConversation stores a conversation memory (dialogue context)
+//
+// Conversation memories are intended for dialogue history and ephemeral context.
+func (a *Agent) RememberConversation(content string) (string, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+
+	cID := C.thymos_agent_remember_conversation(a.handle, cContent, &cErr)
+	if cID == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
+}
+
+// RememberPrivate stores a memory in the private backend (hybrid mode only)
+//
+// Returns ErrNotHybridMode if the agent is not in hybrid mode.
+func (a *Agent) RememberPrivate(content string) (string, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+
+	cID := C.thymos_agent_remember_private(a.handle, cContent, &cErr)
+	if cID == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotHybrid {
+			return "", ErrNotHybridMode
+		}
+		return "", err
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
+}
+
+// RememberShared stores a memory in the shared backend (hybrid mode only)
+//
+// Returns ErrNotHybridMode if the agent is not in hybrid mode.
+func (a *Agent) RememberShared(content string) (string, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+
+	cID := C.thymos_agent_remember_shared(a.handle, cContent, &cErr)
+	if cID == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotHybrid {
+			return "", ErrNotHybridMode
+		}
+		return "", err
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
+}
+
+// RememberWithProperties stores a memory with arbitrary properties
+//
+// The properties are returned in Memory.Properties by GetMemory and searches.
+// props must be JSON-serializable; otherwise an error is returned before
+// anything is stored. Memories go to the local store (the private backend in
+// hybrid mode) and are embedded like those from Remember, so semantic search
+// finds them.
+func (a *Agent) RememberWithProperties(content string, props map[string]interface{}) (string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	propsJSON, err := json.Marshal(props)
+	if err != nil {
+		return "", fmt.Errorf("thymos: properties are not JSON-serializable: %w", err)
+	}
+
+	defer a.trackWrite()()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+	cProps := C.CString(string(propsJSON))
+	defer C.free(unsafe.Pointer(cProps))
+
+	cID := C.thymos_agent_remember_with_properties(a.handle, cContent, cProps, &cErr)
+	if cID == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
+}
+
+// BatchError reports the items of a RememberBatch call that failed
+type BatchError struct {
+	// IDs holds the new memory ID for each input, or "" where it failed
+	IDs []string
+	// Errors maps the index of each failed input to its error
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	first := -1
+	for i := range e.Errors {
+		if first < 0 || i < first {
+			first = i
+		}
+	}
+	return fmt.Sprintf("thymos: %d of %d memories failed to store (first at index %d: %v)",
+		len(e.Errors), len(e.IDs), first, e.Errors[first])
+}
+
+// Succeeded returns the indices of the inputs that were stored
+func (e *BatchError) Succeeded() []int {
+	indices := make([]int, 0, len(e.IDs)-len(e.Errors))
+	for i, id := range e.IDs {
+		if id != "" {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// RememberBatch stores each of contents like Remember and returns their IDs
+// in order
+//
+// The whole batch crosses into the library in one call, where contents are
+// embedded in batches (see MemoryConfig.SetEmbeddingBatchSize). A failed item
+// does not stop the batch: the returned IDs hold "" for it and the error is a
+// *BatchError saying which indices succeeded. Items longer than
+// MaxContentLength fail with ErrContentTooLarge without being sent.
+func (a *Agent) RememberBatch(contents []string) ([]string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	if len(contents) == 0 {
+		return []string{}, nil
+	}
+
+	defer a.trackWrite()()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	batchErr := &BatchError{IDs: make([]string, len(contents)), Errors: make(map[int]error)}
+	var send []string
+	var sendIndex []int
+	for i, content := range contents {
+		if err := thymosapi.CheckContent(content); err != nil {
+			batchErr.Errors[i] = err
+			continue
+		}
+		send = append(send, content)
+		sendIndex = append(sendIndex, i)
+	}
+
+	if len(send) > 0 {
+		cContents, freeContents := newCStringArray(send)
+		defer freeContents()
+
+		var idsPtr, errorsPtr unsafe.Pointer
+		stored := C.thymos_agent_remember_batch(a.handle, &cContents[0], C.size_t(len(cContents)), &idsPtr, &errorsPtr, &cErr)
+		if stored < 0 {
+			return nil, takeError(&cErr)
+		}
+		defer C.thymos_free_string_list(idsPtr)
+		defer C.thymos_free_string_list(errorsPtr)
+
+		for j, id := range convertCStringList(idsPtr) {
+			batchErr.IDs[sendIndex[j]] = id
+		}
+		for j, msg := range convertCStringList(errorsPtr) {
+			if msg != "" {
+				batchErr.Errors[sendIndex[j]] = &Error{Message: msg}
+			}
+		}
+	}
+
+	if len(batchErr.Errors) == 0 {
+		return batchErr.IDs, nil
+	}
+	return batchErr.IDs, batchErr
+}
+
+// RememberChunked stores content as linked chunks of at most chunkSize bytes
+// and returns their IDs in order
+//
+// Use it for text longer than MaxContentLength, such as whole documents.
+// Chunks end after whitespace where that keeps them at least half full, never
+// split a UTF-8 character, and concatenate back to content. Each is stored
+// like RememberWithProperties with "chunk_group" (shared by the chunks of one
+// call), "chunk_index" (from 0) and "chunk_count" properties. chunkSize must
+// be between 1 and MaxContentLength. If a chunk fails, the chunks already
+// stored are forgotten again.
+func (a *Agent) RememberChunked(content string, chunkSize int) ([]string, error) {
+	if a.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	if chunkSize < 1 || chunkSize > MaxContentLength {
+		return nil, fmt.Errorf("thymos: chunk size must be between 1 and %d, got %d", MaxContentLength, chunkSize)
+	}
+
+	chunks := splitChunks(content, chunkSize)
+	group := rand.Text()
+	ids := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		id, err := a.RememberWithProperties(chunk, map[string]interface{}{
+			"chunk_group": group,
+			"chunk_index": i,
+			"chunk_count": len(chunks),
+		})
+		if err != nil {
+			for _, stored := range ids {
+				_ = a.Forget(stored)
+			}
+			return nil, fmt.Errorf("thymos: chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// RememberContext is Remember with a deadline
+//
+// If ctx is done before the memory is stored, the native write is canceled
+// and ctx.Err() is returned. A write canceled while its content is being
+// embedded (where most of its time goes) stores nothing, but one canceled
+// once the store has started writing may still be stored and its ID is
+// discarded. Use Forget or a later search if that must be cleaned up.
+func (a *Agent) RememberContext(ctx context.Context, content string) (string, error) {
+	return a.rememberCancelable(ctx, content, func(cContent *C.char, token unsafe.Pointer, cErr *C.ThymosError) *C.char {
+		return C.thymos_agent_remember_cancelable(a.handle, cContent, token, cErr)
+	})
+}
+
+// RememberFactContext is RememberFact with a deadline, like RememberContext
+func (a *Agent) RememberFactContext(ctx context.Context, content string) (string, error) {
+	return a.rememberCancelable(ctx, content, func(cContent *C.char, token unsafe.Pointer, cErr *C.ThymosError) *C.char {
+		return C.thymos_agent_remember_fact_cancelable(a.handle, cContent, token, cErr)
+	})
+}
+
+// RememberConversationContext is RememberConversation with a deadline, like
+// RememberContext
+func (a *Agent) RememberConversationContext(ctx context.Context, content string) (string, error) {
+	return a.rememberCancelable(ctx, content, func(cContent *C.char, token unsafe.Pointer, cErr *C.ThymosError) *C.char {
+		return C.thymos_agent_remember_conversation_cancelable(a.handle, cContent, token, cErr)
+	})
+}
+
+// RememberPrivateContext is RememberPrivate with a deadline, like
+// RememberContext
+func (a *Agent) RememberPrivateContext(ctx context.Context, content string) (string, error) {
+	return a.rememberCancelable(ctx, content, func(cContent *C.char, token unsafe.Pointer, cErr *C.ThymosError) *C.char {
+		return C.thymos_agent_remember_private_cancelable(a.handle, cContent, token, cErr)
+	})
+}
+
+// RememberSharedContext is RememberShared with a deadline, like
+// RememberContext
+func (a *Agent) RememberSharedContext(ctx context.Context, content string) (string, error) {
+	return a.rememberCancelable(ctx, content, func(cContent *C.char, token unsafe.Pointer, cErr *C.ThymosError) *C.char {
+		return C.thymos_agent_remember_shared_cancelable(a.handle, cContent, token, cErr)
+	})
+}
+
+// RememberWithPropertiesContext is RememberWithProperties with a deadline,
+// like RememberContext
+func (a *Agent) RememberWithPropertiesContext(ctx context.Context, content string, props map[string]interface{}) (string, error) {
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	propsJSON, err := json.Marshal(props)
+	if err != nil {
+		return "", fmt.Errorf("thymos: properties are not JSON-serializable: %w", err)
+	}
+
+	cProps := C.CString(string(propsJSON))
+	defer C.free(unsafe.Pointer(cProps))
+
+	return a.rememberCancelable(ctx, content, func(cContent *C.char, token unsafe.Pointer, cErr *C.ThymosError) *C.char {
+		return C.thymos_agent_remember_with_properties_cancelable(a.handle, cContent, cProps, token, cErr)
+	})
+}
+
+// rememberCancelable runs the checks shared by the Remember methods, then
+// calls write with the content and a token canceled once ctx is done. write
+// runs under a.mu with a.handle non-nil.
+func (a *Agent) rememberCancelable(ctx context.Context, content string, write func(cContent *C.char, token unsafe.Pointer, cErr *C.ThymosError) *C.char) (string, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+
+	token := C.thymos_cancel_token_new()
+	canceled := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		C.thymos_cancel_token_cancel(token)
+		close(canceled)
+	})
+
+	cID := write(cContent, token, &cErr)
+
+	if !stop() {
+		<-canceled
+	}
+	C.thymos_cancel_token_free(token)
+
+	if cID == nil {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotHybrid {
+			return "", ErrNotHybridMode
+		}
+		return "", err
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
+}
+
+// splitChunks splits s into chunks of at most size bytes for RememberChunked
+//
+// A chunk is longer than size only when size is smaller than the character
+// that starts it.
+func splitChunks(s string, size int) []string {
+	var chunks []string
+	for len(s) > size {
+		end := size
+		for end > 0 && !utf8.RuneStart(s[end]) {
+			end--
+		}
+		if end == 0 {
+			_, end = utf8.DecodeRuneInString(s)
+		}
+		if i := strings.LastIndexFunc(s[:end], unicode.IsSpace); i >= end/2 {
+			_, width := utf8.DecodeRuneInString(s[i:])
+			end = i + width
+		}
+		chunks = append(chunks, s[:end])
+		s = s[end:]
+	}
+	if s != "" || len(chunks) == 0 {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
+
+// Forget deletes a memory by ID
+//
+// Returns an error wrapping ErrMemoryNotFound if no memory has the ID. Unlike
+// Prune, forgotten memories cannot be restored.
+func (a *Agent) Forget(memoryID string) error {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+
+	result := C.thymos_agent_forget(a.handle, cID, &cErr)
+	if result != 0 {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotFound {
+			return fmt.Errorf("%w: %s", ErrMemoryNotFound, memoryID)
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateMemory replaces the content of a stored memory
+//
+// The memory keeps its ID and CreatedAt; it is re-embedded so searches
+// reflect the new content, and LastAccessed is set to now. Returns an error
+// wrapping ErrMemoryNotFound if no memory has the ID.
+func (a *Agent) UpdateMemory(memoryID, newContent string) error {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	if err := thymosapi.CheckContent(newContent); err != nil {
+		return err
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+	cContent := C.CString(newContent)
+	defer C.free(unsafe.Pointer(cContent))
+
+	result := C.thymos_agent_update_memory(a.handle, cID, cContent, &cErr)
+	if result != 0 {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotFound {
+			return fmt.Errorf("%w: %s", ErrMemoryNotFound, memoryID)
+		}
+		return err
+	}
+	return nil
+}
+
+// ============================================================================
+// Bulk Import
+// ============================================================================
+
+// ImportFormat selects the file format for ImportFile
+type ImportFormat int
+
+const (
+	// FormatJSONL reads one {"content": ..., "properties": {...}} object per line
+	FormatJSONL ImportFormat = iota
+	// FormatCSV reads a CSV file with a header row
+	FormatCSV
+)
+
+// CSVMapping selects which CSV columns become content and properties
+type CSVMapping struct {
+	// ContentColumn is the header of the content column (default "content")
+	ContentColumn string
+	// PropertyColumns are the headers stored as string properties
+	// (default: every column except ContentColumn)
+	PropertyColumns []string
+}
+
+// ImportError reports the first malformed row of an import file
+type ImportError struct {
+	Line    int
+	Message string
+}
+
+func (e *ImportError) Error() string {
+	return e.Message
+}
+
+// ImportFile imports memories from a JSONL or CSV file
+//
+// CSV files use the default CSVMapping. The file is parsed in full before
+// anything is stored, so a malformed row, or one whose content is longer than
+// MaxContentLength, imports nothing and returns an *ImportError with its line
+// number. Rows are embedded like RememberBatch, in
+// batches of MemoryConfig's embedding batch size. Returns the number of
+// memories imported; if storing one fails, those stored before it stay and
+// are counted.
+func (a *Agent) ImportFile(path string, format ImportFormat) (int, error) {
+	return a.importFile(path, format, CSVMapping{})
+}
+
+// ImportCSV imports memories from a CSV file using a custom column mapping
+func (a *Agent) ImportCSV(path string, mapping CSVMapping) (int, error) {
+	return a.importFile(path, FormatCSV, mapping)
+}
+
+func (a *Agent) importFile(path string, format ImportFormat, mapping CSVMapping) (int, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	var cContentColumn *C.char
+	if mapping.ContentColumn != "" {
+		cContentColumn = C.CString(mapping.ContentColumn)
+		defer C.free(unsafe.Pointer(cContentColumn))
+	}
+
+	cColumns, freeColumns := newCStringArray(mapping.PropertyColumns)
+	defer freeColumns()
+
+	var cColumnsPtr **C.char
+	if len(cColumns) > 0 {
+		cColumnsPtr = &cColumns[0]
+	}
+
+	var cErrorLine, cImported C.size_t
+	result := C.thymos_agent_import_file(a.handle, cPath, C.int(format), cContentColumn, cColumnsPtr, C.size_t(len(cColumns)), &cErrorLine, &cImported, &cErr)
+	if result != 0 {
+		err := takeError(&cErr)
+		if cErrorLine > 0 && err != nil {
+			return 0, &ImportError{Line: int(cErrorLine), Message: err.Error()}
+		}
+		return int(cImported), err
+	}
+	return int(cImported), nil
+}
+
+// ============================================================================
+// Memory Search
+// ============================================================================
+
+// DefaultSearchLimit is the number of results SearchMemories returns for a
+// limit of 0
+const DefaultSearchLimit = thymosapi.DefaultSearchLimit
+
+// Unlimited is the limit that makes SearchMemories and its variants return
+// every match
+//
+// A limit of 0 used to mean no limit; it now means DefaultSearchLimit, so an
+// unset limit can't pull the whole store by accident. Pass Unlimited, or call
+// SearchAll, to keep the old behavior.
+const Unlimited = thymosapi.Unlimited
+
+// searchLimit converts a SearchMemories limit to the native convention, where
+// 0 means no limit
+func searchLimit(limit int) (C.size_t, error) {
+	switch {
+	case limit == Unlimited:
+		return 0, nil
+	case limit == 0:
+		return DefaultSearchLimit, nil
+	case limit < 0:
+		return 0, fmt.Errorf("%w: %d", ErrInvalidLimit, limit)
+	}
+	return C.size_t(limit), nil
+}
+
+// SearchMemories searches for memories matching the query
+//
+// Returns at most limit results. A limit of 0 returns up to
+// DefaultSearchLimit results and Unlimited returns every match; any other
+// negative limit returns ErrInvalidLimit.
+//
+// Each result's Score is its relevance to the query, from 0 to 1: the cosine
+// similarity of the query's and the memory's embeddings when the agent has an
+// embedding provider, and otherwise the fraction of the query's words found
+// in the memory. Scores do not depend on the other results, so they can be
+// compared across queries and agents.
+func (a *Agent) SearchMemories(query string, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	resultsPtr := C.thymos_agent_search_memories(a.handle, cQuery, cLimit, &cErr)
+	if resultsPtr == nil {
+		err := takeError(&cErr)
+		if err == nil {
+			return []*Memory{}, nil
+		}
+		return nil, err
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// SearchAll returns every memory matching the query
+//
+// It is SearchMemories with an Unlimited limit; on a large store it can
+// return a great many results.
+func (a *Agent) SearchAll(query string) ([]*Memory, error) {
+	return a.SearchMemories(query, Unlimited)
+}
+
+// SearchMany runs SearchMemories for each of queries and returns their
+// results in the same order
+//
+// All queries cross into the library in one call and are searched
+// concurrently there, which is cheaper than calling SearchMemories in a loop
+// for fan-out retrieval. limit applies to each query and is interpreted as by
+// SearchMemories. If any query fails, no results are returned.
+func (a *Agent) SearchMany(queries []string, limit int) ([][]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(queries) == 0 {
+		return [][]*Memory{}, nil
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQueries, freeQueries := newCStringArray(queries)
+	defer freeQueries()
+
+	cCounts := make([]C.size_t, len(queries))
+	resultsPtr := C.thymos_agent_search_many(a.handle, &cQueries[0], C.size_t(len(cQueries)), cLimit, &cCounts[0], &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	memories := convertSearchResults(resultsPtr)
+	results := make([][]*Memory, len(queries))
+	for i, count := range cCounts {
+		results[i] = memories[:count:count]
+		memories = memories[count:]
+	}
+	return results, nil
+}
+
+// SearchMemoriesWithThreshold is SearchMemories without results scored below
+// minScore
+//
+// Results are filtered before limit is applied, so up to limit results with a
+// Score of at least minScore are returned. limit is interpreted as by
+// SearchMemories.
+func (a *Agent) SearchMemoriesWithThreshold(query string, limit int, minScore float64) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	resultsPtr := C.thymos_agent_search_memories_threshold(a.handle, cQuery, cLimit, C.double(minScore), &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// SearchMemoriesContext is SearchMemories with cancellation
+//
+// limit is interpreted as by SearchMemories. If ctx is done before the search
+// completes, the search is abandoned and ctx.Err() is returned.
+func (a *Agent) SearchMemoriesContext(ctx context.Context, query string, limit int) ([]*Memory, error) {
+	resultsPtr, err := a.searchCancelable(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	if resultsPtr == nil {
+		return []*Memory{}, nil
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// searchCancelable runs a cancelable search and returns the raw results,
+// which the caller must free with thymos_free_search_results. A nil pointer
+// with a nil error means no results.
+func (a *Agent) searchCancelable(ctx context.Context, query string, limit int) (unsafe.Pointer, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	token := C.thymos_cancel_token_new()
+	canceled := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		C.thymos_cancel_token_cancel(token)
+		close(canceled)
+	})
+
+	resultsPtr := C.thymos_agent_search_memories_cancelable(a.handle, cQuery, cLimit, token, &cErr)
+
+	if !stop() {
+		<-canceled
+	}
+	C.thymos_cancel_token_free(token)
+
+	if resultsPtr == nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, takeError(&cErr)
+	}
+	return resultsPtr, nil
+}
+
+// SearchStream is SearchMemoriesContext delivering results one at a time
+//
+// The search itself runs to completion in the native library; results are
+// then converted and sent on the memory channel one by one, so the caller
+// never holds the whole result set as Go values. Both channels are closed
+// when the stream ends. At most one error is sent, after which no more
+// memories follow; canceling ctx stops the stream promptly with ctx.Err()
+// and frees the native results.
+func (a *Agent) SearchStream(ctx context.Context, query string, limit int) (<-chan *Memory, <-chan error) {
+	memories := make(chan *Memory)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(memories)
+
+		resultsPtr, err := a.searchCancelable(ctx, query, limit)
+		if err != nil {
+			errs <- err
+			return
+		}
+		if resultsPtr == nil {
+			return
+		}
+		defer C.thymos_free_search_results(resultsPtr)
+
+		results := (*C.ThymosSearchResults)(resultsPtr)
+		if results.count == 0 {
+			return
+		}
+		memArray := (*[1 << 28]C.ThymosMemory)(unsafe.Pointer(results.memories))[:results.count:results.count]
+		for i := range memArray {
+			select {
+			case memories <- convertCMemory(&memArray[i]):
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return memories, errs
+}
+
+// QueryCacheStats returns the query cache hit and miss counts
+//
+// Returns an error if no cache was configured with MemoryConfig.SetQueryCache.
+func (a *Agent) QueryCacheStats() (hits, misses int, err error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, 0, ErrNilHandle
+	}
+
+	var cHits, cMisses C.uint64_t
+	result := C.thymos_agent_query_cache_stats(a.handle, &cHits, &cMisses, &cErr)
+	if result != 0 {
+		return 0, 0, takeError(&cErr)
+	}
+	return int(cHits), int(cMisses), nil
+}
+
+// SearchMemoriesReinforced searches like SearchMemories and also returns the
+// IDs of the results the search reinforced
+//
+// Searching updates a matched memory's access time, which strengthens it on
+// the forgetting curve. The local store (the private backend in hybrid mode)
+// does so for every memory a search retrieves from it, so the reinforced IDs
+// are the returned memories from the local store, and always a subset of the
+// results. limit is interpreted as by SearchMemories and is applied by the
+// store, so no memory is reinforced without being returned. A hybrid agent
+// fills the limit with private matches first and tops it up with shared
+// ones, which a server keeps its own access records for.
+func (a *Agent) SearchMemoriesReinforced(query string, limit int) ([]*Memory, []string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reinforcedPtr unsafe.Pointer
+	resultsPtr := C.thymos_agent_search_memories_reinforced(a.handle, cQuery, cLimit, &reinforcedPtr, &cErr)
+	if resultsPtr == nil {
+		return nil, nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+	defer C.thymos_free_string_list(reinforcedPtr)
+
+	return convertSearchResults(resultsPtr), convertCStringList(reinforcedPtr), nil
+}
+
+// SearchGrouped searches memories and groups the results by a property
+//
+// Memories lacking the groupBy property are grouped under "". limit applies
+// per group and is interpreted as by SearchMemories. Within each group,
+// results keep relevance order.
+func (a *Agent) SearchGrouped(query string, limit int, groupBy string) (map[string][]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cGroupBy := C.CString(groupBy)
+	defer C.free(unsafe.Pointer(cGroupBy))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var keysPtr unsafe.Pointer
+	resultsPtr := C.thymos_agent_search_grouped(a.handle, cQuery, cLimit, cGroupBy, &keysPtr, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+	defer C.thymos_free_string_list(keysPtr)
+
+	memories := convertSearchResults(resultsPtr)
+	keys := convertCStringList(keysPtr)
+
+	groups := make(map[string][]*Memory)
+	for i, mem := range memories {
+		groups[keys[i]] = append(groups[keys[i]], mem)
+	}
+	return groups, nil
+}
+
+// SearchReranked searches memories and ranks them with a custom formula
+//
+// The formula is an arithmetic expression (+ - * / and parentheses) over:
+//   - score: relevance to the query, the Score SearchMemories reports
+//   - recency: 1.0 when just accessed, halving every week since last access
+//   - retention: current strength on the forgetting curve (0.0-1.0)
+//   - access_count: retrievals of the memory through this agent
+//
+// For example "0.7*score + 0.3*recency". An invalid formula returns a parse
+// error. limit is interpreted as by SearchMemories.
+func (a *Agent) SearchReranked(query string, limit int, formula string) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cFormula := C.CString(formula)
+	defer C.free(unsafe.Pointer(cFormula))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_search_reranked(a.handle, cQuery, cLimit, cFormula, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// SearchMemoriesExplained searches like SearchMemories and sets each result's
+// ScoreComponents
+//
+// Components are "semantic" (relevance to the query, the Score SearchMemories
+// reports), "recency_boost" (1.0 when just accessed, halving every week) and
+// "retention_factor" (current strength on the forgetting curve).
+// limit is interpreted as by SearchMemories.
+func (a *Agent) SearchMemoriesExplained(query string, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var componentsPtr unsafe.Pointer
+	resultsPtr := C.thymos_agent_search_explained(a.handle, cQuery, cLimit, &componentsPtr, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+	defer C.thymos_free_string_list(componentsPtr)
+
+	memories := convertSearchResults(resultsPtr)
+	components := convertCStringList(componentsPtr)
+
+	for i, mem := range memories {
+		if err := json.Unmarshal([]byte(components[i]), &mem.ScoreComponents); err != nil {
+			return nil, fmt.Errorf("thymos: invalid score components JSON: %w", err)
+		}
+	}
+	return memories, nil
+}
+
+// SearchHybrid searches by a blend of semantic and keyword relevance
+//
+// Semantic similarity and BM25 keyword scores are each scaled to [0, 1] and
+// fused as alpha*semantic + (1-alpha)*keyword, so alpha=1 is pure semantic and
+// alpha=0 pure keyword. Keyword scoring helps queries with rare exact terms;
+// it covers the store's top 1000 text-search matches (or limit, if larger).
+// Semantic scoring needs an embedding provider and reads every memory, so
+// keyword-only searches (alpha=0) are the cheapest; memories without an
+// embedding only match by keyword (see EmbedPending). limit is interpreted as
+// by SearchMemories.
+func (a *Agent) SearchHybrid(query string, limit int, alpha float64) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_search_hybrid(a.handle, cQuery, cLimit, C.double(alpha), &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// SearchPrivate searches private memories (hybrid mode only)
+//
+// limit is interpreted as by SearchMemories. Returns ErrNotHybridMode if the
+// agent is not in hybrid mode.
+func (a *Agent) SearchPrivate(query string, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_search_private(a.handle, cQuery, cLimit, &cErr)
+	if resultsPtr == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotHybrid {
+			return nil, ErrNotHybridMode
+		}
+		if err == nil {
+			return []*Memory{}, nil
+		}
+		return nil, err
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// SearchShared searches shared memories (hybrid mode only)
+//
+// limit is interpreted as by SearchMemories. Returns ErrNotHybridMode if the
+// agent is not in hybrid mode.
+func (a *Agent) SearchShared(query string, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_search_shared(a.handle, cQuery, cLimit, &cErr)
+	if resultsPtr == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotHybrid {
+			return nil, ErrNotHybridMode
+		}
+		if err == nil {
+			return []*Memory{}, nil
+		}
+		return nil, err
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// SearchPrivateAndShared searches private and shared memories in one call
+// (hybrid mode only)
+//
+// Both backends are queried and their results merged by Score, best first, as
+// SearchMemories scores them; ties keep private results ahead. Each result's
+// Scope reports the backend it came from. limit is interpreted as by
+// SearchMemories. (SearchHybrid is the unrelated semantic/keyword blend.)
+//
+// Returns ErrNotHybridMode if the agent is not in hybrid mode.
+func (a *Agent) SearchPrivateAndShared(query string, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_search_scoped(a.handle, cQuery, cLimit, &cErr)
+	if resultsPtr == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotHybrid {
+			return nil, ErrNotHybridMode
+		}
+		if err == nil {
+			return []*Memory{}, nil
+		}
+		return nil, err
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// SearchWithin searches only among the memories in allowedIDs
+//
+// Ranking follows SearchMemories, but memories outside allowedIDs are never
+// returned. An empty allowlist returns no results. limit is interpreted as by
+// SearchMemories.
+func (a *Agent) SearchWithin(query string, allowedIDs []string, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	if len(allowedIDs) == 0 {
+		return []*Memory{}, nil
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cIDs, freeIDs := newCStringArray(allowedIDs)
+	defer freeIDs()
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_search_within(a.handle, cQuery, &cIDs[0], C.size_t(len(cIDs)), cLimit, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// SearchMemoriesByType searches only memories of type t
+//
+// Ranking follows SearchMemories, but memories of other types are never
+// returned. Returns an empty slice if none match. limit is interpreted as by
+// SearchMemories.
+func (a *Agent) SearchMemoriesByType(query string, t MemoryType, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_search_by_type(a.handle, cQuery, C.int(t), cLimit, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// SearchByType searches only memories of type t
+//
+// It is another name for SearchMemoriesByType and behaves identically.
+func (a *Agent) SearchByType(query string, t MemoryType, limit int) ([]*Memory, error) {
+	return a.SearchMemoriesByType(query, t, limit)
+}
+
+// SearchMemoriesInRange searches only memories created between since and
+// until, inclusive
+//
+// A zero since means the beginning of time and a zero until means now.
+// Ranking follows SearchMemories. Returns an empty slice if none match. limit
+// is interpreted as by SearchMemories.
+func (a *Agent) SearchMemoriesInRange(query string, since, until time.Time, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cSince := C.int64_t(math.MinInt64)
+	if !since.IsZero() {
+		cSince = C.int64_t(since.UnixMilli())
+	}
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_search_in_range(a.handle, cQuery, cSince, C.int64_t(until.UnixMilli()), cLimit, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// GetMemory retrieves a memory by its ID
+//
+// A missing memory is not an error: GetMemory returns nil, nil, so callers
+// must check the memory as well as the error. Use HasMemory for an explicit
+// existence check.
+func (a *Agent) GetMemory(memoryID string) (*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cMemoryID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cMemoryID))
+
+	memPtr := C.thymos_agent_get_memory(a.handle, cMemoryID, &cErr)
+	if memPtr == nil {
+		err := takeError(&cErr)
+		if err == nil {
+			return nil, nil // Memory not found
+		}
+		return nil, err
+	}
+	defer C.thymos_free_memory(memPtr)
+
+	return convertCMemory((*C.ThymosMemory)(memPtr)), nil
+}
+
+// HasMemory reports whether a memory with the ID exists
+//
+// The memory is read from the store just as by GetMemory, so the check costs
+// about the same; unlike GetMemory it does not count as an access.
+func (a *Agent) HasMemory(memoryID string) (bool, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return false, ErrNilHandle
+	}
+
+	cMemoryID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cMemoryID))
+
+	result := C.thymos_agent_has_memory(a.handle, cMemoryID, &cErr)
+	if result < 0 {
+		return false, takeError(&cErr)
+	}
+	return result == 1, nil
+}
+
+// GetMemories retrieves many memories by ID in one call
+//
+// The result is parallel to ids: each entry is the memory with that ID, or
+// nil if there is none. Every memory found counts as an access.
+func (a *Agent) GetMemories(ids []string) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if len(ids) == 0 {
+		return []*Memory{}, nil
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cIDs, freeIDs := newCStringArray(ids)
+	defer freeIDs()
+
+	resultsPtr := C.thymos_agent_get_memories(a.handle, &cIDs[0], C.size_t(len(cIDs)), &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	// Found memories come back in request order with missing IDs skipped, so
+	// walk both lists together to put each one back at its index
+	found := convertSearchResults(resultsPtr)
+	memories := make([]*Memory, len(ids))
+	for i, id := range ids {
+		if len(found) > 0 && found[0].ID == id {
+			memories[i] = found[0]
+			found = found[1:]
+		}
+	}
+	return memories, nil
+}
+
+// MemoryFingerprint returns a stable fingerprint of a memory's content
+//
+// The fingerprint is the lowercase hex SHA-256 of the content, so equal
+// content yields equal fingerprints across restarts and replicas. Unlike
+// GetMemory it does not count as an access. Returns an error wrapping
+// ErrMemoryNotFound if no memory has the ID.
+func (a *Agent) MemoryFingerprint(memoryID string) (string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+
+	cFingerprint := C.thymos_agent_fingerprint(a.handle, cID, &cErr)
+	if cFingerprint == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotFound {
+			return "", fmt.Errorf("%w: %s", ErrMemoryNotFound, memoryID)
+		}
+		return "", err
+	}
+	defer C.thymos_free_string(cFingerprint)
+
+	return C.GoString(cFingerprint), nil
+}
+
+// ListMemories returns one page of stored memories, oldest first
+//
+// Memories are ordered by CreatedAt, ties broken by ID, so paging through with
+// increasing offsets visits every memory exactly once while the store is
+// unchanged. The page skips the first offset memories and holds at most limit
+// memories, interpreted as by SearchMemories; a negative offset is treated as
+// 0. An offset at or past the end returns an empty slice. Listing does not
+// count as an access.
+//
+// The agent keeps the creation order of its memories between calls, so only
+// the first call loads every memory; later calls load those stored since and
+// then just the requested page.
+func (a *Agent) ListMemories(offset, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cOffset := C.size_t(offset)
+	if offset < 0 {
+		cOffset = 0
+	}
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_list_memories(a.handle, cOffset, cLimit, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// MemoriesModifiedSince returns memories created or changed since t, for
+// incremental sync
+//
+// A memory's modification time is its "modified_at" property, set when
+// UpdateMemory, SetMemoryTTL, EmbedPending or MergeAgents changes it, or its
+// CreatedAt if it never changed. Accesses are not changes: the store writes a
+// memory's last access time on every search that returns it, so counting
+// them would report every searched memory, and those writes bypass the
+// Agent, so catching them would mean reloading the whole store on each call.
+// Memories are ordered by modification time, oldest first, ties broken by ID,
+// so a sync can pass the last memory's time as the next t. Times compare to
+// the millisecond and t itself is included, so that memory is returned again
+// rather than others changed in the same millisecond being skipped;
+// de-duplicate by ID. limit is interpreted as by SearchMemories. The call does
+// not count as an access. Use DeletedSince for the memories deleted since t.
+func (a *Agent) MemoriesModifiedSince(t time.Time, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_modified_since(a.handle, sinceMillis(t), cLimit, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// DeletedSince returns the IDs of memories deleted since t, oldest deletion
+// first, for incremental sync alongside MemoriesModifiedSince
+//
+// Deletions, including forgotten and expired memories, are noticed by this
+// Agent at the first call to either method after they happen and reported
+// from then on; the most recent 10,000 are remembered, and deletions before
+// the Agent's first call are not reported.
+func (a *Agent) DeletedSince(t time.Time) ([]string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	listPtr := C.thymos_agent_deleted_since(a.handle, sinceMillis(t), &cErr)
+	if listPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_string_list(listPtr)
+
+	return convertCStringList(listPtr), nil
+}
+
+// sinceMillis converts t to Unix milliseconds, the zero time to the earliest
+func sinceMillis(t time.Time) C.int64_t {
+	if t.IsZero() {
+		return C.int64_t(math.MinInt64)
+	}
+	return C.int64_t(t.UnixMilli())
+}
+
+// MemoryCount returns the number of stored memories
+//
+// The count is read from the store's index without loading any memory, so it
+// is cheap enough to poll.
+func (a *Agent) MemoryCount() (int, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	count := C.thymos_agent_memory_count(a.handle, &cErr)
+	if count < 0 {
+		return 0, takeError(&cErr)
+	}
+	return int(count), nil
+}
+
+// MemoryCountByType returns the number of stored memories of type t
+//
+// Memory types are not indexed, so unlike MemoryCount this loads every memory.
+func (a *Agent) MemoryCountByType(t MemoryType) (int, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	count := C.thymos_agent_memory_count_by_type(a.handle, C.int(t), &cErr)
+	if count < 0 {
+		return 0, takeError(&cErr)
+	}
+	return int(count), nil
+}
+
+// ============================================================================
+// Forgetting
+// ============================================================================
+
+// Prune forgets memories whose strength has fallen below threshold
+//
+// Strength follows the forgetting curve and ranges from 0.0 to 1.0. Pruned
+// memories stay recoverable with RestoreMemory for the grace period set by
+// MemoryConfig.SetForgetGracePeriod. threshold must be between 0 and 1.
+// Returns the number of memories forgotten.
+func (a *Agent) Prune(threshold float64) (int, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	if threshold < 0 || threshold > 1 || math.IsNaN(threshold) {
+		return 0, errors.New("thymos: prune threshold must be between 0 and 1")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	result := C.thymos_agent_prune(a.handle, C.double(threshold), &cErr)
+	if result < 0 {
+		return 0, takeError(&cErr)
+	}
+	return int(result), nil
+}
+
+// RecentlyForgotten returns forgotten memories still within the grace period
+//
+// Results are ordered most recently forgotten first. limit is interpreted as
+// by SearchMemories. Forgotten memories are held in memory by the agent handle
+// and do not survive Close.
+func (a *Agent) RecentlyForgotten(limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_recently_forgotten(a.handle, cLimit, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// RestoreMemory brings back a recently forgotten memory
+//
+// The memory keeps its original ID and timestamps. Returns an error if the
+// memory is not in RecentlyForgotten (never forgotten, or already purged).
+func (a *Agent) RestoreMemory(memoryID string) error {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cMemoryID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cMemoryID))
+
+	result := C.thymos_agent_restore_memory(a.handle, cMemoryID, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// Compact drops the agent's records of memories no longer in the store
+//
+// The agent keeps access counts, access timelines, TTLs and concept index
+// entries per memory and drops them when it deletes the memory, but memories
+// deleted some other way, such as by another agent on the same data
+// directory, leave theirs behind. Compact drops those and purges forgotten
+// memories whose grace period has passed. It reads every memory ID in the
+// store. Returns the number of records dropped.
+func (a *Agent) Compact() (int, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	result := C.thymos_agent_compact(a.handle, &cErr)
+	if result < 0 {
+		return 0, takeError(&cErr)
+	}
+	return int(result), nil
+}
+
+// ForgettingSuggestion holds forgetting-curve parameters derived from usage
+type ForgettingSuggestion struct {
+	// ReuseInterval is the time from creation by which most re-accessed
+	// memories were re-used (the 75th percentile, at least an hour)
+	ReuseInterval time.Duration
+	// DecayRate is the rate for MemoryConfig.SetDecayRate under which age
+	// decay halves a memory's strength over ReuseInterval
+	DecayRate float64
+	// RetentionFloor is the strength below which memories are rarely re-accessed
+	// and can be pruned
+	RetentionFloor float64
+	// SampleSize is the number of re-accessed memories the suggestion is based on
+	SampleSize int
+}
+
+// SuggestForgettingParams suggests forgetting-curve parameters from usage
+//
+// The suggestion is based on observed re-access intervals (time from creation
+// to last access). DecayRate keeps most memories above half strength at the
+// point they are typically re-used; RetentionFloor can be passed to Prune.
+// Returns an error if no memory has been re-accessed yet.
+func (a *Agent) SuggestForgettingParams() (*ForgettingSuggestion, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	var cInterval C.uint64_t
+	var cRate, cFloor C.double
+	var cSamples C.size_t
+	result := C.thymos_agent_suggest_forgetting(a.handle, &cInterval, &cRate, &cFloor, &cSamples, &cErr)
+	if result != 0 {
+		return nil, takeError(&cErr)
+	}
+
+	return &ForgettingSuggestion{
+		ReuseInterval:  time.Duration(cInterval) * time.Millisecond,
+		DecayRate:      float64(cRate),
+		RetentionFloor: float64(cFloor),
+		SampleSize:     int(cSamples),
+	}, nil
+}
+
+// ProjectRetention returns the strength a memory will have at the given time
+//
+// The forgetting curve is applied forward assuming no further access, so
+// callers can find memories that will fade and reinforce them beforehand.
+// This is a pure projection and does not count as an access.
+func (a *Agent) ProjectRetention(memoryID string, at time.Time) (float64, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+
+	var cStrength C.double
+	result := C.thymos_agent_project_retention(a.handle, cID, C.int64_t(at.UnixMilli()), &cStrength, &cErr)
+	if result != 0 {
+		return 0, takeError(&cErr)
+	}
+
+	return float64(cStrength), nil
+}
+
+// ReinforceMemory restarts a memory's forgetting curve
+//
+// The memory's LastAccessed is set to now, as if it had just been retrieved,
+// so its Strength climbs back and Prune keeps it while it is still relevant.
+// Returns an error wrapping ErrMemoryNotFound if no memory has the ID.
+func (a *Agent) ReinforceMemory(memoryID string) error {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+
+	result := C.thymos_agent_reinforce(a.handle, cID, &cErr)
+	if result != 0 {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotFound {
+			return fmt.Errorf("%w: %s", ErrMemoryNotFound, memoryID)
+		}
+		return err
+	}
+	return nil
+}
+
+// ============================================================================
+// Maintenance
+// ============================================================================
+
+// MaintenanceConfig sets how often background maintenance tasks run
+//
+// A zero interval disables that task.
+type MaintenanceConfig struct {
+	// PruneInterval is how often to forget weak memories with Prune
+	PruneInterval time.Duration
+	// PruneThreshold is the strength below which Prune forgets memories
+	PruneThreshold float64
+	// EmbedInterval is how often to repair unembedded memories with EmbedPending
+	EmbedInterval time.Duration
+	// CompactInterval is how often to drop stale records with Compact
+	CompactInterval time.Duration
+	// DedupeInterval is how often to forget duplicates with DeduplicateMemories
+	DedupeInterval time.Duration
+	// DedupeThreshold is the similarity DeduplicateMemories treats as duplicate
+	DedupeThreshold float64
+	// OnError, if set, is called with the task name ("prune", "embed",
+	// "compact" or "dedupe") when a run fails. It runs on the maintenance
+	// goroutine and must not call StopMaintenance, SetMaintenanceSchedule or
+	// Close. The schedule does not keep the agent reachable, but an OnError
+	// that refers to the agent does.
+	OnError func(task string, err error)
+}
+
+type maintenance struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// SetMaintenanceSchedule runs maintenance tasks on a background goroutine
+//
+// Tasks run one at a time, so runs never overlap; a tick that arrives while
+// another task is running is skipped. Calling it again replaces the current
+// schedule. The schedule stops on StopMaintenance or Close, and holds only a
+// weak reference to the agent, so an agent that is never closed can still be
+// collected (see SetLeakHandler).
+func (a *Agent) SetMaintenanceSchedule(cfg MaintenanceConfig) error {
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	if cfg.PruneInterval < 0 || cfg.EmbedInterval < 0 || cfg.CompactInterval < 0 || cfg.DedupeInterval < 0 {
+		return errors.New("thymos: maintenance intervals must not be negative")
+	}
+	if cfg.PruneInterval > 0 && (cfg.PruneThreshold < 0 || cfg.PruneThreshold > 1) {
+		return errors.New("thymos: prune threshold must be between 0 and 1")
+	}
+	if cfg.DedupeInterval > 0 && (cfg.DedupeThreshold <= 0 || cfg.DedupeThreshold > 1) {
+		return errors.New("thymos: dedupe threshold must be in (0, 1]")
+	}
+	if a.IsClosed() {
+		return ErrNilHandle
+	}
+
+	a.maintMu.Lock()
+	defer a.maintMu.Unlock()
+
+	a.stopMaintenanceLocked()
+	if cfg.PruneInterval == 0 && cfg.EmbedInterval == 0 && cfg.CompactInterval == 0 && cfg.DedupeInterval == 0 {
+		return nil
+	}
+
+	m := &maintenance{stop: make(chan struct{}), done: make(chan struct{})}
+	a.maint = m
+	go runMaintenance(weak.Make(a), cfg, m)
+	return nil
+}
+
+// StopMaintenance stops the maintenance schedule, waiting for a running task
+// to finish. It is a no-op if no schedule is set.
+func (a *Agent) StopMaintenance() {
+	a.maintMu.Lock()
+	defer a.maintMu.Unlock()
+	a.stopMaintenanceLocked()
+}
+
+func (a *Agent) stopMaintenanceLocked() {
+	if a.maint == nil {
+		return
+	}
+	close(a.maint.stop)
+	<-a.maint.done
+	a.maint = nil
+}
+
+// runMaintenance runs the tasks of cfg until m is stopped or the agent is
+// collected. It holds the agent only while a task runs, so the agent's
+// finalizer can still run between tasks.
+func runMaintenance(agent weak.Pointer[Agent], cfg MaintenanceConfig, m *maintenance) {
+	defer close(m.done)
+
+	var tickers []*time.Ticker
+	defer func() {
+		for _, t := range tickers {
+			t.Stop()
+		}
+	}()
+	ticker := func(interval time.Duration) <-chan time.Time {
+		if interval <= 0 {
+			return nil
+		}
+		t := time.NewTicker(interval)
+		tickers = append(tickers, t)
+		return t.C
+	}
+	pruneC := ticker(cfg.PruneInterval)
+	embedC := ticker(cfg.EmbedInterval)
+	compactC := ticker(cfg.CompactInterval)
+	dedupeC := ticker(cfg.DedupeInterval)
+
+	// run reports whether the agent was still reachable
+	run := func(task string, fn func(a *Agent) error) bool {
+		a := agent.Value()
+		if a == nil {
+			return false
+		}
+		if err := fn(a); err != nil && cfg.OnError != nil {
+			cfg.OnError(task, err)
+		}
+		return true
+	}
+
+	for {
+		var ok bool
+		select {
+		case <-m.stop:
+			return
+		case <-pruneC:
+			ok = run("prune", func(a *Agent) error {
+				_, err := a.Prune(cfg.PruneThreshold)
+				return err
+			})
+		case <-embedC:
+			ok = run("embed", func(a *Agent) error {
+				_, err := a.EmbedPending()
+				return err
+			})
+		case <-compactC:
+			ok = run("compact", func(a *Agent) error {
+				_, err := a.Compact()
+				return err
+			})
+		case <-dedupeC:
+			ok = run("dedupe", func(a *Agent) error {
+				_, err := a.DeduplicateMemories(cfg.DedupeThreshold)
+				return err
+			})
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// ============================================================================
+// Lifecycle State
+// ============================================================================
+
+// ExportLifecycleState writes the retention state of all memories to w
+//
+// The state is JSON holding each memory's ID and last access time; memory
+// content is not included. Restore it with ImportLifecycleState.
+func (a *Agent) ExportLifecycleState(w io.Writer) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cJSON := C.thymos_agent_export_lifecycle(a.handle, &cErr)
+	if cJSON == nil {
+		return takeError(&cErr)
+	}
+	defer C.thymos_free_string(cJSON)
+
+	_, err := io.WriteString(w, C.GoString(cJSON))
+	return err
+}
+
+// ImportLifecycleState restores retention state written by ExportLifecycleState
+//
+// The state must cover exactly the agent's current memories; otherwise nothing
+// is changed and an error is returned. Memory content is never modified.
+func (a *Agent) ImportLifecycleState(r io.Reader) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	defer a.trackWrite()()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cState := C.CString(string(data))
+	defer C.free(unsafe.Pointer(cState))
+
+	result := C.thymos_agent_import_lifecycle(a.handle, cState, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// ============================================================================
+// Store Analytics
+// ============================================================================
+
+// CountPoint is the memory count at a point in time
+type CountPoint struct {
+	At    time.Time
+	Count int
+}
+
+// CountHistory returns the memory count at each interval from since to now
+//
+// Counts come from periodic samples, so each point holds the latest sample
+// taken at or before it; points before the first sample are omitted, and at
+// most the latest 10,000 points are returned. Returns
+// ErrCountHistoryUnavailable unless MemoryConfig.SetCountSampling was enabled.
+func (a *Agent) CountHistory(since time.Time, interval time.Duration) ([]CountPoint, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+	if interval <= 0 {
+		return nil, errors.New("thymos: count history interval must be positive")
+	}
+
+	cJSON := C.thymos_agent_count_history(a.handle, C.int64_t(since.UnixMilli()), C.int64_t(interval.Milliseconds()), &cErr)
+	if cJSON == nil {
+		err := takeError(&cErr)
+		if err != nil && err.Error() == "count history not available: sampling is disabled" {
+			return nil, ErrCountHistoryUnavailable
+		}
+		return nil, err
+	}
+	defer C.thymos_free_string(cJSON)
+
+	var raw []struct {
+		At    time.Time `json:"at"`
+		Count int       `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(C.GoString(cJSON)), &raw); err != nil {
+		return nil, fmt.Errorf("thymos: invalid count history JSON: %w", err)
+	}
+
+	points := make([]CountPoint, len(raw))
+	for i, p := range raw {
+		points[i] = CountPoint{At: p.At, Count: p.Count}
+	}
+	return points, nil
+}
+
+// AccessTimeline returns the times a memory was accessed, oldest first
+//
+// Accesses are retrievals through this agent (searches and GetMemory). A
+// memory never accessed returns an empty slice. Returns
+// ErrAccessTimelineUnavailable unless MemoryConfig.SetTrackAccessTimeline was
+// enabled.
+func (a *Agent) AccessTimeline(memoryID string) ([]time.Time, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+
+	cJSON := C.thymos_agent_access_timeline(a.handle, cID, &cErr)
+	if cJSON == nil {
+		err := takeError(&cErr)
+		if err != nil && err.Error() == "access timeline not available: tracking is disabled" {
+			return nil, ErrAccessTimelineUnavailable
+		}
+		return nil, err
+	}
+	defer C.thymos_free_string(cJSON)
+
+	var timeline []time.Time
+	if err := json.Unmarshal([]byte(C.GoString(cJSON)), &timeline); err != nil {
+		return nil, fmt.Errorf("thymos: invalid access timeline JSON: %w", err)
+	}
+	return timeline, nil
+}
+
+// AgeHistogram counts memories by age (time since CreatedAt)
+//
+// buckets are ascending upper bounds. The result has len(buckets)+1 entries;
+// the last entry counts memories older than the final boundary.
+func (a *Agent) AgeHistogram(buckets []time.Duration) ([]int, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	var cBoundaries *C.int64_t
+	if len(buckets) > 0 {
+		boundaries := make([]C.int64_t, len(buckets))
+		for i, b := range buckets {
+			boundaries[i] = C.int64_t(b.Milliseconds())
+		}
+		cBoundaries = &boundaries[0]
+	}
+
+	cCounts := make([]C.size_t, len(buckets)+1)
+	result := C.thymos_agent_age_histogram(a.handle, cBoundaries, C.size_t(len(buckets)), &cCounts[0], &cErr)
+	if result != 0 {
+		return nil, takeError(&cErr)
+	}
+
+	counts := make([]int, len(cCounts))
+	for i, c := range cCounts {
+		counts[i] = int(c)
+	}
+	return counts, nil
+}
+
+// DominantLanguage returns the most common language across stored memories
+//
+// The language is an ISO 639-3 code (e.g. "eng") and the fraction is its share
+// of memories whose language could be detected. A store with no detectable
+// language returns "" and 0.
+func (a *Agent) DominantLanguage() (string, float64, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", 0, ErrNilHandle
+	}
+
+	var cFraction C.double
+	cLang := C.thymos_agent_dominant_language(a.handle, &cFraction, &cErr)
+	if cLang == nil {
+		return "", 0, takeError(&cErr)
+	}
+	defer C.thymos_free_string(cLang)
+
+	return C.GoString(cLang), float64(cFraction), nil
+}
+
+// TermFreq is a term and the number of times it occurs across memories
+type TermFreq struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// TermFrequencies returns the topN most frequent terms across all memories
+//
+// Terms are lowercased runs of letters and digits, counted without stemming or
+// stop-word removal. Ties are ordered alphabetically. Set topN to 0 for all
+// terms.
+func (a *Agent) TermFrequencies(topN int) ([]TermFreq, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cTopN := C.size_t(topN)
+	if topN < 0 {
+		cTopN = 0
+	}
+
+	cJSON := C.thymos_agent_term_frequencies(a.handle, cTopN, &cErr)
+	if cJSON == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_string(cJSON)
+
+	var terms []TermFreq
+	if err := json.Unmarshal([]byte(C.GoString(cJSON)), &terms); err != nil {
+		return nil, fmt.Errorf("thymos: invalid term frequencies JSON: %w", err)
+	}
+	return terms, nil
+}
+
+// ============================================================================
+// Blob Attachments
+// ============================================================================
+
+// AttachBlob stores data under name alongside a memory
+//
+// Blobs are kept in the agent's data directory, so they require embedded or
+// hybrid mode. Attaching an existing name replaces it. Data larger than the
+// limit set by MemoryConfig.SetMaxBlobSize is rejected.
+func (a *Agent) AttachBlob(memoryID string, name string, data []byte) error {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var cData *C.uint8_t
+	if len(data) > 0 {
+		cData = (*C.uint8_t)(C.CBytes(data))
+		defer C.free(unsafe.Pointer(cData))
+	}
+
+	result := C.thymos_agent_attach_blob(a.handle, cID, cName, cData, C.size_t(len(data)), &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// GetBlob returns the blob stored under name alongside a memory
+func (a *Agent) GetBlob(memoryID, name string) ([]byte, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var cLen C.size_t
+	cData := C.thymos_agent_get_blob(a.handle, cID, cName, &cLen, &cErr)
+	if cData == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_bytes(cData, cLen)
+
+	return C.GoBytes(unsafe.Pointer(cData), C.int(cLen)), nil
+}
+
+// ============================================================================
+// Saved Queries
+// ============================================================================
+
+// Query is a reusable search definition (see SaveQuery)
+type Query struct {
+	// Text is the search query
+	Text string `json:"text"`
+	// Type restricts results to one memory type; nil searches every type
+	Type *MemoryType `json:"memory_type,omitempty"`
+}
+
+// SaveQuery saves q under name so it can be run later with RunSavedQuery
+//
+// Saved queries are stored in the agent's data directory and survive
+// restarts; saving an existing name replaces it. Requires local storage
+// (embedded or hybrid mode).
+func (a *Agent) SaveQuery(name string, q *Query) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	if q == nil {
+		return errors.New("thymos: query is nil")
+	}
+	queryJSON, err := json.Marshal(q)
+	if err != nil {
+		return fmt.Errorf("thymos: invalid query: %w", err)
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return ErrNilHandle
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cQuery := C.CString(string(queryJSON))
+	defer C.free(unsafe.Pointer(cQuery))
+
+	if C.thymos_agent_save_query(a.handle, cName, cQuery, &cErr) != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// RunSavedQuery runs the query saved under name
+//
+// It searches like SearchMemories, or SearchMemoriesByType when the query has
+// a Type. Returns an error wrapping ErrSavedQueryNotFound if no query has the
+// name. limit is interpreted as by SearchMemories.
+func (a *Agent) RunSavedQuery(name string, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_run_saved_query(a.handle, cName, cLimit, &cErr)
+	if resultsPtr == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrSavedQueryNotFound, name)
+		}
+		return nil, err
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// ListSavedQueries returns the names of the agent's saved queries, sorted
+func (a *Agent) ListSavedQueries() ([]string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	listPtr := C.thymos_agent_list_saved_queries(a.handle, &cErr)
+	if listPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_string_list(listPtr)
+
+	return convertCStringList(listPtr), nil
+}
+
+// ============================================================================
+// Embedding Repair
+// ============================================================================
+
+// UnembeddedMemories lists memories stored without an embedding, oldest first
+//
+// These memories are invisible to vector search, typically because the
+// embedder was unavailable during ingestion. A healthy store returns an empty
+// slice. limit is interpreted as by SearchMemories.
+func (a *Agent) UnembeddedMemories(limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_unembedded(a.handle, cLimit, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// EmbedPending embeds every memory listed by UnembeddedMemories and returns
+// the number repaired
+//
+// Requires an embedding provider. Memories embedded before an error stay
+// embedded, so it is safe to call again.
+func (a *Agent) EmbedPending() (int, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	count := C.thymos_agent_embed_pending(a.handle, &cErr)
+	if count < 0 {
+		return 0, takeError(&cErr)
+	}
+	return int(count), nil
+}
+
+// ============================================================================
+// Contradiction Detection
+// ============================================================================
+
+// ContradictionPair is two memories that appear to contradict each other
+type ContradictionPair struct {
+	A          *Memory
+	B          *Memory
+	Confidence float64
+}
+
+// FindContradictions returns up to limit memory pairs that appear to
+// contradict each other, most confident first
+//
+// Detection is lexical: it flags statements that differ only by negation
+// ("Paris is in France" / "Paris is not in France") and statements sharing a
+// subject and relation but ending in different objects ("Paris is in France" /
+// "Paris is in Italy"). It scans the whole store, so run it as a background
+// job. limit is interpreted as by SearchMemories.
+func (a *Agent) FindContradictions(limit int) ([]ContradictionPair, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var cConfidences *C.double
+	resultsPtr := C.thymos_agent_contradictions(a.handle, cLimit, &cConfidences, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	memories := convertSearchResults(resultsPtr)
+	count := len(memories) / 2
+	defer C.thymos_free_doubles(cConfidences, C.size_t(count))
+
+	pairs := make([]ContradictionPair, count)
+	if count == 0 {
+		return pairs, nil
+	}
+	confidences := unsafe.Slice(cConfidences, count)
+	for i := range pairs {
+		pairs[i] = ContradictionPair{
+			A:          memories[2*i],
+			B:          memories[2*i+1],
+			Confidence: float64(confidences[i]),
+		}
+	}
+	return pairs, nil
+}
+
+// ============================================================================
+// Graph Export
+// ============================================================================
+
+// Graph node kinds
+const (
+	GraphNodeMemory = "memory"
+	GraphNodeEntity = "entity"
+)
+
+// Graph edge kinds
+const (
+	GraphEdgeMentions = "mentions"
+	GraphEdgeCoOccurs = "co_occurs"
+)
+
+// GraphNode is a memory or an extracted entity
+//
+// Memory node IDs are "memory:<memory id>"; entity node IDs are
+// "entity:<entity type>:<lowercased text>".
+type GraphNode struct {
+	ID         string `json:"id"`
+	Kind       string `json:"kind"`
+	Label      string `json:"label"`
+	EntityType string `json:"entity_type,omitempty"`
+}
+
+// GraphEdge connects two graph nodes
+//
+// Mentions edges run from a memory to an entity and carry the entity's
+// significance. CoOccurs edges join two entities mentioned by the same memory
+// and carry the number of such memories.
+type GraphEdge struct {
+	Source string  `json:"source"`
+	Target string  `json:"target"`
+	Kind   string  `json:"kind"`
+	Weight float64 `json:"weight"`
+}
+
+// MemoryGraph is the entity-memory graph of an agent's store
+type MemoryGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// ExportGraph returns the entity-memory graph of the agent's store
+//
+// The graph marshals to the same JSON format produced by the C API, so it can
+// be fed directly to graph-rendering tools. See the README for the format.
+func (a *Agent) ExportGraph() (*MemoryGraph, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cJSON := C.thymos_agent_export_graph(a.handle, &cErr)
+	if cJSON == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_string(cJSON)
+
+	graph := &MemoryGraph{}
+	if err := json.Unmarshal([]byte(C.GoString(cJSON)), graph); err != nil {
+		return nil, fmt.Errorf("thymos: invalid graph JSON: %w", err)
+	}
+	return graph, nil
+}
+
+// ============================================================================
+// Concept Index
+// ============================================================================
+
+// conceptRebuildBatch is the number of memories extracted per rebuild step,
+// bounding how long cancellation waits between progress reports
+const conceptRebuildBatch = 32
+
+// RebuildConceptIndex re-runs concept extraction over every memory and
+// replaces the agent's concept index, which ExportGraph reads entities from
+//
+// progress, if non-nil, is called after each batch with the number of
+// memories processed so far and the total. See RebuildConceptIndexContext.
+func (a *Agent) RebuildConceptIndex(progress func(done, total int)) error {
+	return a.RebuildConceptIndexContext(context.Background(), progress)
+}
+
+// RebuildConceptIndexContext is RebuildConceptIndex with cancellation
+//
+// Cancellation is checked between batches and returns ctx.Err(). The old
+// index stays in use until a rebuild completes, and the next call resumes an
+// interrupted rebuild rather than starting over. Memories stored after a
+// rebuild starts are extracted on demand instead.
+func (a *Agent) RebuildConceptIndexContext(ctx context.Context, progress func(done, total int)) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		done, total, complete, err := a.rebuildConceptsStep()
+		if err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(done, total)
+		}
+		if complete {
+			return nil
+		}
+	}
+}
+
+// rebuildConceptsStep extracts one batch of a concept index rebuild
+func (a *Agent) rebuildConceptsStep() (done, total int, complete bool, err error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, 0, false, ErrNilHandle
+	}
+
+	var cDone, cTotal C.uint64_t
+	result := C.thymos_agent_rebuild_concepts(a.handle, conceptRebuildBatch, &cDone, &cTotal, &cErr)
+	if result < 0 {
+		return 0, 0, false, takeError(&cErr)
+	}
+	return int(cDone), int(cTotal), result == 1, nil
+}
+
+// Concept is an entity extracted from a memory's content
+type Concept struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`       // e.g. "person", "location"
+	Confidence float64 `json:"confidence"` // significance score (0.0-1.0)
+}
+
+// GetConcepts returns the significant concepts extracted from a memory
+//
+// Concepts come from the concept index when it is current for the memory and
+// are extracted on demand otherwise. Returns ErrMemoryNotFound if no memory
+// has the ID.
+func (a *Agent) GetConcepts(memoryID string) ([]Concept, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+
+	cJSON := C.thymos_agent_get_concepts(a.handle, cID, &cErr)
+	if cJSON == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrMemoryNotFound, memoryID)
+		}
+		return nil, err
+	}
+	defer C.thymos_free_string(cJSON)
+
+	var concepts []Concept
+	if err := json.Unmarshal([]byte(C.GoString(cJSON)), &concepts); err != nil {
+		return nil, fmt.Errorf("thymos: invalid concepts JSON: %w", err)
+	}
+	return concepts, nil
+}
+
+// RelatedMemories returns memories that share extracted entities with a memory
+//
+// Each result's Score is its connection strength: the sum, over shared
+// entities, of the entity's significance in both memories multiplied
+// together. Results are ordered strongest first; memories sharing no entity
+// are omitted. Concepts come from the concept index where current (see
+// RebuildConceptIndex); memories it lacks are extracted and added to it, so
+// only the first call on an unindexed store is slow. limit is interpreted as
+// by SearchMemories. Returns
+// ErrMemoryNotFound if no memory has the ID.
+func (a *Agent) RelatedMemories(memoryID string, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_related_memories(a.handle, cID, cLimit, &cErr)
+	if resultsPtr == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrMemoryNotFound, memoryID)
+		}
+		return nil, err
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// ============================================================================
+// Knowledge Overlap
+// ============================================================================
+
+// KnowledgeOverlap returns how much two agents' knowledge overlaps
+//
+// The score is Jaccard-like in [0, 1]: memories are matched across the two
+// stores by embedding similarity. Both agents must use the same embedding
+// model, and providers of the same dimension; a mismatch returns an error.
+// Stored embeddings are reused, and memories without one are embedded in
+// batches of each agent's embedding batch size. Two empty stores score 0.
+//
+// Every memory of a is compared with every memory of b, so the cost grows as
+// O(N·M) in the two store sizes.
+func KnowledgeOverlap(a, b *Agent) (float64, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a == nil || b == nil {
+		return 0, ErrNilHandle
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if b != a {
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+	}
+
+	if a.handle == nil || b.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	var cScore C.double
+	result := C.thymos_knowledge_overlap(a.handle, b.handle, &cScore, &cErr)
+	if result != 0 {
+		return 0, takeError(&cErr)
+	}
+	return float64(cScore), nil
+}
+
+// ============================================================================
+// Embedding Outliers
+// ============================================================================
+
+// EmbeddingOutliers returns up to limit memories whose embeddings lie
+// farthest from the centroid of the store, farthest first
+//
+// Outliers are often noise, errors or off-topic content. Distance is cosine
+// distance to the mean embedding. Memories stored without an embedding are
+// skipped; run EmbedPending first to include them. Returns an empty slice with
+// fewer than two embedded memories. limit is interpreted as by SearchMemories.
+func (a *Agent) EmbeddingOutliers(limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_embedding_outliers(a.handle, cLimit, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// ============================================================================
+// Agent Merge
+// ============================================================================
+
+// ConflictPolicy decides which memory survives when MergeAgents finds the same
+// memory ID in both agents
+type ConflictPolicy int
+
+const (
+	// KeepDst keeps the destination agent's memory
+	KeepDst ConflictPolicy = iota
+	// KeepSrc overwrites the destination's memory with the source's
+	KeepSrc
+	// KeepNewer keeps whichever memory was created later
+	KeepNewer
+)
+
+// MergeAgents copies all of src's memories into dst and returns the number
+// written to dst
+//
+// Memory IDs are preserved; collisions are resolved by onConflict. Stored
+// embeddings are reused when both agents use the same embedding model and
+// they match the dimension of dst's embedding provider, and are re-embedded
+// otherwise. src is left unchanged.
+func MergeAgents(dst, src *Agent, onConflict ConflictPolicy) (int, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if dst == nil || src == nil {
+		return 0, ErrNilHandle
+	}
+	if dst == src {
+		return 0, errors.New("thymos: cannot merge an agent into itself")
+	}
+	if dst.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	defer dst.trackWrite()()
+
+	// Lock in liveID order, so merging a into b while b merges into a can't
+	// deadlock behind a Close
+	for _, agent := range byLiveID([]*Agent{dst, src}) {
+		agent.mu.RLock()
+		defer agent.mu.RUnlock()
+	}
+
+	if dst.handle == nil || src.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	count := C.thymos_merge_agents(dst.handle, src.handle, C.int(onConflict), &cErr)
+	if count < 0 {
+		return 0, takeError(&cErr)
+	}
+	return int(count), nil
+}
+
+// ============================================================================
+// Shared Batches
+// ============================================================================
+
+// SharedBatch batches shared-backend writes across several hybrid agents
+//
+// Writes are buffered until Commit, which applies them one at a time. A batch
+// is not a transaction: the shared backend cannot make several writes visible
+// at once, so other agents can see a write before the rest are applied, and
+// if one fails the writes already applied are deleted again on a best-effort
+// basis (see Commit).
+type SharedBatch struct {
+	agents []*Agent
+	writes []sharedWrite
+	done   bool
+	mu     sync.Mutex
+}
+
+type sharedWrite struct {
+	agent   *Agent
+	content string
+}
+
+// BeginSharedBatch starts a batch of shared-backend writes across the given
+// agents
+//
+// Returns ErrNotHybridMode if any agent is not in hybrid mode.
+func BeginSharedBatch(agents []*Agent) (*SharedBatch, error) {
+	if len(agents) == 0 {
+		return nil, errors.New("thymos: shared batch requires at least one agent")
+	}
+
+	batch := &SharedBatch{}
+	for _, agent := range agents {
+		if agent == nil {
+			return nil, ErrNilHandle
+		}
+		hybrid, err := agent.IsHybrid()
+		if err != nil {
+			return nil, err
+		}
+		if !hybrid {
+			return nil, ErrNotHybridMode
+		}
+		if !slices.Contains(batch.agents, agent) {
+			batch.agents = append(batch.agents, agent)
+		}
+	}
+
+	return batch, nil
+}
+
+// RememberShared queues a shared memory write on behalf of agent
+//
+// The agent must be one of the agents the batch was started with.
+func (b *SharedBatch) RememberShared(agent *Agent, content string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.done {
+		return ErrBatchDone
+	}
+
+	if !slices.Contains(b.agents, agent) {
+		return errors.New("thymos: agent is not part of this batch")
+	}
+	if agent.readOnly {
+		return ErrReadOnly
+	}
+	if err := thymosapi.CheckContent(content); err != nil {
+		return err
+	}
+	b.writes = append(b.writes, sharedWrite{agent: agent, content: content})
+	return nil
+}
+
+// Commit applies all queued writes in order and returns the new memory IDs
+//
+// If a write fails, the writes already applied are deleted again and the
+// write's error is returned. Until then they are visible to other agents, and
+// a deletion that fails leaves its memory behind; the error then reports how
+// many memories could not be deleted.
+func (b *SharedBatch) Commit() ([]string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.done {
+		return nil, ErrBatchDone
+	}
+	b.done = true
+
+	if len(b.writes) == 0 {
+		return []string{}, nil
+	}
+
+	for _, w := range b.writes {
+		defer w.agent.trackWrite()()
+	}
+
+	for _, agent := range byLiveID(b.agents) {
+		agent.mu.RLock()
+		defer agent.mu.RUnlock()
+
+		if agent.handle == nil {
+			return nil, ErrNilHandle
+		}
+	}
+
+	cHandles := make([]unsafe.Pointer, len(b.writes))
+	contents := make([]string, len(b.writes))
+	for i, w := range b.writes {
+		cHandles[i] = w.agent.handle
+		contents[i] = w.content
+	}
+	cContents, freeContents := newCStringArray(contents)
+	defer freeContents()
+
+	listPtr := C.thymos_shared_batch_commit(&cHandles[0], &cContents[0], C.size_t(len(b.writes)), &cErr)
+	if listPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_string_list(listPtr)
+
+	return convertCStringList(listPtr), nil
+}
+
+// Discard drops all queued writes
+//
+// Nothing is written to the shared backend until Commit, so Discard only
+// clears the buffer.
+func (b *SharedBatch) Discard() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.done {
+		return ErrBatchDone
+	}
+	b.done = true
+	b.writes = nil
+	return nil
+}
+
+// ============================================================================
+// Ingest Benchmark
+// ============================================================================
+
+// IngestBench holds the results of BenchmarkIngest
+type IngestBench struct {
+	MemoriesPerSecond float64 `json:"memories_per_second"`
+	P50EmbedMicros    int64   `json:"p50_embed_micros"` // 0 without an embedding provider
+	P99EmbedMicros    int64   `json:"p99_embed_micros"` // 0 without an embedding provider
+}
+
+// BenchmarkIngest measures end-to-end ingestion throughput
+//
+// Each sample is embedded and stored like a regular memory, timing the whole
+// run and each embedding. The stored samples are deleted again afterwards,
+// even if ingestion fails part-way, so no test data is left behind.
+func (a *Agent) BenchmarkIngest(sampleContents []string) (*IngestBench, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	if len(sampleContents) == 0 {
+		return nil, errors.New("thymos: no sample contents to ingest")
+	}
+
+	defer a.trackWrite()()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cContents, freeContents := newCStringArray(sampleContents)
+	defer freeContents()
+
+	cJSON := C.thymos_agent_benchmark_ingest(a.handle, &cContents[0], C.size_t(len(cContents)), &cErr)
+	if cJSON == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_string(cJSON)
+
+	bench := &IngestBench{}
+	if err := json.Unmarshal([]byte(C.GoString(cJSON)), bench); err != nil {
+		return nil, fmt.Errorf("thymos: invalid ingest benchmark JSON: %w", err)
+	}
+	return bench, nil
+}
+
+// ============================================================================
+// Pub/Sub
+// ============================================================================
+
+// subscriptionPoll is how long a subscription waits for a message before
+// checking whether it has been stopped
+const subscriptionPoll = 100 * time.Millisecond
+
+// subscriptionBuffer is how many messages a subscription channel holds before
+// delivery blocks
+const subscriptionBuffer = 64
+
+// Message is a pub/sub message delivered to a subscription
+type Message struct {
+	SenderID string // empty for messages sent with Broker.Publish
+	Topic    string
+	Payload  string
+	SentAt   time.Time
+}
+
+// Broker routes pub/sub messages between agents in this process
+//
+// Register agents with a broker, then use Agent.Subscribe and Agent.Publish.
+// Every message goes to all subscriptions on its topic, including the
+// sender's own.
+type Broker struct {
+	handle unsafe.Pointer
+	mu     sync.RWMutex
+}
+
+// NewBroker creates an in-process pub/sub broker
+func NewBroker() (*Broker, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	handle := C.thymos_broker_new(&cErr)
+	if handle == nil {
+		return nil, takeError(&cErr)
+	}
+
+	broker := &Broker{handle: handle}
+	runtime.SetFinalizer(broker, (*Broker).Close)
+	return broker, nil
+}
+
+// Close releases the broker resources
+//
+// Existing subscriptions stay open but receive no further messages. Close is
+// idempotent and safe to call multiple times.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.handle != nil {
+		C.thymos_broker_free(b.handle)
+		b.handle = nil
+	}
+}
+
+// Register lets a publish and subscribe through the broker
+//
+// An agent can be registered with one broker; registering it again with the
+// same broker is a no-op.
+func (b *Broker) Register(a *Agent) error {
+	if a == nil || a.IsClosed() {
+		return ErrNilHandle
+	}
+
+	b.mu.RLock()
+	closed := b.handle == nil
+	b.mu.RUnlock()
+	if closed {
+		return ErrNilBroker
+	}
+
+	a.brokerMu.Lock()
+	defer a.brokerMu.Unlock()
+
+	if a.broker != nil && a.broker != b {
+		return errors.New("thymos: agent is already registered with another broker")
+	}
+	a.broker = b
+	return nil
+}
+
+// Publish sends message to every subscription on topic, with no sender
+func (b *Broker) Publish(topic, message string) error {
+	return b.publish("", topic, message)
+}
+
+func (b *Broker) publish(senderID, topic, message string) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if topic == "" {
+		return errors.New("thymos: topic must not be empty")
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.handle == nil {
+		return ErrNilBroker
+	}
+
+	cSender := C.CString(senderID)
+	defer C.free(unsafe.Pointer(cSender))
+	cTopic := C.CString(topic)
+	defer C.free(unsafe.Pointer(cTopic))
+	cMessage := C.CString(message)
+	defer C.free(unsafe.Pointer(cMessage))
+
+	result := C.thymos_broker_publish(b.handle, cSender, cTopic, cMessage, &cErr)
+	if result != 0 {
+		return takeError(&cErr)
+	}
+	return nil
+}
+
+// Publish sends message to every subscription on topic, with this agent as
+// the sender
+//
+// Returns ErrNotRegistered unless the agent was registered with a Broker.
+func (a *Agent) Publish(topic, message string) error {
+	a.brokerMu.Lock()
+	broker := a.broker
+	a.brokerMu.Unlock()
+
+	if broker == nil {
+		return ErrNotRegistered
+	}
+
+	id, err := a.ID()
+	if err != nil {
+		return err
+	}
+	return broker.publish(id, topic, message)
+}
+
+// inbox holds every subscription of an agent on one native subscription, so
+// a single goroutine waits for all of their messages
+type inbox struct {
+	handle unsafe.Pointer
+	stop   chan struct{}
+	done   chan struct{}
+
+	// mu guards subs and the handle's topics; the handle is nil once freed
+	mu   sync.Mutex
+	subs map[uint64]*subscription
+}
+
+// subscription is one Subscribe call's channel
+type subscription struct {
+	id       uint64
+	messages chan Message
+	// done is closed by cancel, so a delivery to the channel gives up
+	done chan struct{}
+	// sendMu is held while a message is delivered, so the channel is not
+	// closed under it; closed is guarded by sendMu
+	sendMu sync.Mutex
+	closed bool
+}
+
+// Subscribe delivers messages published on topic to the returned channel
+//
+// The channel is closed by Unsubscribe, which stops the subscription, or
+// when the agent is closed. All of an agent's subscriptions are read by one
+// goroutine, and delivery blocks once a channel holds 64 undelivered
+// messages, so keep reading from it: a full channel holds up the agent's
+// other subscriptions too. Returns ErrNotRegistered unless the agent was
+// registered with a Broker.
+func (a *Agent) Subscribe(topic string) (<-chan Message, error) {
+	if topic == "" {
+		return nil, errors.New("thymos: topic must not be empty")
+	}
+
+	a.brokerMu.Lock()
+	defer a.brokerMu.Unlock()
+
+	if a.IsClosed() {
+		return nil, ErrNilHandle
+	}
+	if a.broker == nil {
+		return nil, ErrNotRegistered
+	}
+
+	if a.inbox == nil {
+		in := &inbox{
+			handle: C.thymos_subscription_new(),
+			stop:   make(chan struct{}),
+			done:   make(chan struct{}),
+			subs:   make(map[uint64]*subscription),
+		}
+		a.inbox = in
+		go in.run()
+	}
+	in := a.inbox
+
+	// Hold in.mu until the subscription is in subs, so its first message
+	// finds it there
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	id, err := a.broker.subscribe(in.handle, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &subscription{
+		id:       id,
+		messages: make(chan Message, subscriptionBuffer),
+		done:     make(chan struct{}),
+	}
+	in.subs[id] = sub
+	return sub.messages, nil
+}
+
+// Unsubscribe stops the subscription that delivers to messages, a channel
+// returned by Subscribe, and closes the channel
+//
+// Channels of stopped subscriptions, or of other agents, are ignored.
+func (a *Agent) Unsubscribe(messages <-chan Message) {
+	a.brokerMu.Lock()
+	in := a.inbox
+	a.brokerMu.Unlock()
+
+	if in == nil {
+		return
+	}
+
+	in.mu.Lock()
+	var found *subscription
+	for _, sub := range in.subs {
+		if (<-chan Message)(sub.messages) == messages {
+			found = sub
+			break
+		}
+	}
+	in.mu.Unlock()
+
+	if found != nil {
+		in.unsubscribe(found)
+	}
+}
+
+func (b *Broker) subscribe(subscription unsafe.Pointer, topic string) (uint64, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.handle == nil {
+		return 0, ErrNilBroker
+	}
+
+	cTopic := C.CString(topic)
+	defer C.free(unsafe.Pointer(cTopic))
+
+	id := C.thymos_subscription_add(subscription, b.handle, cTopic, &cErr)
+	if id == 0 {
+		return 0, takeError(&cErr)
+	}
+	return uint64(id), nil
+}
+
+// unsubscribe stops sub and closes its channel, unless the inbox has already
+// been stopped
+func (in *inbox) unsubscribe(sub *subscription) {
+	in.mu.Lock()
+	if in.subs[sub.id] != sub {
+		in.mu.Unlock()
+		return
+	}
+	delete(in.subs, sub.id)
+	C.thymos_subscription_remove(in.handle, C.uint64_t(sub.id), nil)
+	in.mu.Unlock()
+
+	sub.close()
+}
+
+// close closes the subscription's channel, first making a delivery to it
+// give up
+func (s *subscription) close() {
+	close(s.done)
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	close(s.messages)
+	s.closed = true
+}
+
+// stopSubscriptions stops every subscription, closing their channels
+func (a *Agent) stopSubscriptions() {
+	a.brokerMu.Lock()
+	in := a.inbox
+	a.inbox = nil
+	a.brokerMu.Unlock()
+
+	if in == nil {
+		return
+	}
+
+	close(in.stop)
+	<-in.done
+
+	in.mu.Lock()
+	subs := in.subs
+	in.subs = nil
+	C.thymos_subscription_free(in.handle)
+	in.handle = nil
+	in.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+// run delivers the inbox's messages to their subscriptions until it is
+// stopped. Only run reads messages, and the handle is freed after it returns.
+func (in *inbox) run() {
+	defer close(in.done)
+
+	for {
+		select {
+		case <-in.stop:
+			return
+		default:
+		}
+
+		var cJSON *C.char
+		switch C.thymos_subscription_next(in.handle, C.uint64_t(subscriptionPoll.Milliseconds()), &cJSON, nil) {
+		case 0:
+			continue
+		case 1:
+		default:
+			return
+		}
+
+		var raw struct {
+			Subscription uint64 `json:"subscription"`
+			Sender       string `json:"sender"`
+			Topic        string `json:"topic"`
+			Payload      string `json:"payload"`
+			SentAt       int64  `json:"sent_at"`
+		}
+		err := json.Unmarshal([]byte(C.GoString(cJSON)), &raw)
+		C.thymos_free_string(cJSON)
+		if err != nil {
+			continue
+		}
+
+		// Messages still queued for a canceled subscription are dropped
+		in.mu.Lock()
+		sub := in.subs[raw.Subscription]
+		in.mu.Unlock()
+		if sub == nil {
+			continue
+		}
+
+		msg := Message{
+			SenderID: raw.Sender,
+			Topic:    raw.Topic,
+			Payload:  raw.Payload,
+			SentAt:   time.UnixMilli(raw.SentAt),
+		}
+		sub.sendMu.Lock()
+		if !sub.closed {
+			select {
+			case sub.messages <- msg:
+			case <-sub.done:
+			case <-in.stop:
+			}
+		}
+		sub.sendMu.Unlock()
+	}
+}
+
+// ============================================================================
+// Export and Import
+// ============================================================================
+
+// exportFormat and exportVersion identify the Export format in its header line
+const (
+	exportFormat  = "thymos-memories"
+	exportVersion = 1
+)
+
+// exportPageSize is the number of memories Export reads per native call, and
+// importBatchSize the number ImportMemories stores per native call
+const (
+	exportPageSize  = 256
+	importBatchSize = 256
+)
+
+// exportHeader is the first line of an Export dump
+type exportHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+// exportRecord is one memory in an Export dump
+//
+// ID and AgentID are written for downstream consumers and ignored on import.
+type exportRecord struct {
+	ID           string                 `json:"id,omitempty"`
+	AgentID      string                 `json:"agent_id,omitempty"`
+	Content      string                 `json:"content"`
+	Type         MemoryType             `json:"memory_type"`
+	Properties   map[string]interface{} `json:"properties"`
+	CreatedAt    string                 `json:"created_at"`
+	LastAccessed *string                `json:"last_accessed"`
+	Tags         []string               `json:"tags,omitempty"`
+}
+
+// Export writes every memory to w as NDJSON for ImportMemories
+//
+// The first line is a header, {"format":"thymos-memories","version":1};
+// each following line holds one memory's id, agent_id, content, memory_type,
+// properties, created_at, last_accessed and tags, oldest first. The id is the
+// memory ID namespaced by the agent ID as "<agent_id>/<memory_id>", so dumps
+// from several agents can be merged without collisions; agent_id attributes
+// the memory without parsing it. ImportMemories ignores both and assigns new
+// IDs. Embeddings are not exported.
+//
+// The set of memories is fixed by one store snapshot when Export starts:
+// memories stored later are not included and memories forgotten meanwhile
+// are skipped. Memories are written in no particular order, and each native
+// call reads only the next page of them.
+func (a *Agent) Export(w io.Writer) error {
+	agentID, err := a.ID()
+	if err != nil {
+		return err
+	}
+
+	cursor, err := a.openMemoryCursor()
+	if err != nil {
+		return err
+	}
+	defer C.thymos_memory_cursor_free(cursor)
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(exportHeader{Format: exportFormat, Version: exportVersion}); err != nil {
+		return err
+	}
+
+	for {
+		page, err := a.nextMemories(cursor, exportPageSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			return nil
+		}
+		for _, m := range page {
+			record := exportRecord{
+				ID:           agentID + "/" + m.ID,
+				AgentID:      agentID,
+				Content:      m.Content,
+				Type:         m.Type,
+				Properties:   m.Properties,
+				CreatedAt:    m.CreatedAt,
+				LastAccessed: m.LastAccessed,
+				Tags:         m.Tags,
+			}
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// openMemoryCursor fixes the set of memories Export reads
+func (a *Agent) openMemoryCursor() (unsafe.Pointer, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	cursor := C.thymos_agent_memory_cursor(a.handle, &cErr)
+	if cursor == nil {
+		return nil, takeError(&cErr)
+	}
+	return cursor, nil
+}
+
+// nextMemories reads the next page of up to n memories from a cursor; an
+// empty page means the cursor is exhausted
+func (a *Agent) nextMemories(cursor unsafe.Pointer, n int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return nil, ErrNilHandle
+	}
+
+	resultsPtr := C.thymos_agent_memory_cursor_next(a.handle, cursor, C.size_t(n), &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// ImportMemories stores the memories of an Export dump read from r
+//
+// Memories get new IDs but keep their type, properties, tags and timestamps,
+// and are re-embedded with the agent's embedding provider so they are
+// searchable as before. Memories of MemoryTypeOther import as
+// MemoryTypeEpisodic. The dump is imported in batches; on error, the count of
+// memories imported so far is returned with the error. A record whose content
+// is longer than MaxContentLength stops the import with an error wrapping
+// ErrContentTooLarge that names the record's position in the dump, counting
+// from 1.
+func (a *Agent) ImportMemories(r io.Reader) (int, error) {
+	if a.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	dec := json.NewDecoder(r)
 
-	handle := C.thymos_agent_new_with_config(cAgentID, config.handle)
-	if handle == nil {
-		return nil, getLastError()
+	var header exportHeader
+	if err := dec.Decode(&header); err != nil {
+		return 0, fmt.Errorf("thymos: invalid export header: %w", err)
+	}
+	if header.Format != exportFormat {
+		return 0, fmt.Errorf("thymos: not a memory export (format %q)", header.Format)
+	}
+	if header.Version != exportVersion {
+		return 0, fmt.Errorf("thymos: unsupported export version %d", header.Version)
 	}
 
-	agent := &Agent{handle: handle}
-	runtime.SetFinalizer(agent, (*Agent).Close)
-	return agent, nil
+	imported := 0
+	decoded := 0
+	batch := make([]exportRecord, 0, importBatchSize)
+	for {
+		var record exportRecord
+		err := dec.Decode(&record)
+		if err != nil && err != io.EOF {
+			return imported, fmt.Errorf("thymos: invalid export record: %w", err)
+		}
+		if err == nil {
+			decoded++
+			if contentErr := thymosapi.CheckContent(record.Content); contentErr != nil {
+				// Store the records before it, so the count covers the whole
+				// dump up to the rejected record
+				if len(batch) > 0 {
+					n, importErr := a.importRecords(batch)
+					imported += n
+					if importErr != nil {
+						return imported, importErr
+					}
+				}
+				return imported, fmt.Errorf("thymos: export record %d: %w", decoded, contentErr)
+			}
+			record.ID, record.AgentID = "", ""
+			batch = append(batch, record)
+		}
+
+		if len(batch) > 0 && (len(batch) == importBatchSize || err == io.EOF) {
+			n, importErr := a.importRecords(batch)
+			imported += n
+			if importErr != nil {
+				return imported, importErr
+			}
+			batch = batch[:0]
+		}
+		if err == io.EOF {
+			return imported, nil
+		}
+	}
 }
 
-// Close releases the agent resources
-//
-// After Close is called, all methods will return ErrNilHandle.
-// Close is idempotent and safe to call multiple times.
-func (a *Agent) Close() {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+// importRecords stores one batch of export records
+func (a *Agent) importRecords(records []exportRecord) (int, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
 
-	if a.handle != nil {
-		C.thymos_free_agent(a.handle)
-		a.handle = nil
+	data, err := json.Marshal(records)
+	if err != nil {
+		return 0, err
+	}
+
+	defer a.trackWrite()()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return 0, ErrNilHandle
+	}
+
+	cJSON := C.CString(string(data))
+	defer C.free(unsafe.Pointer(cJSON))
+
+	// Memories stored before a failure are counted too
+	var cImported C.size_t
+	result := C.thymos_agent_import_memories(a.handle, cJSON, &cImported, &cErr)
+	if result != 0 {
+		return int(cImported), takeError(&cErr)
 	}
+	return int(cImported), nil
 }
 
-// IsClosed returns true if the agent has been closed
-func (a *Agent) IsClosed() bool {
+// ============================================================================
+// Conversations
+// ============================================================================
+
+// ConversationID identifies a conversation started with StartConversation
+type ConversationID string
+
+// StartConversation starts a new conversation and returns its ID
+//
+// A conversation groups the memories stored with RememberInConversation into
+// an ordered thread. IDs are opaque and stay valid across restarts of an
+// agent with the same data directory.
+func (a *Agent) StartConversation() (ConversationID, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	return a.handle == nil
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	cID := C.thymos_agent_start_conversation(a.handle, &cErr)
+	if cID == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cID)
+
+	return ConversationID(C.GoString(cID)), nil
 }
 
-// ID returns the agent's unique identifier
-func (a *Agent) ID() (string, error) {
+// RememberInConversation stores a conversation memory as the next turn of a
+// conversation and returns its ID
+//
+// The memory is stored like RememberConversation, with the "conversation_id"
+// and "conversation_turn" properties set. Turns are numbered from 1 in the
+// order they are stored.
+func (a *Agent) RememberInConversation(convID ConversationID, content string) (string, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -361,65 +6186,156 @@ func (a *Agent) ID() (string, error) {
 		return "", ErrNilHandle
 	}
 
-	cID := C.thymos_agent_id(a.handle)
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	cConvID := C.CString(string(convID))
+	defer C.free(unsafe.Pointer(cConvID))
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+
+	cID := C.thymos_agent_remember_in_conversation(a.handle, cConvID, cContent, &cErr)
 	if cID == nil {
-		return "", getLastError()
+		return "", takeError(&cErr)
 	}
 	defer C.thymos_free_string(cID)
 
 	return C.GoString(cID), nil
 }
 
-// Description returns the agent's description
-func (a *Agent) Description() (string, error) {
+// ConversationHistory returns the memories of a conversation in turn order
+//
+// Forgotten turns are left out. An unknown conversation returns an empty
+// slice. Reading the history does not count as an access.
+func (a *Agent) ConversationHistory(convID ConversationID) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	if a.handle == nil {
-		return "", ErrNilHandle
+		return nil, ErrNilHandle
 	}
 
-	cDesc := C.thymos_agent_description(a.handle)
-	if cDesc == nil {
-		return "", getLastError()
+	cConvID := C.CString(string(convID))
+	defer C.free(unsafe.Pointer(cConvID))
+
+	resultsPtr := C.thymos_agent_conversation_history(a.handle, cConvID, &cErr)
+	if resultsPtr == nil {
+		return nil, takeError(&cErr)
 	}
-	defer C.thymos_free_string(cDesc)
+	defer C.thymos_free_search_results(resultsPtr)
 
-	return C.GoString(cDesc), nil
+	return convertSearchResults(resultsPtr), nil
 }
 
-// IsHybrid returns true if the agent is using hybrid memory mode
-func (a *Agent) IsHybrid() (bool, error) {
+// ============================================================================
+// Agent Stats
+// ============================================================================
+
+// AgentStats holds storage and lifecycle metrics for an agent
+type AgentStats struct {
+	// TotalMemories is the number of stored memories
+	TotalMemories int
+	// CountsByType is the number of memories of each type; types with no
+	// memories are absent
+	CountsByType map[MemoryType]int
+	// AverageStrength is the mean current strength, or 0 with no memories
+	AverageStrength float64
+	// Oldest and Newest are the earliest and latest creation times, or zero
+	// with no memories
+	Oldest time.Time
+	Newest time.Time
+	// DiskBytes is the size of the agent's data directory (0 in server mode)
+	DiskBytes int64
+}
+
+// agentStatsJSON is the stats object returned by the native library
+type agentStatsJSON struct {
+	TotalMemories   int                `json:"total_memories"`
+	CountsByType    map[MemoryType]int `json:"counts_by_type"`
+	AverageStrength float64            `json:"average_strength"`
+	Oldest          *time.Time         `json:"oldest"`
+	Newest          *time.Time         `json:"newest"`
+	DiskBytes       int64              `json:"disk_bytes"`
+}
+
+// Stats returns storage and lifecycle metrics for the agent
+//
+// Every memory is loaded to compute the metrics, so avoid calling it on a hot
+// path. Computing stats does not count as an access.
+func (a *Agent) Stats() (*AgentStats, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	if a.handle == nil {
-		return false, ErrNilHandle
+		return nil, ErrNilHandle
 	}
 
-	result := C.thymos_agent_is_hybrid(a.handle)
-	if result < 0 {
-		return false, getLastError()
+	cJSON := C.thymos_agent_stats(a.handle, &cErr)
+	if cJSON == nil {
+		return nil, takeError(&cErr)
 	}
-	return result == 1, nil
+	defer C.thymos_free_string(cJSON)
+
+	var raw agentStatsJSON
+	if err := json.Unmarshal([]byte(C.GoString(cJSON)), &raw); err != nil {
+		return nil, fmt.Errorf("thymos: invalid stats JSON: %w", err)
+	}
+
+	stats := &AgentStats{
+		TotalMemories:   raw.TotalMemories,
+		CountsByType:    raw.CountsByType,
+		AverageStrength: raw.AverageStrength,
+		DiskBytes:       raw.DiskBytes,
+	}
+	if stats.CountsByType == nil {
+		stats.CountsByType = map[MemoryType]int{}
+	}
+	if raw.Oldest != nil {
+		stats.Oldest = *raw.Oldest
+	}
+	if raw.Newest != nil {
+		stats.Newest = *raw.Newest
+	}
+	return stats, nil
 }
 
 // ============================================================================
-// Status and State
+// Memory Expiry
 // ============================================================================
 
-// Status represents the agent's operational status
-type Status string
+// ttlMillis converts a TTL to whole milliseconds, rounding up so a short TTL
+// never becomes 0
+func ttlMillis(ttl time.Duration) C.uint64_t {
+	return C.uint64_t((ttl + time.Millisecond - 1) / time.Millisecond)
+}
 
-const (
-	StatusActive    Status = "Active"
-	StatusListening Status = "Listening"
-	StatusDormant   Status = "Dormant"
-	StatusArchived  Status = "Archived"
-)
+// RememberWithTTL stores a general memory that is deleted once ttl has passed
+//
+// Unlike forgetting, expiry ignores the memory's strength: shortly after ttl
+// the memory is deleted outright, with no grace period, and once expired it
+// never appears in search results or GetMemory. The expiry time is stored in
+// the "expires_at" property and survives restarts. ttl must be positive.
+func (a *Agent) RememberWithTTL(content string, ttl time.Duration) (string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
+	if ttl <= 0 {
+		return "", errors.New("thymos: ttl must be positive")
+	}
+
+	defer a.trackWrite()()
 
-// Status returns the current agent status
-func (a *Agent) Status() (Status, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -427,19 +6343,53 @@ func (a *Agent) Status() (Status, error) {
 		return "", ErrNilHandle
 	}
 
-	cStatus := C.thymos_agent_status(a.handle)
-	if cStatus == nil {
-		return "", getLastError()
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
 	}
-	defer C.thymos_free_string(cStatus)
 
-	return Status(C.GoString(cStatus)), nil
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+
+	cID := C.thymos_agent_remember_with_ttl(a.handle, cContent, ttlMillis(ttl), &cErr)
+	if cID == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
 }
 
-// SetStatus sets the agent status
+// RememberWithTTLContext is RememberWithTTL with a deadline, like
+// RememberContext
+func (a *Agent) RememberWithTTLContext(ctx context.Context, content string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", errors.New("thymos: ttl must be positive")
+	}
+
+	return a.rememberCancelable(ctx, content, func(cContent *C.char, token unsafe.Pointer, cErr *C.ThymosError) *C.char {
+		return C.thymos_agent_remember_with_ttl_cancelable(a.handle, cContent, ttlMillis(ttl), token, cErr)
+	})
+}
+
+// SetMemoryTTL makes an existing memory expire ttl from now
 //
-// Valid statuses: StatusActive, StatusListening, StatusDormant, StatusArchived
-func (a *Agent) SetStatus(status Status) error {
+// The memory is then deleted as described for RememberWithTTL, replacing
+// any TTL it already had. A ttl of 0 clears the TTL. Returns an error
+// wrapping ErrMemoryNotFound if no memory has the ID.
+func (a *Agent) SetMemoryTTL(memoryID string, ttl time.Duration) error {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
+	if ttl < 0 {
+		return errors.New("thymos: ttl must not be negative")
+	}
+
+	defer a.trackWrite()()
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -447,26 +6397,100 @@ func (a *Agent) SetStatus(status Status) error {
 		return ErrNilHandle
 	}
 
-	cStatus := C.CString(string(status))
-	defer C.free(unsafe.Pointer(cStatus))
+	cMemoryID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cMemoryID))
 
-	result := C.thymos_agent_set_status(a.handle, cStatus)
-	if result != 0 {
-		return getLastError()
+	if C.thymos_agent_set_memory_ttl(a.handle, cMemoryID, ttlMillis(ttl), &cErr) != 0 {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotFound {
+			return fmt.Errorf("%w: %s", ErrMemoryNotFound, memoryID)
+		}
+		return err
 	}
 	return nil
 }
 
-// State holds the full agent state
-type State struct {
-	Status     Status
-	StartedAt  *string
-	LastActive string
-	Properties map[string]interface{}
+// ============================================================================
+// Tags
+// ============================================================================
+
+// RememberWithTags stores a general memory with tags
+//
+// Tags are a first-class filter for SearchMemoriesByTags, kept apart from
+// Properties and returned in Memory.Tags. They must be non-empty and are
+// matched exactly, so "project:alpha" and "Project:Alpha" differ.
+func (a *Agent) RememberWithTags(content string, tags []string) (string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+	defer a.trackWrite()()
+
+	if a.readOnly {
+		return "", ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", ErrNilHandle
+	}
+
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+
+	cTags, freeTags := newCStringArray(tags)
+	defer freeTags()
+	var tagsPtr **C.char
+	if len(cTags) > 0 {
+		tagsPtr = &cTags[0]
+	}
+
+	cID := C.thymos_agent_remember_with_tags(a.handle, cContent, tagsPtr, C.size_t(len(cTags)), &cErr)
+	if cID == nil {
+		return "", takeError(&cErr)
+	}
+	defer C.thymos_free_string(cID)
+
+	return C.GoString(cID), nil
 }
 
-// State returns the full agent state
-func (a *Agent) State() (*State, error) {
+// RememberWithTagsContext is RememberWithTags with a deadline, like
+// RememberContext
+func (a *Agent) RememberWithTagsContext(ctx context.Context, content string, tags []string) (string, error) {
+	cTags, freeTags := newCStringArray(tags)
+	defer freeTags()
+	var tagsPtr **C.char
+	if len(cTags) > 0 {
+		tagsPtr = &cTags[0]
+	}
+
+	return a.rememberCancelable(ctx, content, func(cContent *C.char, token unsafe.Pointer, cErr *C.ThymosError) *C.char {
+		return C.thymos_agent_remember_with_tags_cancelable(a.handle, cContent, tagsPtr, C.size_t(len(cTags)), token, cErr)
+	})
+}
+
+// SearchMemoriesByTags returns memories by tag, newest first
+//
+// With matchAll a memory must carry every tag in tags; otherwise any one of
+// them is enough. No tags match nothing. limit is interpreted as by
+// SearchMemories. Results count as accesses.
+//
+// Only the local store is searched, so in hybrid mode memories tagged in the
+// shared backend are not found, and in server mode ErrNoDataDir is returned.
+// An index of tags is loaded from the store by the first call, so later
+// calls read only the matching memories.
+func (a *Agent) SearchMemoriesByTags(tags []string, matchAll bool, limit int) ([]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if len(tags) == 0 {
+		return []*Memory{}, nil
+	}
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -474,291 +6498,655 @@ func (a *Agent) State() (*State, error) {
 		return nil, ErrNilHandle
 	}
 
-	cState := C.thymos_agent_state(a.handle)
-	if cState == nil {
-		return nil, getLastError()
+	cTags, freeTags := newCStringArray(tags)
+	defer freeTags()
+
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsPtr := C.thymos_agent_search_by_tags(a.handle, &cTags[0], C.size_t(len(cTags)), C.bool(matchAll), cLimit, &cErr)
+	if resultsPtr == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeConfiguration {
+			return nil, ErrNoDataDir
+		}
+		return nil, err
+	}
+	defer C.thymos_free_search_results(resultsPtr)
+
+	return convertSearchResults(resultsPtr), nil
+}
+
+// ============================================================================
+// Summarization
+// ============================================================================
+
+// Summarize returns a consolidated summary of the memories that best match query
+//
+// Up to maxMemories top matches are summarized by the agent's LLM provider,
+// in the voice of the agent's persona (see SetPersona) if it has one; set
+// maxMemories to 0 for no limit (negative values are treated as 0).
+// Expired memories are skipped and the summarized memories count as accesses.
+// No matches gives an empty summary. Returns ErrSummarizationUnavailable if
+// the agent has no LLM provider.
+func (a *Agent) Summarize(query string, maxMemories int) (string, error) {
+	summary, _, err := a.summarize(query, maxMemories, false)
+	return summary, err
+}
+
+// SummarizeAndRemember is Summarize that also stores the summary as a fact
+//
+// The fact's properties record the query ("summary_of") and the summarized
+// memory IDs ("source_ids"). Returns the summary and the new memory's ID;
+// when nothing matches, both are empty and nothing is stored.
+func (a *Agent) SummarizeAndRemember(query string, maxMemories int) (summary, memoryID string, err error) {
+	return a.summarize(query, maxMemories, true)
+}
+
+// summarize runs a summary, storing it as a fact if store is set
+func (a *Agent) summarize(query string, maxMemories int, store bool) (string, string, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+	if store {
+		defer a.trackWrite()()
+	}
+	if store && a.readOnly {
+		return "", "", ErrReadOnly
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.handle == nil {
+		return "", "", ErrNilHandle
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cMax := C.size_t(maxMemories)
+	if maxMemories < 0 {
+		cMax = 0
+	}
+
+	cJSON := C.thymos_agent_summarize(a.handle, cQuery, cMax, C.bool(store), &cErr)
+	if cJSON == nil {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNoLLM {
+			return "", "", ErrSummarizationUnavailable
+		}
+		return "", "", err
+	}
+	defer C.thymos_free_string(cJSON)
+
+	var result struct {
+		Summary  string  `json:"summary"`
+		MemoryID *string `json:"memory_id"`
+	}
+	if err := json.Unmarshal([]byte(C.GoString(cJSON)), &result); err != nil {
+		return "", "", fmt.Errorf("thymos: invalid summary JSON: %w", err)
+	}
+	if result.MemoryID == nil {
+		return result.Summary, "", nil
+	}
+	return result.Summary, *result.MemoryID, nil
+}
+
+// ============================================================================
+// Status Change Callbacks
+// ============================================================================
+
+// statusPollInterval is how often the status is polled while callbacks are
+// registered, to catch changes not made through SetStatus
+const statusPollInterval = time.Second
+
+// OnStatusChange registers fn to run when the agent's status changes
+//
+// fn runs after SetStatus changes the status, before SetStatus returns, and
+// for changes made any other way (such as by the native agent itself) within
+// about a second, found by polling while any callback is registered. Callbacks
+// run one at a time, in registration order, and only for actual transitions:
+// setting the current status again does not call them. Several changes between
+// polls are reported as a single transition. Transitions are reported in the
+// order they happen: one found while callbacks are running, including one
+// made by SetStatus from within fn, is reported by the goroutine running them
+// once they return, after the SetStatus that made it has returned.
+//
+// Call the returned cancel function to unregister fn; it is safe to call more
+// than once, and from within fn. Callbacks stop when the agent is closed.
+// Polling does not keep the agent reachable, so an agent dropped without
+// Close is still finalized.
+func (a *Agent) OnStatusChange(fn func(old, new Status)) (cancel func()) {
+	if fn == nil || a.IsClosed() {
+		return func() {}
+	}
+
+	// Record the status before registering so the first change has a baseline
+	a.checkStatus()
+
+	a.statusMu.Lock()
+	defer a.statusMu.Unlock()
+
+	if a.statusFns == nil {
+		a.statusFns = make(map[uint64]func(old, new Status))
+	}
+	a.statusNext++
+	key := a.statusNext
+	a.statusFns[key] = fn
+	if a.statusStop == nil {
+		a.statusStop = make(chan struct{})
+		go pollStatus(weak.Make(a), a.statusStop)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.statusMu.Lock()
+			defer a.statusMu.Unlock()
+			delete(a.statusFns, key)
+			if len(a.statusFns) == 0 {
+				a.stopStatusPollLocked()
+			}
+		})
+	}
+}
+
+// watchingStatus reports whether any status change callback is registered
+func (a *Agent) watchingStatus() bool {
+	a.statusMu.Lock()
+	defer a.statusMu.Unlock()
+	return len(a.statusFns) > 0
+}
+
+// stopStatusPoll stops status polling and unregisters every callback
+func (a *Agent) stopStatusPoll() {
+	a.statusMu.Lock()
+	defer a.statusMu.Unlock()
+	a.statusFns = nil
+	a.stopStatusPollLocked()
+}
+
+// stopStatusPollLocked signals the poller to stop without waiting for it, so
+// it can be called from a callback running on the poller
+func (a *Agent) stopStatusPollLocked() {
+	if a.statusStop == nil {
+		return
+	}
+	close(a.statusStop)
+	a.statusStop = nil
+}
+
+// pollStatus checks the agent's status every statusPollInterval until stop
+// is closed or the agent is collected. Like runMaintenance, it holds the
+// agent only while checking.
+func pollStatus(agent weak.Pointer[Agent], stop chan struct{}) {
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			a := agent.Value()
+			if a == nil {
+				return
+			}
+			a.checkStatus()
+		}
 	}
-	defer C.thymos_free_agent_state(cState)
+}
 
-	state := (*C.ThymosAgentState)(cState)
+// statusChange is a status transition waiting to be reported
+type statusChange struct {
+	old, new Status
+}
 
-	result := &State{
-		Status:     Status(C.GoString(state.status)),
-		LastActive: C.GoString(state.last_active),
-		Properties: make(map[string]interface{}),
+// checkStatus reads the status and, if it changed since the last check,
+// queues the transition for the registered callbacks
+//
+// The first caller to queue a transition while no callbacks are running
+// runs them, outside notifyMu, for every transition queued until the queue
+// is empty. Callbacks can therefore call SetStatus, whose transition is
+// queued and reported after theirs.
+func (a *Agent) checkStatus() {
+	a.notifyMu.Lock()
+	status, err := a.Status()
+	old := a.statusLast
+	if err != nil || old == status {
+		a.notifyMu.Unlock()
+		return
 	}
-
-	if state.started_at != nil {
-		startedAt := C.GoString(state.started_at)
-		result.StartedAt = &startedAt
+	a.statusLast = status
+	if old == "" {
+		a.notifyMu.Unlock()
+		return
 	}
+	a.statusQueue = append(a.statusQueue, statusChange{old: old, new: status})
+	if a.statusNotifying {
+		a.notifyMu.Unlock()
+		return
+	}
+	a.statusNotifying = true
+	a.notifyMu.Unlock()
+
+	// If a callback panics, let the next transition start reporting again
+	finished := false
+	defer func() {
+		if !finished {
+			a.notifyMu.Lock()
+			a.statusNotifying = false
+			a.notifyMu.Unlock()
+		}
+	}()
+
+	for {
+		a.notifyMu.Lock()
+		if len(a.statusQueue) == 0 {
+			a.statusNotifying = false
+			a.notifyMu.Unlock()
+			finished = true
+			return
+		}
+		change := a.statusQueue[0]
+		a.statusQueue = a.statusQueue[1:]
+		a.notifyMu.Unlock()
 
-	if state.properties_json != nil {
-		propsJSON := C.GoString(state.properties_json)
-		if err := json.Unmarshal([]byte(propsJSON), &result.Properties); err != nil {
-			result.Properties = make(map[string]interface{})
+		for _, fn := range a.statusCallbacks() {
+			fn(change.old, change.new)
 		}
 	}
+}
 
-	return result, nil
+// statusCallbacks returns the registered status change callbacks in
+// registration order
+func (a *Agent) statusCallbacks() []func(old, new Status) {
+	a.statusMu.Lock()
+	keys := make([]uint64, 0, len(a.statusFns))
+	for key := range a.statusFns {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	fns := make([]func(old, new Status), len(keys))
+	for i, key := range keys {
+		fns[i] = a.statusFns[key]
+	}
+	a.statusMu.Unlock()
+	return fns
 }
 
 // ============================================================================
-// Memory
+// Federated Search
 // ============================================================================
 
-// Memory represents a stored memory
-type Memory struct {
-	ID           string
-	Content      string
-	Properties   map[string]interface{}
-	CreatedAt    string
-	LastAccessed *string
+// FederatedResult is a SearchAcrossAgents match and the agent it came from
+type FederatedResult struct {
+	AgentID string
+	Memory  *Memory
 }
 
-func convertCMemory(cMem *C.ThymosMemory) *Memory {
-	mem := &Memory{
-		ID:         C.GoString(cMem.id),
-		Content:    C.GoString(cMem.content),
-		CreatedAt:  C.GoString(cMem.created_at),
-		Properties: make(map[string]interface{}),
+// SearchAcrossAgents searches several agents at once and merges the results
+//
+// Each agent is searched concurrently with SearchMemories, then the matches
+// are merged by Score, highest first (ties keep the order of agents), and cut
+// to limit, which works as in SearchMemories. Scores measure relevance to the
+// query independently of the other results, so matches from different agents
+// are ranked against each other. A memory found through several agents, as
+// shared memories are in hybrid mode, is kept once, for the agent that scored
+// it highest. If any search fails, the first failure is returned.
+func SearchAcrossAgents(agents []*Agent, query string, limit int) ([]FederatedResult, error) {
+	keep, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, agent := range agents {
+		if agent == nil {
+			return nil, ErrNilHandle
+		}
 	}
 
-	if cMem.last_accessed != nil {
-		lastAccessed := C.GoString(cMem.last_accessed)
-		mem.LastAccessed = &lastAccessed
+	perAgent := make([][]*Memory, len(agents))
+	errs := make([]error, len(agents))
+	var wg sync.WaitGroup
+	for i, agent := range agents {
+		wg.Go(func() {
+			perAgent[i], errs[i] = agent.SearchMemories(query, limit)
+		})
 	}
+	wg.Wait()
 
-	if cMem.properties_json != nil {
-		propsJSON := C.GoString(cMem.properties_json)
-		if err := json.Unmarshal([]byte(propsJSON), &mem.Properties); err != nil {
-			mem.Properties = make(map[string]interface{})
+	var results []FederatedResult
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("thymos: search agent %s: %w", agents[i].id, err)
+		}
+		for _, m := range perAgent[i] {
+			results = append(results, FederatedResult{AgentID: agents[i].id, Memory: m})
 		}
 	}
 
-	return mem
+	slices.SortStableFunc(results, func(x, y FederatedResult) int {
+		return cmp.Compare(y.Memory.Score, x.Memory.Score)
+	})
+	seen := make(map[string]bool, len(results))
+	results = slices.DeleteFunc(results, func(r FederatedResult) bool {
+		if seen[r.Memory.ID] {
+			return true
+		}
+		seen[r.Memory.ID] = true
+		return false
+	})
+
+	if keep > 0 && len(results) > int(keep) {
+		results = results[:keep]
+	}
+	return results, nil
 }
 
-// Remember stores a memory and returns its ID
-func (a *Agent) Remember(content string) (string, error) {
+// ============================================================================
+// Embeddings
+// ============================================================================
+
+// GetEmbedding returns the embedding stored with a memory
+//
+// Its length is the embedding model's dimension (see
+// Config.SetEmbeddingDimensions), the same for every memory of an agent. The
+// slice is a copy owned by the caller. Returns ErrNoEmbedding if the memory
+// was stored without one, and an error wrapping ErrMemoryNotFound if no
+// memory has the ID. Does not count as an access.
+func (a *Agent) GetEmbedding(memoryID string) ([]float32, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	if a.handle == nil {
-		return "", ErrNilHandle
+		return nil, ErrNilHandle
 	}
 
-	cContent := C.CString(content)
-	defer C.free(unsafe.Pointer(cContent))
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
 
-	cID := C.thymos_agent_remember(a.handle, cContent)
-	if cID == nil {
-		return "", getLastError()
+	var cLen C.size_t
+	cData := C.thymos_agent_get_embedding(a.handle, cID, &cLen, &cErr)
+	if cData == nil {
+		err := takeError(&cErr)
+		if err == nil {
+			return nil, ErrNoEmbedding
+		}
+		if errorCode(err) == ErrCodeNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrMemoryNotFound, memoryID)
+		}
+		return nil, err
 	}
-	defer C.thymos_free_string(cID)
+	defer C.thymos_free_floats(cData, cLen)
 
-	return C.GoString(cID), nil
+	return copyFloats(cData, cLen), nil
 }
 
-// RememberFact stores a fact memory (durable, context-independent knowledge)
+// EmbedText embeds text with the agent's embedding provider without storing it
 //
-// Facts are intended for knowledge like "Paris is the capital of France".
-func (a *Agent) RememberFact(content string) (string, error) {
+// The vector matches what a memory with the same content is stored with, so
+// it can query an external index built from GetEmbedding. The slice is a copy
+// owned by the caller. Fails with an ErrCodeConfiguration error if the agent
+// has no embedding provider.
+func (a *Agent) EmbedText(text string) ([]float32, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	if a.handle == nil {
-		return "", ErrNilHandle
+		return nil, ErrNilHandle
 	}
 
-	cContent := C.CString(content)
-	defer C.free(unsafe.Pointer(cContent))
+	cText := C.CString(text)
+	defer C.free(unsafe.Pointer(cText))
 
-	cID := C.thymos_agent_remember_fact(a.handle, cContent)
-	if cID == nil {
-		return "", getLastError()
+	var cLen C.size_t
+	cData := C.thymos_agent_embed_text(a.handle, cText, &cLen, &cErr)
+	if cData == nil {
+		return nil, takeError(&cErr)
 	}
-	defer C.thymos_free_string(cID)
+	defer C.thymos_free_floats(cData, cLen)
 
-	return C.GoString(cID), nil
+	return copyFloats(cData, cLen), nil
 }
 
-// RememberConversation stores a conversation memory (dialogue context)
+// copyFloats copies a native float buffer into a Go slice
+func copyFloats(data *C.float, n C.size_t) []float32 {
+	if n == 0 {
+		return []float32{}
+	}
+	return slices.Clone(unsafe.Slice((*float32)(unsafe.Pointer(data)), int(n)))
+}
+
+// ============================================================================
+// Clearing
+// ============================================================================
+
+// Clear deletes every memory
 //
-// Conversation memories are intended for dialogue history and ephemeral context.
-func (a *Agent) RememberConversation(content string) (string, error) {
+// The agent stays open and keeps its configuration, status, description,
+// persona and saved queries, so it is a lighter "start fresh" than deleting
+// the data directory. Cleared memories cannot be restored with RestoreMemory.
+// If a deletion fails, the memories already deleted are stored again with
+// their IDs and the error is returned. That rollback is best-effort: should
+// storing some of them again fail too, they are lost and the error says how
+// many.
+func (a *Agent) Clear() error {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	if a.handle == nil {
-		return "", ErrNilHandle
+		return ErrNilHandle
 	}
 
-	cContent := C.CString(content)
-	defer C.free(unsafe.Pointer(cContent))
-
-	cID := C.thymos_agent_remember_conversation(a.handle, cContent)
-	if cID == nil {
-		return "", getLastError()
+	if C.thymos_agent_clear(a.handle, &cErr) < 0 {
+		return takeError(&cErr)
 	}
-	defer C.thymos_free_string(cID)
-
-	return C.GoString(cID), nil
+	return nil
 }
 
-// RememberPrivate stores a memory in the private backend (hybrid mode only)
-//
-// Returns ErrNotHybridMode if the agent is not in hybrid mode.
-func (a *Agent) RememberPrivate(content string) (string, error) {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+// ============================================================================
+// Logging
+// ============================================================================
 
-	if a.handle == nil {
-		return "", ErrNilHandle
-	}
+// logger receives log records while set by SetLogger
+var logger atomic.Pointer[func(level, msg string)]
 
-	cContent := C.CString(content)
-	defer C.free(unsafe.Pointer(cContent))
+// nativeLogLevels names the native library's log levels by number
+var nativeLogLevels = [...]string{"error", "warn", "info", "debug", "trace"}
 
-	cID := C.thymos_agent_remember_private(a.handle, cContent)
-	if cID == nil {
-		err := getLastError()
-		if err != nil && err.Error() == "remember_private only available in hybrid mode" {
-			return "", ErrNotHybridMode
-		}
-		return "", err
+// SetLogger sends log records about calls into the native library, and the
+// library's own logs, to fn
+//
+// Each call that can report a native error is logged at level "debug" on
+// entry and on exit, with its duration and error code (0 when it succeeded).
+// Native records keep their level: "error", "warn", "info", "debug" or
+// "trace". By default they include thymos debug records and other crates'
+// warnings; set the THYMOS_LOG environment variable to a tracing EnvFilter
+// directive, such as "info" or "locai=debug", to change that.
+//
+// fn may be called concurrently, from any goroutine or native thread, and
+// must not call back into this package. Pass nil to stop logging. If native
+// records cannot be forwarded, because the process already installed a global
+// tracing subscriber, fn receives a "warn" record saying so and still gets
+// the call logs.
+func SetLogger(fn func(level, msg string)) {
+	if fn == nil {
+		logger.Store(nil)
+		C.thymos_set_log_callback(nil, nil)
+		return
 	}
-	defer C.thymos_free_string(cID)
+	logger.Store(&fn)
 
-	return C.GoString(cID), nil
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+	if C.thymos_set_log_callback((C.thymos_log_fn)(unsafe.Pointer(C.thymosGoLog)), &cErr) != 0 {
+		fn("warn", takeError(&cErr).Error())
+	}
 }
 
-// RememberShared stores a memory in the shared backend (hybrid mode only)
+// thymosGoLog receives native log records for SetLogger
 //
-// Returns ErrNotHybridMode if the agent is not in hybrid mode.
-func (a *Agent) RememberShared(content string) (string, error) {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
-	if a.handle == nil {
-		return "", ErrNilHandle
+//export thymosGoLog
+func thymosGoLog(level C.int, message *C.char) {
+	log := logger.Load()
+	if log == nil {
+		return
 	}
-
-	cContent := C.CString(content)
-	defer C.free(unsafe.Pointer(cContent))
-
-	cID := C.thymos_agent_remember_shared(a.handle, cContent)
-	if cID == nil {
-		err := getLastError()
-		if err != nil && err.Error() == "remember_shared only available in hybrid mode" {
-			return "", ErrNotHybridMode
-		}
-		return "", err
+	name := "debug"
+	if int(level) >= 0 && int(level) < len(nativeLogLevels) {
+		name = nativeLogLevels[level]
 	}
-	defer C.thymos_free_string(cID)
-
-	return C.GoString(cID), nil
+	(*log)(name, C.GoString(message))
 }
 
 // ============================================================================
-// Memory Search
+// Scope Changes
 // ============================================================================
 
-// SearchMemories searches for memories matching the query
+// PromoteToShared moves a private memory to the shared backend (hybrid mode
+// only), keeping its ID
 //
-// Set limit to 0 for no limit.
-func (a *Agent) SearchMemories(query string, limit int) ([]*Memory, error) {
+// Tags, properties and embedding move with the memory, so a TTL set on it
+// still applies. A memory that is already shared is left alone. Returns
+// ErrNotHybridMode if the agent is not in hybrid mode, or an error wrapping
+// ErrMemoryNotFound if neither backend has the memory.
+func (a *Agent) PromoteToShared(memoryID string) error {
+	return a.moveMemory(memoryID, true)
+}
+
+// DemoteToPrivate moves a shared memory to the private backend (hybrid mode
+// only), keeping its ID
+//
+// A memory that is already private is left alone. Returns ErrNotHybridMode if
+// the agent is not in hybrid mode, or an error wrapping ErrMemoryNotFound if
+// neither backend has the memory.
+func (a *Agent) DemoteToPrivate(memoryID string) error {
+	return a.moveMemory(memoryID, false)
+}
+
+// moveMemory moves a memory to the shared or private backend
+func (a *Agent) moveMemory(memoryID string, toShared bool) error {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return ErrReadOnly
+	}
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	if a.handle == nil {
-		return nil, ErrNilHandle
+		return ErrNilHandle
 	}
 
-	cQuery := C.CString(query)
-	defer C.free(unsafe.Pointer(cQuery))
+	cID := C.CString(memoryID)
+	defer C.free(unsafe.Pointer(cID))
 
-	cLimit := C.size_t(limit)
-	if limit < 0 {
-		cLimit = 0
+	var result C.int
+	if toShared {
+		result = C.thymos_agent_promote_to_shared(a.handle, cID, &cErr)
+	} else {
+		result = C.thymos_agent_demote_to_private(a.handle, cID, &cErr)
 	}
-
-	resultsPtr := C.thymos_agent_search_memories(a.handle, cQuery, cLimit)
-	if resultsPtr == nil {
-		err := getLastError()
-		if err == nil {
-			return []*Memory{}, nil
+	if result != 0 {
+		err := takeError(&cErr)
+		if errorCode(err) == ErrCodeNotHybrid {
+			return ErrNotHybridMode
 		}
-		return nil, err
+		if errorCode(err) == ErrCodeNotFound {
+			return fmt.Errorf("%w: %s", ErrMemoryNotFound, memoryID)
+		}
+		return err
 	}
-	defer C.thymos_free_search_results(resultsPtr)
 
-	results := (*C.ThymosSearchResults)(resultsPtr)
-	if results.count == 0 {
-		return []*Memory{}, nil
-	}
+	return nil
+}
 
-	memories := make([]*Memory, 0, results.count)
-	memArray := (*[1 << 28]C.ThymosMemory)(unsafe.Pointer(results.memories))[:results.count:results.count]
+// ============================================================================
+// Idempotent Writes
+// ============================================================================
 
-	for i := range memArray {
-		memories = append(memories, convertCMemory(&memArray[i]))
+// RememberIdempotent stores a general memory once per idempotencyKey and
+// returns its ID
+//
+// Repeating a key returns the ID of the memory first stored under it, and
+// content is ignored, so a write retried after a timeout is not duplicated.
+// The key is kept in the memory's "idempotency_key" property, so it also
+// de-duplicates across restarts; once the memory is forgotten the key can
+// store a new one. The first call on an agent scans its store for keys.
+func (a *Agent) RememberIdempotent(idempotencyKey, content string) (string, error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return "", ErrReadOnly
 	}
 
-	return memories, nil
-}
+	if idempotencyKey == "" {
+		return "", errors.New("thymos: idempotency key must not be empty")
+	}
 
-// SearchPrivate searches private memories (hybrid mode only)
-//
-// Returns ErrNotHybridMode if the agent is not in hybrid mode.
-func (a *Agent) SearchPrivate(query string, limit int) ([]*Memory, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	if a.handle == nil {
-		return nil, ErrNilHandle
-	}
-
-	cQuery := C.CString(query)
-	defer C.free(unsafe.Pointer(cQuery))
-
-	cLimit := C.size_t(limit)
-	if limit < 0 {
-		cLimit = 0
-	}
-
-	resultsPtr := C.thymos_agent_search_private(a.handle, cQuery, cLimit)
-	if resultsPtr == nil {
-		err := getLastError()
-		if err != nil && err.Error() == "search_private only available in hybrid mode" {
-			return nil, ErrNotHybridMode
-		}
-		if err == nil {
-			return []*Memory{}, nil
-		}
-		return nil, err
+		return "", ErrNilHandle
 	}
-	defer C.thymos_free_search_results(resultsPtr)
 
-	results := (*C.ThymosSearchResults)(resultsPtr)
-	if results.count == 0 {
-		return []*Memory{}, nil
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
 	}
 
-	memories := make([]*Memory, 0, results.count)
-	memArray := (*[1 << 28]C.ThymosMemory)(unsafe.Pointer(results.memories))[:results.count:results.count]
+	cKey := C.CString(idempotencyKey)
+	defer C.free(unsafe.Pointer(cKey))
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
 
-	for i := range memArray {
-		memories = append(memories, convertCMemory(&memArray[i]))
+	cID := C.thymos_agent_remember_idempotent(a.handle, cKey, cContent, &cErr)
+	if cID == nil {
+		return "", takeError(&cErr)
 	}
+	defer C.thymos_free_string(cID)
 
-	return memories, nil
+	return C.GoString(cID), nil
 }
 
-// SearchShared searches shared memories (hybrid mode only)
+// ============================================================================
+// Deduplication
+// ============================================================================
+
+// FindDuplicates returns clusters of near-duplicate memories
 //
-// Returns ErrNotHybridMode if the agent is not in hybrid mode.
-func (a *Agent) SearchShared(query string, limit int) ([]*Memory, error) {
+// Every pair of memories in a cluster has a similarity of at least threshold,
+// which must be in (0, 1]. Similarity is the cosine similarity of the stored
+// embeddings when both memories have one, and the overlap of their terms
+// otherwise. Clusters are ordered largest first and their memories oldest
+// first; only clusters of two or more are returned. limit caps the number of
+// clusters and is interpreted as by SearchMemories. Every pair of memories is
+// compared, so this is slow on large stores.
+func (a *Agent) FindDuplicates(threshold float64, limit int) ([][]*Memory, error) {
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -766,70 +7154,57 @@ func (a *Agent) SearchShared(query string, limit int) ([]*Memory, error) {
 		return nil, ErrNilHandle
 	}
 
-	cQuery := C.CString(query)
-	defer C.free(unsafe.Pointer(cQuery))
-
-	cLimit := C.size_t(limit)
-	if limit < 0 {
-		cLimit = 0
+	cLimit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
 	}
 
-	resultsPtr := C.thymos_agent_search_shared(a.handle, cQuery, cLimit)
+	var keysPtr unsafe.Pointer
+	resultsPtr := C.thymos_agent_find_duplicates(a.handle, C.double(threshold), cLimit, &keysPtr, &cErr)
 	if resultsPtr == nil {
-		err := getLastError()
-		if err != nil && err.Error() == "search_shared only available in hybrid mode" {
-			return nil, ErrNotHybridMode
-		}
-		if err == nil {
-			return []*Memory{}, nil
-		}
-		return nil, err
+		return nil, takeError(&cErr)
 	}
 	defer C.thymos_free_search_results(resultsPtr)
+	defer C.thymos_free_string_list(keysPtr)
 
-	results := (*C.ThymosSearchResults)(resultsPtr)
-	if results.count == 0 {
-		return []*Memory{}, nil
-	}
-
-	memories := make([]*Memory, 0, results.count)
-	memArray := (*[1 << 28]C.ThymosMemory)(unsafe.Pointer(results.memories))[:results.count:results.count]
+	memories := convertSearchResults(resultsPtr)
+	keys := convertCStringList(keysPtr)
 
-	for i := range memArray {
-		memories = append(memories, convertCMemory(&memArray[i]))
+	var clusters [][]*Memory
+	for i, mem := range memories {
+		if i == 0 || keys[i] != keys[i-1] {
+			clusters = append(clusters, nil)
+		}
+		clusters[len(clusters)-1] = append(clusters[len(clusters)-1], mem)
 	}
-
-	return memories, nil
+	return clusters, nil
 }
 
-// GetMemory retrieves a memory by its ID
+// DeduplicateMemories forgets all but the oldest memory of each cluster
+// FindDuplicates would return for threshold
 //
-// Returns nil, nil if the memory is not found.
-func (a *Agent) GetMemory(memoryID string) (*Memory, error) {
+// Removed memories stay recoverable with RestoreMemory for the grace period
+// set by MemoryConfig.SetForgetGracePeriod. Returns the number of memories
+// removed.
+func (a *Agent) DeduplicateMemories(threshold float64) (removed int, err error) {
+	defer a.trackWrite()()
+	var cErr C.ThymosError
+	defer nativeCall(&cErr)()
+
+	if a.readOnly {
+		return 0, ErrReadOnly
+	}
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	if a.handle == nil {
-		return nil, ErrNilHandle
+		return 0, ErrNilHandle
 	}
 
-	cMemoryID := C.CString(memoryID)
-	defer C.free(unsafe.Pointer(cMemoryID))
-
-	memPtr := C.thymos_agent_get_memory(a.handle, cMemoryID)
-	if memPtr == nil {
-		err := getLastError()
-		if err == nil {
-			return nil, nil // Memory not found
-		}
-		return nil, err
+	result := C.thymos_agent_deduplicate(a.handle, C.double(threshold), &cErr)
+	if result < 0 {
+		return 0, takeError(&cErr)
 	}
-	defer C.thymos_free_memory(memPtr)
-
-	return convertCMemory((*C.ThymosMemory)(memPtr)), nil
-}
-
-// String returns a string representation of the memory
-func (m *Memory) String() string {
-	return fmt.Sprintf("Memory{ID: %s, Content: %q}", m.ID, m.Content)
+	return int(result), nil
 }
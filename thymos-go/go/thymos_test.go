@@ -0,0 +1,105 @@
+package thymos
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    SemVer
+		wantErr bool
+	}{
+		{in: "1.2.3", want: SemVer{Major: 1, Minor: 2, Patch: 3}},
+		{in: "v0.10.0", want: SemVer{Minor: 10}},
+		{in: "1.2.0-beta.1", want: SemVer{Major: 1, Minor: 2, PreRelease: "beta.1"}},
+		{in: "1.2.0+build.5", want: SemVer{Major: 1, Minor: 2, Build: "build.5"}},
+		{in: "1.2.0-rc.1+sha.abc-def", want: SemVer{Major: 1, Minor: 2, PreRelease: "rc.1", Build: "sha.abc-def"}},
+		{in: "", wantErr: true},
+		{in: "1.2", wantErr: true},
+		{in: "1.2.3.4", wantErr: true},
+		{in: "1.x.3", wantErr: true},
+		{in: "1.2.3-", wantErr: true},
+		{in: "1.2.3+", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSemVer(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSemVer(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSemVer(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSemVer(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSemVerCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.3.0", "1.2.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.2.0-beta", "1.2.0", -1},
+		{"1.2.0-alpha", "1.2.0-beta", -1},
+		{"1.2.0-beta.2", "1.2.0-beta.11", -1},
+		{"1.2.0-beta.1", "1.2.0-beta", 1},
+		{"1.2.0-1", "1.2.0-alpha", -1},
+		{"1.2.0+a", "1.2.0+b", 0},
+	}
+	for _, tt := range tests {
+		a, err := ParseSemVer(tt.a)
+		if err != nil {
+			t.Fatalf("ParseSemVer(%q): %v", tt.a, err)
+		}
+		b, err := ParseSemVer(tt.b)
+		if err != nil {
+			t.Fatalf("ParseSemVer(%q): %v", tt.b, err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.Compare(a); got != -tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		size int
+		want []string
+	}{
+		{name: "empty", in: "", size: 4, want: []string{""}},
+		{name: "fits", in: "abcd", size: 4, want: []string{"abcd"}},
+		{name: "no spaces", in: "abcdefghij", size: 4, want: []string{"abcd", "efgh", "ij"}},
+		{name: "breaks after space", in: "hello world again", size: 8, want: []string{"hello ", "world ", "again"}},
+		{name: "ignores early space", in: "a bcdefghij", size: 8, want: []string{"a bcdefg", "hij"}},
+		{name: "keeps runes whole", in: "héllo", size: 2, want: []string{"h", "é", "ll", "o"}},
+		{name: "rune wider than size", in: "日本", size: 1, want: []string{"日", "本"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitChunks(tt.in, tt.size)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitChunks(%q, %d) = %q, want %q", tt.in, tt.size, got, tt.want)
+			}
+			if joined := strings.Join(got, ""); joined != tt.in {
+				t.Errorf("chunks join to %q, want %q", joined, tt.in)
+			}
+		})
+	}
+}
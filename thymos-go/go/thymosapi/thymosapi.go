@@ -0,0 +1,241 @@
+// Package thymosapi defines the memory types and agent interface shared by
+// package thymos and its test fake.
+//
+// It does not use cgo, so code written against MemoryAgent can be built and
+// tested without the native Thymos library; see package thymostest. Package
+// thymos re-exports everything here, so most programs never import it
+// directly.
+package thymosapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNilHandle is returned when an operation is attempted on a closed agent
+var ErrNilHandle = errors.New("thymos: agent handle is nil (agent may be closed)")
+
+// ErrMemoryNotFound is returned when no memory has the requested ID
+var ErrMemoryNotFound = errors.New("thymos: memory not found")
+
+// ErrInvalidLimit is returned by SearchMemories and its variants for a
+// negative limit other than Unlimited
+var ErrInvalidLimit = errors.New("thymos: invalid limit (use a positive limit, 0 for the default, or Unlimited)")
+
+// DefaultSearchLimit is the number of results SearchMemories returns for a
+// limit of 0
+const DefaultSearchLimit = 10
+
+// Unlimited is the limit that makes SearchMemories and its variants return
+// every match
+const Unlimited = -1
+
+// MaxContentLength is the longest memory content, in bytes, that the write
+// methods accept. Embedding much longer text is slow and memory-hungry, so
+// longer content is rejected before it reaches the native library.
+const MaxContentLength = 1 << 20
+
+// ErrContentTooLarge is returned for content longer than MaxContentLength
+var ErrContentTooLarge = fmt.Errorf("thymos: content too large (limit %d bytes)", MaxContentLength)
+
+// CheckContent returns an error wrapping ErrContentTooLarge if content is
+// longer than MaxContentLength
+func CheckContent(content string) error {
+	if len(content) > MaxContentLength {
+		return fmt.Errorf("%w: %d bytes", ErrContentTooLarge, len(content))
+	}
+	return nil
+}
+
+// MemoryAgent is the core memory API of an agent
+//
+// *thymos.Agent implements it on top of the native library and
+// *thymostest.Agent in memory. Every method behaves as documented on
+// thymos.Agent.
+type MemoryAgent interface {
+	// Remember stores a general memory and returns its ID
+	Remember(content string) (string, error)
+	// RememberFact stores durable knowledge and returns its ID
+	RememberFact(content string) (string, error)
+	// RememberConversation stores dialogue context and returns its ID
+	RememberConversation(content string) (string, error)
+	// RememberWithProperties stores a memory with properties and returns its ID
+	RememberWithProperties(content string, props map[string]interface{}) (string, error)
+	// GetMemory returns a memory by ID, or nil, nil if there is none
+	GetMemory(memoryID string) (*Memory, error)
+	// HasMemory reports whether a memory with the ID exists
+	HasMemory(memoryID string) (bool, error)
+	// GetMemories returns the memories with the given IDs, parallel to ids,
+	// with nil for IDs that have no memory
+	GetMemories(ids []string) ([]*Memory, error)
+	// UpdateMemory replaces a memory's content, or returns ErrMemoryNotFound
+	UpdateMemory(memoryID, newContent string) error
+	// Forget deletes a memory, or returns ErrMemoryNotFound
+	Forget(memoryID string) error
+	// SearchMemories returns up to limit memories matching the query, best
+	// first; 0 means DefaultSearchLimit
+	SearchMemories(query string, limit int) ([]*Memory, error)
+	// SearchAll returns every memory matching the query, best first
+	SearchAll(query string) ([]*Memory, error)
+	// ListMemories returns one page of memories, oldest first; limit is
+	// interpreted as by SearchMemories
+	ListMemories(offset, limit int) ([]*Memory, error)
+	// MemoryCount returns the number of stored memories
+	MemoryCount() (int, error)
+	// Close releases the agent
+	Close()
+}
+
+// Memory represents a stored memory
+type Memory struct {
+	ID           string
+	Content      string
+	Properties   map[string]interface{}
+	CreatedAt    string
+	LastAccessed *string
+	// Score is the result's relevance to the query, from 0 to 1; only set by
+	// ranked searches (SearchMemories, SearchMemoriesWithThreshold,
+	// SearchMany, SearchMemoriesExplained, SearchHybrid and
+	// SearchPrivateAndShared, while SearchReranked sets its formula's value
+	// and RelatedMemories the connection strength) and 0 otherwise
+	Score float64
+	// ScoreComponents breaks down the result's ranking score; only set by
+	// SearchMemoriesExplained
+	ScoreComponents map[string]float64
+	// Type is the kind of memory, set by the Remember variant that stored it
+	Type MemoryType
+	// Strength is the memory's current strength on the forgetting curve, from
+	// 1.0 (fresh) down to 0.0 (forgotten), as of when it was returned
+	Strength float64
+	// Tags are the labels the memory was stored with (see RememberWithTags)
+	Tags []string
+	// Scope is the backend the memory lives in: ScopePrivate or ScopeShared
+	// in hybrid mode, ScopeDefault otherwise. Check it before showing a
+	// memory outside the agent that owns it.
+	Scope Scope
+	// AccessCount is how many times the memory has been retrieved through
+	// the agent, counting the retrieval that returned it. Counts start at 0
+	// when the agent is created and are not persisted.
+	AccessCount int
+}
+
+// MemoryType is the kind of a stored memory
+type MemoryType int
+
+const (
+	// MemoryTypeEpisodic is a general memory stored by Remember
+	MemoryTypeEpisodic MemoryType = iota
+	// MemoryTypeFact is durable knowledge stored by RememberFact
+	MemoryTypeFact
+	// MemoryTypeConversation is dialogue context stored by RememberConversation
+	MemoryTypeConversation
+)
+
+// MemoryTypeOther is a memory type with no constant of its own, e.g. one
+// written to the store by another client
+const MemoryTypeOther MemoryType = -1
+
+// Scope is the backend a memory lives in
+type Scope int
+
+const (
+	// ScopeDefault is the only backend of an agent not in hybrid mode
+	ScopeDefault Scope = iota
+	// ScopePrivate is a hybrid agent's own private backend
+	ScopePrivate
+	// ScopeShared is the hybrid backend shared with other agents
+	ScopeShared
+)
+
+// memoryJSON is the JSON form of Memory
+type memoryJSON struct {
+	ID              string                 `json:"id"`
+	Content         string                 `json:"content"`
+	Properties      map[string]interface{} `json:"properties"`
+	CreatedAt       string                 `json:"created_at"`
+	LastAccessed    *string                `json:"last_accessed"`
+	Score           float64                `json:"score"`
+	ScoreComponents map[string]float64     `json:"score_components,omitempty"`
+	Type            MemoryType             `json:"memory_type"`
+	Strength        float64                `json:"strength"`
+	Tags            []string               `json:"tags,omitempty"`
+	Scope           Scope                  `json:"scope,omitempty"`
+	AccessCount     int                    `json:"access_count"`
+}
+
+// MarshalJSON implements json.Marshaler
+//
+// Field names are snake_case, an absent LastAccessed is written as null, and
+// score_components, tags and a ScopeDefault scope are omitted.
+func (m Memory) MarshalJSON() ([]byte, error) {
+	props := m.Properties
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	return json.Marshal(memoryJSON{
+		ID:              m.ID,
+		Content:         m.Content,
+		Properties:      props,
+		CreatedAt:       m.CreatedAt,
+		LastAccessed:    m.LastAccessed,
+		Score:           m.Score,
+		ScoreComponents: m.ScoreComponents,
+		Type:            m.Type,
+		Strength:        m.Strength,
+		Tags:            m.Tags,
+		Scope:           m.Scope,
+		AccessCount:     m.AccessCount,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, rejecting timestamps that are
+// not RFC 3339
+func (m *Memory) UnmarshalJSON(data []byte) error {
+	var raw memoryJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := checkTimestamp("created_at", &raw.CreatedAt); err != nil {
+		return err
+	}
+	if err := checkTimestamp("last_accessed", raw.LastAccessed); err != nil {
+		return err
+	}
+
+	*m = Memory{
+		ID:              raw.ID,
+		Content:         raw.Content,
+		Properties:      raw.Properties,
+		CreatedAt:       raw.CreatedAt,
+		LastAccessed:    raw.LastAccessed,
+		Score:           raw.Score,
+		ScoreComponents: raw.ScoreComponents,
+		Type:            raw.Type,
+		Strength:        raw.Strength,
+		Tags:            raw.Tags,
+		Scope:           raw.Scope,
+		AccessCount:     raw.AccessCount,
+	}
+	if m.Properties == nil {
+		m.Properties = make(map[string]interface{})
+	}
+	return nil
+}
+
+// String returns a string representation of the memory
+func (m *Memory) String() string {
+	return fmt.Sprintf("Memory{ID: %s, Content: %q}", m.ID, m.Content)
+}
+
+// checkTimestamp returns an error unless value is nil, empty or RFC 3339
+func checkTimestamp(field string, value *string) error {
+	if value == nil || *value == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339Nano, *value); err != nil {
+		return fmt.Errorf("thymos: invalid %s timestamp: %w", field, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package thymosapi
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		length  int
+		wantErr bool
+	}{
+		{name: "empty", length: 0},
+		{name: "short", length: 10},
+		{name: "at limit", length: MaxContentLength},
+		{name: "over limit", length: MaxContentLength + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckContent(strings.Repeat("a", tt.length))
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("CheckContent(%d bytes) = %v, want error %v", tt.length, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrContentTooLarge) {
+				t.Errorf("CheckContent error %v does not wrap ErrContentTooLarge", err)
+			}
+		})
+	}
+}
+
+func TestMemoryJSONRoundTrip(t *testing.T) {
+	accessed := "2024-05-01T12:30:00.5Z"
+	tests := []struct {
+		name string
+		in   Memory
+		want Memory
+	}{
+		{
+			name: "minimal",
+			in:   Memory{ID: "memory:1", Content: "hello", CreatedAt: "2024-05-01T12:00:00Z"},
+			want: Memory{ID: "memory:1", Content: "hello", CreatedAt: "2024-05-01T12:00:00Z", Properties: map[string]interface{}{}},
+		},
+		{
+			name: "full",
+			in: Memory{
+				ID:              "memory:2",
+				Content:         "fact",
+				Properties:      map[string]interface{}{"source": "docs", "page": 3.0},
+				CreatedAt:       "2024-05-01T12:00:00Z",
+				LastAccessed:    &accessed,
+				Score:           0.75,
+				ScoreComponents: map[string]float64{"relevance": 0.5, "recency": 0.25},
+				Type:            MemoryTypeFact,
+				Strength:        0.9,
+				Tags:            []string{"a", "b"},
+				Scope:           ScopeShared,
+				AccessCount:     4,
+			},
+			want: Memory{
+				ID:              "memory:2",
+				Content:         "fact",
+				Properties:      map[string]interface{}{"source": "docs", "page": 3.0},
+				CreatedAt:       "2024-05-01T12:00:00Z",
+				LastAccessed:    &accessed,
+				Score:           0.75,
+				ScoreComponents: map[string]float64{"relevance": 0.5, "recency": 0.25},
+				Type:            MemoryTypeFact,
+				Strength:        0.9,
+				Tags:            []string{"a", "b"},
+				Scope:           ScopeShared,
+				AccessCount:     4,
+			},
+		},
+		{
+			name: "other type",
+			in:   Memory{ID: "memory:3", CreatedAt: "2024-05-01T12:00:00Z", Type: MemoryTypeOther},
+			want: Memory{ID: "memory:3", CreatedAt: "2024-05-01T12:00:00Z", Type: MemoryTypeOther, Properties: map[string]interface{}{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.in)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var got Memory
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal(%s): %v", data, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("round trip of %s = %+v, want %+v", data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryMarshalJSONFields(t *testing.T) {
+	data, err := json.Marshal(Memory{ID: "memory:1", CreatedAt: "2024-05-01T12:00:00Z"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if v, ok := fields["last_accessed"]; !ok || v != nil {
+		t.Errorf("last_accessed = %v (present %v), want null", v, ok)
+	}
+	if v, ok := fields["properties"].(map[string]interface{}); !ok || len(v) != 0 {
+		t.Errorf("properties = %v, want {}", fields["properties"])
+	}
+	for _, omitted := range []string{"score_components", "tags", "scope"} {
+		if _, ok := fields[omitted]; ok {
+			t.Errorf("%s present in %s, want it omitted", omitted, data)
+		}
+	}
+}
+
+func TestMemoryUnmarshalJSONTimestamps(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{name: "rfc3339", json: `{"created_at": "2024-05-01T12:00:00+02:00", "last_accessed": "2024-05-01T12:00:00.123Z"}`},
+		{name: "empty", json: `{"created_at": "", "last_accessed": null}`},
+		{name: "bad created_at", json: `{"created_at": "2024-05-01 12:00:00"}`, wantErr: true},
+		{name: "bad last_accessed", json: `{"created_at": "2024-05-01T12:00:00Z", "last_accessed": "yesterday"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Memory
+			err := json.Unmarshal([]byte(tt.json), &m)
+			if tt.wantErr != (err != nil) {
+				t.Errorf("Unmarshal(%s) = %v, want error %v", tt.json, err, tt.wantErr)
+			}
+		})
+	}
+}
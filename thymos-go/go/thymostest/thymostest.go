@@ -0,0 +1,337 @@
+// Package thymostest provides an in-memory fake of the Thymos memory API for
+// tests.
+//
+// The fake implements thymosapi.MemoryAgent without cgo or the native
+// library, so code that accepts a MemoryAgent can be tested hermetically:
+//
+//	func TestRecall(t *testing.T) {
+//	    agent := thymostest.NewAgent("test")
+//	    defer agent.Close()
+//	    agent.Remember("Alice met Bob in Paris")
+//	    // pass agent to the code under test
+//	}
+//
+// It keeps the real agent's contracts (IDs, errors, limits, ordering and
+// property round-tripping) but not its ranking: a search matches memories
+// containing any query term, case-insensitively, and scores them by the
+// fraction of query terms they contain. Memories never decay, so Strength is
+// always 1, and retrievals are not counted, so AccessCount is always 0.
+package thymostest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blakebarnett/thymos-go/thymosapi"
+)
+
+// Agent is an in-memory thymosapi.MemoryAgent
+type Agent struct {
+	id string
+
+	mu       sync.RWMutex
+	closed   bool
+	nextID   int
+	order    []string
+	memories map[string]*thymosapi.Memory
+}
+
+var _ thymosapi.MemoryAgent = (*Agent)(nil)
+
+// NewAgent creates an empty fake agent with the given ID
+func NewAgent(agentID string) *Agent {
+	return &Agent{id: agentID, memories: make(map[string]*thymosapi.Memory)}
+}
+
+// ID returns the agent's ID
+func (a *Agent) ID() (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return "", thymosapi.ErrNilHandle
+	}
+	return a.id, nil
+}
+
+// Close releases the agent; later calls return thymosapi.ErrNilHandle
+func (a *Agent) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.closed = true
+	a.order = nil
+	a.memories = nil
+}
+
+// Remember stores a general memory
+func (a *Agent) Remember(content string) (string, error) {
+	return a.store(content, thymosapi.MemoryTypeEpisodic, nil)
+}
+
+// RememberFact stores a fact memory
+func (a *Agent) RememberFact(content string) (string, error) {
+	return a.store(content, thymosapi.MemoryTypeFact, nil)
+}
+
+// RememberConversation stores a conversation memory
+func (a *Agent) RememberConversation(content string) (string, error) {
+	return a.store(content, thymosapi.MemoryTypeConversation, nil)
+}
+
+// RememberWithProperties stores a memory with properties
+//
+// Properties round-trip through JSON as they do in the real agent, so
+// numbers come back as float64 and values that can't be marshaled are
+// rejected.
+func (a *Agent) RememberWithProperties(content string, props map[string]interface{}) (string, error) {
+	data, err := json.Marshal(props)
+	if err != nil {
+		return "", fmt.Errorf("thymos: properties are not JSON-serializable: %w", err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return "", fmt.Errorf("thymos: properties are not JSON-serializable: %w", err)
+	}
+	return a.store(content, thymosapi.MemoryTypeEpisodic, stored)
+}
+
+// store adds a memory and returns its ID
+func (a *Agent) store(content string, t thymosapi.MemoryType, props map[string]interface{}) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return "", thymosapi.ErrNilHandle
+	}
+	if err := thymosapi.CheckContent(content); err != nil {
+		return "", err
+	}
+
+	if props == nil {
+		props = make(map[string]interface{})
+	}
+	a.nextID++
+	id := fmt.Sprintf("memory:%08d", a.nextID)
+	a.memories[id] = &thymosapi.Memory{
+		ID:         id,
+		Content:    content,
+		Properties: props,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
+		Type:       t,
+		Strength:   1,
+	}
+	a.order = append(a.order, id)
+	return id, nil
+}
+
+// GetMemory returns a memory by ID, or nil, nil if there is none
+//
+// A missing memory is not an error, matching thymos.Agent.GetMemory.
+func (a *Agent) GetMemory(memoryID string) (*thymosapi.Memory, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return nil, thymosapi.ErrNilHandle
+	}
+	m, ok := a.memories[memoryID]
+	if !ok {
+		return nil, nil
+	}
+	return clone(m), nil
+}
+
+// HasMemory reports whether a memory with the ID exists
+func (a *Agent) HasMemory(memoryID string) (bool, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return false, thymosapi.ErrNilHandle
+	}
+	_, ok := a.memories[memoryID]
+	return ok, nil
+}
+
+// GetMemories returns the memories with the given IDs, parallel to ids, with
+// nil for IDs that have no memory
+func (a *Agent) GetMemories(ids []string) ([]*thymosapi.Memory, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return nil, thymosapi.ErrNilHandle
+	}
+	memories := make([]*thymosapi.Memory, len(ids))
+	for i, id := range ids {
+		if m, ok := a.memories[id]; ok {
+			memories[i] = clone(m)
+		}
+	}
+	return memories, nil
+}
+
+// UpdateMemory replaces a memory's content, keeping its ID and CreatedAt
+//
+// As in the real agent, LastAccessed is set to now.
+func (a *Agent) UpdateMemory(memoryID, newContent string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return thymosapi.ErrNilHandle
+	}
+	if err := thymosapi.CheckContent(newContent); err != nil {
+		return err
+	}
+	m, ok := a.memories[memoryID]
+	if !ok {
+		return fmt.Errorf("%w: %s", thymosapi.ErrMemoryNotFound, memoryID)
+	}
+	m.Content = newContent
+	accessed := time.Now().UTC().Format(time.RFC3339Nano)
+	m.LastAccessed = &accessed
+	return nil
+}
+
+// Forget deletes a memory by ID
+func (a *Agent) Forget(memoryID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return thymosapi.ErrNilHandle
+	}
+	if _, ok := a.memories[memoryID]; !ok {
+		return fmt.Errorf("%w: %s", thymosapi.ErrMemoryNotFound, memoryID)
+	}
+	delete(a.memories, memoryID)
+	a.order = slices.DeleteFunc(a.order, func(id string) bool { return id == memoryID })
+	return nil
+}
+
+// searchLimit converts a limit to the fake's convention, where 0 means every
+// match
+func searchLimit(limit int) (int, error) {
+	switch {
+	case limit == 0:
+		return thymosapi.DefaultSearchLimit, nil
+	case limit == thymosapi.Unlimited:
+		return 0, nil
+	case limit < 0:
+		return 0, fmt.Errorf("%w: %d", thymosapi.ErrInvalidLimit, limit)
+	}
+	return limit, nil
+}
+
+// SearchMemories returns up to limit memories containing any query term,
+// highest Score first
+//
+// limit follows thymos.Agent.SearchMemories: 0 means
+// thymosapi.DefaultSearchLimit, thymosapi.Unlimited means every match and any
+// other negative limit returns thymosapi.ErrInvalidLimit.
+func (a *Agent) SearchMemories(query string, limit int) ([]*thymosapi.Memory, error) {
+	limit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return nil, thymosapi.ErrNilHandle
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	results := []*thymosapi.Memory{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+	for _, id := range a.order {
+		m := a.memories[id]
+		content := strings.ToLower(m.Content)
+		matched := 0
+		for _, term := range terms {
+			if strings.Contains(content, term) {
+				matched++
+			}
+		}
+		if matched > 0 {
+			result := clone(m)
+			result.Score = float64(matched) / float64(len(terms))
+			results = append(results, result)
+		}
+	}
+	// Stable, so equal scores keep insertion order
+	slices.SortStableFunc(results, func(x, y *thymosapi.Memory) int {
+		switch {
+		case x.Score > y.Score:
+			return -1
+		case x.Score < y.Score:
+			return 1
+		}
+		return 0
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// SearchAll returns every memory containing any query term
+func (a *Agent) SearchAll(query string) ([]*thymosapi.Memory, error) {
+	return a.SearchMemories(query, thymosapi.Unlimited)
+}
+
+// ListMemories returns one page of memories, oldest first
+//
+// limit follows SearchMemories, and a negative offset is treated as 0.
+func (a *Agent) ListMemories(offset, limit int) ([]*thymosapi.Memory, error) {
+	limit, err := searchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return nil, thymosapi.ErrNilHandle
+	}
+
+	offset = max(offset, 0)
+	page := []*thymosapi.Memory{}
+	for _, id := range a.order[min(offset, len(a.order)):] {
+		if limit > 0 && len(page) == limit {
+			break
+		}
+		page = append(page, clone(a.memories[id]))
+	}
+	return page, nil
+}
+
+// MemoryCount returns the number of stored memories
+func (a *Agent) MemoryCount() (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return 0, thymosapi.ErrNilHandle
+	}
+	return len(a.memories), nil
+}
+
+// clone copies a memory so callers can't modify the stored one
+func clone(m *thymosapi.Memory) *thymosapi.Memory {
+	c := *m
+	c.Properties = maps.Clone(m.Properties)
+	c.Tags = slices.Clone(m.Tags)
+	return &c
+}
@@ -0,0 +1,290 @@
+package thymostest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/blakebarnett/thymos-go/thymosapi"
+)
+
+// newAgent returns a fake agent holding the given contents, oldest first
+func newAgent(t *testing.T, contents ...string) (*Agent, []string) {
+	t.Helper()
+	agent := NewAgent("test")
+	t.Cleanup(agent.Close)
+
+	ids := make([]string, len(contents))
+	for i, content := range contents {
+		id, err := agent.Remember(content)
+		if err != nil {
+			t.Fatalf("Remember(%q): %v", content, err)
+		}
+		ids[i] = id
+	}
+	return agent, ids
+}
+
+func TestRememberTypes(t *testing.T) {
+	agent, _ := newAgent(t)
+	tests := []struct {
+		name     string
+		remember func(string) (string, error)
+		want     thymosapi.MemoryType
+	}{
+		{"Remember", agent.Remember, thymosapi.MemoryTypeEpisodic},
+		{"RememberFact", agent.RememberFact, thymosapi.MemoryTypeFact},
+		{"RememberConversation", agent.RememberConversation, thymosapi.MemoryTypeConversation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := tt.remember("content")
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			m, err := agent.GetMemory(id)
+			if err != nil || m == nil {
+				t.Fatalf("GetMemory(%q) = %v, %v", id, m, err)
+			}
+			if m.Type != tt.want || m.Strength != 1 || m.LastAccessed != nil {
+				t.Errorf("memory = %+v, want Type %v, Strength 1 and no LastAccessed", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestRememberWithProperties(t *testing.T) {
+	agent, _ := newAgent(t)
+	id, err := agent.RememberWithProperties("content", map[string]interface{}{"page": 3, "source": "docs"})
+	if err != nil {
+		t.Fatalf("RememberWithProperties: %v", err)
+	}
+	m, err := agent.GetMemory(id)
+	if err != nil {
+		t.Fatalf("GetMemory: %v", err)
+	}
+	if m.Properties["page"] != 3.0 || m.Properties["source"] != "docs" {
+		t.Errorf("Properties = %v, want page 3.0 and source docs", m.Properties)
+	}
+
+	_, err = agent.RememberWithProperties("content", map[string]interface{}{"bad": func() {}})
+	if err == nil {
+		t.Error("RememberWithProperties with a func value succeeded, want an error")
+	}
+}
+
+func TestContentTooLarge(t *testing.T) {
+	agent, ids := newAgent(t, "small")
+	large := strings.Repeat("a", thymosapi.MaxContentLength+1)
+
+	if _, err := agent.Remember(large); !errors.Is(err, thymosapi.ErrContentTooLarge) {
+		t.Errorf("Remember = %v, want ErrContentTooLarge", err)
+	}
+	if err := agent.UpdateMemory(ids[0], large); !errors.Is(err, thymosapi.ErrContentTooLarge) {
+		t.Errorf("UpdateMemory = %v, want ErrContentTooLarge", err)
+	}
+}
+
+func TestGetMemoryMissing(t *testing.T) {
+	agent, _ := newAgent(t)
+
+	m, err := agent.GetMemory("memory:missing")
+	if m != nil || err != nil {
+		t.Errorf("GetMemory(missing) = %v, %v, want nil, nil", m, err)
+	}
+	ok, err := agent.HasMemory("memory:missing")
+	if ok || err != nil {
+		t.Errorf("HasMemory(missing) = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestGetMemoriesKeepsOrder(t *testing.T) {
+	agent, ids := newAgent(t, "one", "two")
+
+	got, err := agent.GetMemories([]string{ids[1], "memory:missing", ids[0], ids[1]})
+	if err != nil {
+		t.Fatalf("GetMemories: %v", err)
+	}
+	want := []string{"two", "", "one", "two"}
+	if len(got) != len(want) {
+		t.Fatalf("GetMemories returned %d memories, want %d", len(got), len(want))
+	}
+	for i, m := range got {
+		content := ""
+		if m != nil {
+			content = m.Content
+		}
+		if content != want[i] {
+			t.Errorf("GetMemories[%d] = %q, want %q", i, content, want[i])
+		}
+	}
+}
+
+func TestGetMemoryReturnsCopy(t *testing.T) {
+	agent, _ := newAgent(t)
+	id, err := agent.RememberWithProperties("content", map[string]interface{}{"k": "v"})
+	if err != nil {
+		t.Fatalf("RememberWithProperties: %v", err)
+	}
+
+	m, _ := agent.GetMemory(id)
+	m.Content = "changed"
+	m.Properties["k"] = "changed"
+
+	m, _ = agent.GetMemory(id)
+	if m.Content != "content" || m.Properties["k"] != "v" {
+		t.Errorf("stored memory changed through a returned copy: %+v", m)
+	}
+}
+
+func TestUpdateMemory(t *testing.T) {
+	agent, ids := newAgent(t, "before")
+	before, _ := agent.GetMemory(ids[0])
+
+	if err := agent.UpdateMemory(ids[0], "after"); err != nil {
+		t.Fatalf("UpdateMemory: %v", err)
+	}
+	after, _ := agent.GetMemory(ids[0])
+	if after.Content != "after" || after.CreatedAt != before.CreatedAt {
+		t.Errorf("updated memory = %+v, want content after and CreatedAt %s", after, before.CreatedAt)
+	}
+	if after.LastAccessed == nil {
+		t.Error("UpdateMemory did not set LastAccessed")
+	}
+
+	if err := agent.UpdateMemory("memory:missing", "x"); !errors.Is(err, thymosapi.ErrMemoryNotFound) {
+		t.Errorf("UpdateMemory(missing) = %v, want ErrMemoryNotFound", err)
+	}
+}
+
+func TestForget(t *testing.T) {
+	agent, ids := newAgent(t, "one", "two")
+
+	if err := agent.Forget(ids[0]); err != nil {
+		t.Fatalf("Forget: %v", err)
+	}
+	if err := agent.Forget(ids[0]); !errors.Is(err, thymosapi.ErrMemoryNotFound) {
+		t.Errorf("second Forget = %v, want ErrMemoryNotFound", err)
+	}
+	if n, _ := agent.MemoryCount(); n != 1 {
+		t.Errorf("MemoryCount = %d, want 1", n)
+	}
+	page, _ := agent.ListMemories(0, 0)
+	if len(page) != 1 || page[0].ID != ids[1] {
+		t.Errorf("ListMemories = %v, want only %s", page, ids[1])
+	}
+}
+
+func TestSearchMemories(t *testing.T) {
+	agent, _ := newAgent(t, "alice met bob", "bob went home", "carol stayed", "Alice and Bob")
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{name: "ranked by terms matched", query: "alice bob", limit: 0, want: []string{"alice met bob", "Alice and Bob", "bob went home"}},
+		{name: "limited", query: "alice bob", limit: 1, want: []string{"alice met bob"}},
+		{name: "unlimited", query: "bob", limit: thymosapi.Unlimited, want: []string{"alice met bob", "bob went home", "Alice and Bob"}},
+		{name: "no match", query: "dave", limit: 0, want: nil},
+		{name: "empty query", query: "  ", limit: 0, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := agent.SearchMemories(tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("SearchMemories: %v", err)
+			}
+			if results == nil {
+				t.Fatal("SearchMemories returned nil, want an empty slice")
+			}
+			var got []string
+			for _, m := range results {
+				got = append(got, m.Content)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("SearchMemories(%q, %d) = %q, want %q", tt.query, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchMemoriesDefaultLimit(t *testing.T) {
+	contents := make([]string, thymosapi.DefaultSearchLimit+5)
+	for i := range contents {
+		contents[i] = "match"
+	}
+	agent, _ := newAgent(t, contents...)
+
+	results, _ := agent.SearchMemories("match", 0)
+	if len(results) != thymosapi.DefaultSearchLimit {
+		t.Errorf("SearchMemories(limit 0) returned %d, want %d", len(results), thymosapi.DefaultSearchLimit)
+	}
+	results, _ = agent.SearchAll("match")
+	if len(results) != len(contents) {
+		t.Errorf("SearchAll returned %d, want %d", len(results), len(contents))
+	}
+}
+
+func TestInvalidLimit(t *testing.T) {
+	agent, _ := newAgent(t, "one")
+
+	if _, err := agent.SearchMemories("one", -2); !errors.Is(err, thymosapi.ErrInvalidLimit) {
+		t.Errorf("SearchMemories(-2) = %v, want ErrInvalidLimit", err)
+	}
+	if _, err := agent.ListMemories(0, -2); !errors.Is(err, thymosapi.ErrInvalidLimit) {
+		t.Errorf("ListMemories(-2) = %v, want ErrInvalidLimit", err)
+	}
+}
+
+func TestListMemories(t *testing.T) {
+	agent, _ := newAgent(t, "a", "b", "c", "d")
+
+	tests := []struct {
+		offset, limit int
+		want          string
+	}{
+		{offset: 0, limit: 0, want: "abcd"},
+		{offset: 1, limit: 2, want: "bc"},
+		{offset: -3, limit: 1, want: "a"},
+		{offset: 3, limit: thymosapi.Unlimited, want: "d"},
+		{offset: 10, limit: 0, want: ""},
+	}
+	for _, tt := range tests {
+		page, err := agent.ListMemories(tt.offset, tt.limit)
+		if err != nil {
+			t.Fatalf("ListMemories(%d, %d): %v", tt.offset, tt.limit, err)
+		}
+		got := ""
+		for _, m := range page {
+			got += m.Content
+		}
+		if got != tt.want {
+			t.Errorf("ListMemories(%d, %d) = %q, want %q", tt.offset, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestClosed(t *testing.T) {
+	agent := NewAgent("test")
+	agent.Close()
+
+	calls := map[string]func() error{
+		"ID":             func() error { _, err := agent.ID(); return err },
+		"Remember":       func() error { _, err := agent.Remember("x"); return err },
+		"GetMemory":      func() error { _, err := agent.GetMemory("x"); return err },
+		"HasMemory":      func() error { _, err := agent.HasMemory("x"); return err },
+		"GetMemories":    func() error { _, err := agent.GetMemories([]string{"x"}); return err },
+		"UpdateMemory":   func() error { return agent.UpdateMemory("x", "y") },
+		"Forget":         func() error { return agent.Forget("x") },
+		"SearchMemories": func() error { _, err := agent.SearchMemories("x", 0); return err },
+		"ListMemories":   func() error { _, err := agent.ListMemories(0, 0); return err },
+		"MemoryCount":    func() error { _, err := agent.MemoryCount(); return err },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, thymosapi.ErrNilHandle) {
+			t.Errorf("%s after Close = %v, want ErrNilHandle", name, err)
+		}
+	}
+}
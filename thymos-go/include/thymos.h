@@ -20,6 +20,10 @@ extern "C" {
 typedef struct ThymosAgent ThymosAgent;
 typedef struct ThymosMemoryConfig ThymosMemoryConfig;
 typedef struct ThymosConfigHandle ThymosConfigHandle;
+typedef struct ThymosCancelToken ThymosCancelToken;
+typedef struct ThymosBroker ThymosBroker;
+typedef struct ThymosSubscription ThymosSubscription;
+typedef struct ThymosMemoryCursor ThymosMemoryCursor;
 
 /* ============================================================================
  * Data Structures
@@ -32,6 +36,12 @@ typedef struct ThymosMemory {
     char *properties_json;
     char *created_at;
     char *last_accessed;
+    double score; /* Relevance for ranked searches, 0 otherwise */
+    int memory_type; /* 0 = episodic, 1 = fact, 2 = conversation, -1 = other */
+    double strength; /* Current forgetting-curve strength, 0.0-1.0 */
+    char *tags_json; /* Tags as a JSON array of strings */
+    int scope; /* 0 = default (not hybrid mode), 1 = private, 2 = shared */
+    uint64_t access_count; /* Retrievals through this handle, including this one */
 } ThymosMemory;
 
 /* Search results structure */
@@ -49,15 +59,38 @@ typedef struct ThymosAgentState {
     char *properties_json;
 } ThymosAgentState;
 
+/* String list structure */
+typedef struct ThymosStringList {
+    char **items;
+    size_t count;
+    size_t capacity;
+} ThymosStringList;
+
 /* ============================================================================
  * Error Handling
  * ============================================================================ */
 
-/* Get the last error message (valid until next FFI call) */
-const char *thymos_get_last_error(void);
+/* Error reported by a failed call. Functions that can fail take a trailing
+ * ThymosError *out_error, which is zeroed when the call starts and, if the
+ * call fails, receives the error's code and message; each call reports only
+ * its own error. Free message with thymos_free_string. out_error may be NULL
+ * to ignore the error */
+typedef struct ThymosError {
+    int code;
+    char *message;
+} ThymosError;
 
-/* Clear the last error */
-void thymos_clear_error(void);
+/* Error codes reported in ThymosError.code */
+#define THYMOS_ERROR_NONE             0
+#define THYMOS_ERROR_INTERNAL         1
+#define THYMOS_ERROR_INVALID_ARGUMENT 2
+#define THYMOS_ERROR_STORAGE          3
+#define THYMOS_ERROR_NOT_FOUND        4
+#define THYMOS_ERROR_NOT_HYBRID       5
+#define THYMOS_ERROR_CONFIGURATION    6
+#define THYMOS_ERROR_CANCELED         7
+#define THYMOS_ERROR_READ_ONLY        8
+#define THYMOS_ERROR_NO_LLM           9
 
 /* ============================================================================
  * Memory Management
@@ -70,6 +103,10 @@ void thymos_free_agent(ThymosAgent *handle);
 void thymos_free_memory_config(ThymosMemoryConfig *handle);
 void thymos_free_config(ThymosConfigHandle *handle);
 void thymos_free_agent_state(ThymosAgentState *state);
+void thymos_free_string_list(ThymosStringList *list);
+void thymos_free_bytes(uint8_t *data, size_t len);
+void thymos_free_doubles(double *data, size_t len);
+void thymos_free_floats(float *data, size_t len);
 
 /* ============================================================================
  * Configuration
@@ -79,47 +116,200 @@ void thymos_free_agent_state(ThymosAgentState *state);
 ThymosMemoryConfig *thymos_memory_config_new(void);
 
 /* Create memory config with custom data directory (embedded mode) */
-ThymosMemoryConfig *thymos_memory_config_with_data_dir(const char *data_dir);
+ThymosMemoryConfig *thymos_memory_config_with_data_dir(
+    const char *data_dir,
+    ThymosError *out_error
+);
 
 /* Create memory config for server mode (connects to Locai server) */
 ThymosMemoryConfig *thymos_memory_config_server(
     const char *server_url,
-    const char *api_key  /* can be NULL */
+    const char *api_key  /* can be NULL */,
+    ThymosError *out_error
 );
 
 /* Create memory config for hybrid mode (private embedded + shared server) */
 ThymosMemoryConfig *thymos_memory_config_hybrid(
     const char *private_data_dir,
     const char *shared_url,
-    const char *shared_api_key  /* can be NULL */
+    const char *shared_api_key  /* can be NULL */,
+    ThymosError *out_error
+);
+
+/* Set how long forgotten memories stay recoverable (0 = purge immediately).
+ * Returns 0 on success, -1 on error */
+int thymos_memory_config_set_forget_grace_period(
+    ThymosMemoryConfig *config,
+    uint64_t grace_ms,
+    ThymosError *out_error
+);
+
+/* Record the memory count every interval_ms for count history (0 = off).
+ * Returns 0 on success, -1 on error */
+int thymos_memory_config_set_count_sampling(
+    ThymosMemoryConfig *config,
+    uint64_t interval_ms,
+    ThymosError *out_error
+);
+
+/* Cache search results by (query, limit) for ttl_ms; writes clear the cache.
+ * size=0 disables. Returns 0 on success, -1 on error */
+int thymos_memory_config_set_query_cache(
+    ThymosMemoryConfig *config,
+    size_t size,
+    uint64_t ttl_ms,
+    ThymosError *out_error
+);
+
+/* Set the largest blob thymos_agent_attach_blob accepts (default 16 MiB).
+ * Returns 0 on success, -1 on error */
+int thymos_memory_config_set_max_blob_size(
+    ThymosMemoryConfig *config,
+    size_t max_bytes,
+    ThymosError *out_error
+);
+
+/* Record a timestamp for every memory access (for access timelines).
+ * Returns 0 on success, -1 on error */
+int thymos_memory_config_set_track_access_timeline(
+    ThymosMemoryConfig *config,
+    bool enabled,
+    ThymosError *out_error
+);
+
+/* Cap the number of memories kept; the weakest beyond the cap are forgotten
+ * after each insert (0 = no cap). Returns 0 on success, -1 on error */
+int thymos_memory_config_set_max_memories(
+    ThymosMemoryConfig *config,
+    size_t max_memories,
+    ThymosError *out_error
+);
+
+/* Set how many contents thymos_agent_remember_batch,
+ * thymos_agent_import_file and thymos_knowledge_overlap embed per embedding
+ * provider call (default 32).
+ * Returns 0 on success, -1 on error (including 0) */
+int thymos_memory_config_set_embedding_batch_size(
+    ThymosMemoryConfig *config,
+    size_t batch_size,
+    ThymosError *out_error
+);
+
+/* Set the base decay rate applied to memory strength as memories age.
+ * Returns 0 on success, -1 on error */
+int thymos_memory_config_set_decay_rate(
+    ThymosMemoryConfig *config,
+    double rate,
+    ThymosError *out_error
 );
 
+/* Forget memories whose strength falls below threshold after each insert
+ * (0 = disabled). Returns 0 on success, -1 on error */
+int thymos_memory_config_set_forget_threshold(
+    ThymosMemoryConfig *config,
+    double threshold,
+    ThymosError *out_error
+);
+
+/* Check a memory configuration without creating an agent. Returns 0 if
+ * valid, -1 with THYMOS_ERROR_CONFIGURATION describing the first problem */
+int thymos_memory_config_validate(const ThymosMemoryConfig *config, ThymosError *out_error);
+
 /* Create default Thymos configuration */
 ThymosConfigHandle *thymos_config_new(void);
 
 /* Load configuration from file and environment */
-ThymosConfigHandle *thymos_config_load(void);
+ThymosConfigHandle *thymos_config_load(ThymosError *out_error);
 
 /* Load configuration from specific file */
-ThymosConfigHandle *thymos_config_load_from_file(const char *path);
+ThymosConfigHandle *thymos_config_load_from_file(const char *path, ThymosError *out_error);
+
+/* Select the embedding model (local provider unless configured otherwise;
+ * "" removes embeddings). Returns 0 on success, -1 on error */
+int thymos_config_set_embedding_model(
+    ThymosConfigHandle *config,
+    const char *model,
+    ThymosError *out_error
+);
+
+/* Set the dimensions the embedding model must produce (0 = any). Requires an
+ * embedding model. Returns 0 on success, -1 on error */
+int thymos_config_set_embedding_dimensions(
+    ThymosConfigHandle *config,
+    size_t dimensions,
+    ThymosError *out_error
+);
+
+/* Get the embedding model, "" if none (must free with thymos_free_string) */
+char *thymos_config_embedding_model(const ThymosConfigHandle *config, ThymosError *out_error);
+
+/* Set the local storage directory (the private directory in hybrid mode;
+ * fails in server mode). Returns 0 on success, -1 on error */
+int thymos_config_set_data_dir(
+    ThymosConfigHandle *config,
+    const char *data_dir,
+    ThymosError *out_error
+);
+
+/* Switch to hybrid mode, keeping the local data directory as the private one.
+ * shared_api_key may be NULL. Returns 0 on success, -1 on error */
+int thymos_config_set_hybrid(
+    ThymosConfigHandle *config,
+    const char *shared_url,
+    const char *shared_api_key,
+    ThymosError *out_error
+);
+
+/* Check a configuration without creating an agent. Returns 0 if valid, -1
+ * with THYMOS_ERROR_CONFIGURATION describing the first problem */
+int thymos_config_validate(const ThymosConfigHandle *config, ThymosError *out_error);
 
 /* ============================================================================
  * Agent Lifecycle
  * ============================================================================ */
 
 /* Create agent with default configuration */
-ThymosAgent *thymos_agent_new(const char *agent_id);
+ThymosAgent *thymos_agent_new(const char *agent_id, ThymosError *out_error);
 
 /* Create agent with custom memory configuration */
 ThymosAgent *thymos_agent_new_with_memory_config(
     const char *agent_id,
-    const ThymosMemoryConfig *config
+    const ThymosMemoryConfig *config,
+    ThymosError *out_error
 );
 
 /* Create agent with full Thymos configuration */
 ThymosAgent *thymos_agent_new_with_config(
     const char *agent_id,
-    const ThymosConfigHandle *config
+    const ThymosConfigHandle *config,
+    ThymosError *out_error
+);
+
+/* Reopen an existing agent store at data_dir with the configuration saved in
+ * it when the agent was created (API keys excepted; providers read them from
+ * the environment). Stores without a saved configuration open with the
+ * defaults. Fails with THYMOS_ERROR_NOT_FOUND if data_dir is missing or
+ * empty */
+ThymosAgent *thymos_agent_open(const char *agent_id, const char *data_dir, ThymosError *out_error);
+
+/* Reopen an existing agent store like thymos_agent_open, but every
+ * write fails with THYMOS_ERROR_READ_ONLY and no TTL sweeps or retention
+ * run. Searches still update last access times in the store, and the store
+ * is locked exclusively as for writing, so other processes cannot open it.
+ * Must free with thymos_free_agent */
+ThymosAgent *thymos_agent_open_read_only(
+    const char *agent_id,
+    const char *data_dir,
+    ThymosError *out_error
+);
+
+/* Fork an embedded-mode agent: eagerly copy its memories, blobs and saved
+ * queries into "<parent data dir>.fork-<new_agent_id>" and return an
+ * independent agent (must free with thymos_free_agent), or NULL on error */
+ThymosAgent *thymos_agent_fork(
+    const ThymosAgent *handle,
+    const char *new_agent_id,
+    ThymosError *out_error
 );
 
 /* ============================================================================
@@ -127,41 +317,205 @@ ThymosAgent *thymos_agent_new_with_config(
  * ============================================================================ */
 
 /* Get agent ID (must free with thymos_free_string) */
-char *thymos_agent_id(const ThymosAgent *handle);
+char *thymos_agent_id(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Change the agent ID in place, keeping its memories and storage (embedded
+ * mode only). Fails if the fork directory for new_agent_id exists. No other
+ * call may use handle concurrently. Returns 0 on success, -1 on error */
+int thymos_agent_rename(ThymosAgent *handle, const char *new_agent_id, ThymosError *out_error);
 
 /* Get agent description (must free with thymos_free_string) */
-char *thymos_agent_description(const ThymosAgent *handle);
+char *thymos_agent_description(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Set agent description ("" restores the original). Returns 0 on success,
+ * -1 on error */
+int thymos_agent_set_description(
+    const ThymosAgent *handle,
+    const char *description,
+    ThymosError *out_error
+);
+
+/* Get agent persona, "" if unset (must free with thymos_free_string) */
+char *thymos_agent_persona(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Set agent persona ("" clears). Returns 0 on success, -1 on error */
+int thymos_agent_set_persona(
+    const ThymosAgent *handle,
+    const char *persona,
+    ThymosError *out_error
+);
 
 /* Get agent status: "Active", "Listening", "Dormant", "Archived" */
-char *thymos_agent_status(const ThymosAgent *handle);
+char *thymos_agent_status(const ThymosAgent *handle, ThymosError *out_error);
 
 /* Set agent status. Returns 0 on success, -1 on error */
-int thymos_agent_set_status(const ThymosAgent *handle, const char *status);
+int thymos_agent_set_status(const ThymosAgent *handle, const char *status, ThymosError *out_error);
 
 /* Get full agent state (must free with thymos_free_agent_state) */
-ThymosAgentState *thymos_agent_state(const ThymosAgent *handle);
+ThymosAgentState *thymos_agent_state(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Check the local store is open and writable by storing, reading back and
+ * deleting a probe memory; a read-only agent's store is only read. Returns 0
+ * on success, -1 on error */
+int thymos_agent_ping(const ThymosAgent *handle, ThymosError *out_error);
 
 /* Check if agent is in hybrid mode. Returns 1 if hybrid, 0 otherwise, -1 on error */
-int thymos_agent_is_hybrid(const ThymosAgent *handle);
+int thymos_agent_is_hybrid(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Get the absolute local data directory (the private store in hybrid mode).
+ * Fails with THYMOS_ERROR_CONFIGURATION in server mode
+ * (must free with thymos_free_string) */
+char *thymos_agent_data_dir(const ThymosAgent *handle, ThymosError *out_error);
 
 /* ============================================================================
  * Memory Operations
  * ============================================================================ */
 
 /* Store a memory. Returns memory ID (must free with thymos_free_string) */
-char *thymos_agent_remember(const ThymosAgent *handle, const char *content);
+char *thymos_agent_remember(const ThymosAgent *handle, const char *content, ThymosError *out_error);
 
 /* Store a fact memory (durable knowledge) */
-char *thymos_agent_remember_fact(const ThymosAgent *handle, const char *content);
+char *thymos_agent_remember_fact(
+    const ThymosAgent *handle,
+    const char *content,
+    ThymosError *out_error
+);
 
 /* Store a conversation memory (dialogue context) */
-char *thymos_agent_remember_conversation(const ThymosAgent *handle, const char *content);
+char *thymos_agent_remember_conversation(
+    const ThymosAgent *handle,
+    const char *content,
+    ThymosError *out_error
+);
 
 /* Store memory in private backend (hybrid mode only) */
-char *thymos_agent_remember_private(const ThymosAgent *handle, const char *content);
+char *thymos_agent_remember_private(
+    const ThymosAgent *handle,
+    const char *content,
+    ThymosError *out_error
+);
 
 /* Store memory in shared backend (hybrid mode only) */
-char *thymos_agent_remember_shared(const ThymosAgent *handle, const char *content);
+char *thymos_agent_remember_shared(
+    const ThymosAgent *handle,
+    const char *content,
+    ThymosError *out_error
+);
+
+/* Store memory with properties given as a JSON object. Must free with
+ * thymos_free_string */
+char *thymos_agent_remember_with_properties(
+    const ThymosAgent *handle,
+    const char *content,
+    const char *properties_json,
+    ThymosError *out_error
+);
+
+/* The writes above, failing with "operation canceled" if token is canceled
+ * first; token may be NULL (not cancelable). A write canceled while being
+ * embedded stores nothing; one canceled once the store is writing may still
+ * be stored */
+char *thymos_agent_remember_cancelable(
+    const ThymosAgent *handle,
+    const char *content,
+    const ThymosCancelToken *token,
+    ThymosError *out_error
+);
+char *thymos_agent_remember_fact_cancelable(
+    const ThymosAgent *handle,
+    const char *content,
+    const ThymosCancelToken *token,
+    ThymosError *out_error
+);
+char *thymos_agent_remember_conversation_cancelable(
+    const ThymosAgent *handle,
+    const char *content,
+    const ThymosCancelToken *token,
+    ThymosError *out_error
+);
+char *thymos_agent_remember_private_cancelable(
+    const ThymosAgent *handle,
+    const char *content,
+    const ThymosCancelToken *token,
+    ThymosError *out_error
+);
+char *thymos_agent_remember_shared_cancelable(
+    const ThymosAgent *handle,
+    const char *content,
+    const ThymosCancelToken *token,
+    ThymosError *out_error
+);
+char *thymos_agent_remember_with_properties_cancelable(
+    const ThymosAgent *handle,
+    const char *content,
+    const char *properties_json,
+    const ThymosCancelToken *token,
+    ThymosError *out_error
+);
+
+/* Store many memories in order. Failed items don't stop the batch:
+ * *out_ids and *out_errors are parallel to contents, holding the ID or ""
+ * and the error message or "". Free both with thymos_free_string_list.
+ * Returns the number stored, -1 on error */
+int64_t thymos_agent_remember_batch(
+    const ThymosAgent *handle,
+    const char *const *contents,
+    size_t count,
+    ThymosStringList **out_ids,
+    ThymosStringList **out_errors,
+    ThymosError *out_error
+);
+
+/* Delete a memory by ID. Fails with "memory not found" if absent.
+ * Returns 0 on success, -1 on error */
+int thymos_agent_forget(const ThymosAgent *handle, const char *memory_id, ThymosError *out_error);
+
+/* Replace a memory's content, keeping its ID and creation time. The memory
+ * is re-embedded and its last-accessed time set to now. Fails with
+ * "memory not found" (THYMOS_ERROR_NOT_FOUND) if absent.
+ * Returns 0 on success, -1 on error */
+int thymos_agent_update_memory(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    const char *content,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Bulk Import
+ * ============================================================================ */
+
+/* Import a file. format: 0 = JSONL, 1 = CSV. For CSV, content_column (NULL =
+ * "content") holds the content and property_columns (count 0 = all others)
+ * become properties. Nothing is imported if any row is malformed or its content
+ * is longer than 1 MiB; its line is written to out_error_line. Rows are embedded in batches like
+ * thymos_agent_remember_batch. *out_imported receives the number stored, also
+ * on error. Returns 0 on success, -1 on error */
+int thymos_agent_import_file(
+    const ThymosAgent *handle,
+    const char *path,
+    int format,
+    const char *content_column,
+    const char *const *property_columns,
+    size_t property_count,
+    size_t *out_error_line,
+    size_t *out_imported,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Cancellation
+ * ============================================================================ */
+
+/* Create a cancellation token. Must free with thymos_cancel_token_free */
+ThymosCancelToken *thymos_cancel_token_new(void);
+
+/* Cancel operations running with the token; they fail with
+ * "operation canceled". Safe to call from any thread */
+void thymos_cancel_token_cancel(const ThymosCancelToken *token);
+
+/* Free a token no longer used by any operation */
+void thymos_cancel_token_free(ThymosCancelToken *token);
 
 /* ============================================================================
  * Memory Search
@@ -171,27 +525,896 @@ char *thymos_agent_remember_shared(const ThymosAgent *handle, const char *conten
 ThymosSearchResults *thymos_agent_search_memories(
     const ThymosAgent *handle,
     const char *query,
-    size_t limit
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* Search memories, failing with "operation canceled" if token is canceled
+ * first. token may be NULL (not cancelable) */
+ThymosSearchResults *thymos_agent_search_memories_cancelable(
+    const ThymosAgent *handle,
+    const char *query,
+    size_t limit,
+    const ThymosCancelToken *token,
+    ThymosError *out_error
+);
+
+/* Search memories, dropping results scored below min_score before limit is
+ * applied (must free with thymos_free_search_results) */
+ThymosSearchResults *thymos_agent_search_memories_threshold(
+    const ThymosAgent *handle,
+    const char *query,
+    size_t limit,
+    double min_score,
+    ThymosError *out_error
+);
+
+/* Search each of count queries like thymos_agent_search_memories, running
+ * them concurrently. Results are flattened in query order; out_counts must
+ * hold count entries and receives each query's result count. Any failed
+ * query fails the call (must free with thymos_free_search_results) */
+ThymosSearchResults *thymos_agent_search_many(
+    const ThymosAgent *handle,
+    const char *const *queries,
+    size_t count,
+    size_t limit,
+    size_t *out_counts,
+    ThymosError *out_error
+);
+
+/* Get query cache hit/miss counts. Returns 0 on success, -1 on error
+ * (including when no cache is configured) */
+int thymos_agent_query_cache_stats(
+    const ThymosAgent *handle,
+    uint64_t *out_hits,
+    uint64_t *out_misses,
+    ThymosError *out_error
+);
+
+/* Search memories and report the IDs whose access time the search updated:
+ * the returned memories from the local store. limit is applied by the store,
+ * and a hybrid agent takes private matches first. *out_reinforced must be
+ * freed with thymos_free_string_list */
+ThymosSearchResults *thymos_agent_search_memories_reinforced(
+    const ThymosAgent *handle,
+    const char *query,
+    size_t limit,
+    ThymosStringList **out_reinforced,
+    ThymosError *out_error
+);
+
+/* Search and group results by a property. *out_keys holds one group key per
+ * returned memory ("" when the property is absent). limit is per group,
+ * 0 for no limit. *out_keys must be freed with thymos_free_string_list */
+ThymosSearchResults *thymos_agent_search_grouped(
+    const ThymosAgent *handle,
+    const char *query,
+    size_t limit,
+    const char *group_by,
+    ThymosStringList **out_keys,
+    ThymosError *out_error
+);
+
+/* Search and rerank with a formula over score, recency, retention and
+ * access_count, e.g. "0.7*score + 0.3*recency". limit=0 for no limit */
+ThymosSearchResults *thymos_agent_search_reranked(
+    const ThymosAgent *handle,
+    const char *query,
+    size_t limit,
+    const char *formula,
+    ThymosError *out_error
+);
+
+/* Search and return each result's score components as a parallel list of
+ * JSON objects {"semantic", "recency_boost", "retention_factor"} in
+ * *out_components (free with thymos_free_string_list). limit=0 for no limit */
+ThymosSearchResults *thymos_agent_search_explained(
+    const ThymosAgent *handle,
+    const char *query,
+    size_t limit,
+    ThymosStringList **out_components,
+    ThymosError *out_error
+);
+
+/* Search by fused semantic and BM25 keyword relevance. alpha in [0, 1]:
+ * 1 = pure semantic, 0 = pure keyword (scoring the store's text-search matches
+ * only). limit=0 for no limit */
+ThymosSearchResults *thymos_agent_search_hybrid(
+    const ThymosAgent *handle,
+    const char *query,
+    size_t limit,
+    double alpha,
+    ThymosError *out_error
 );
 
 /* Search private memories (hybrid mode only) */
 ThymosSearchResults *thymos_agent_search_private(
     const ThymosAgent *handle,
     const char *query,
-    size_t limit
+    size_t limit,
+    ThymosError *out_error
 );
 
 /* Search shared memories (hybrid mode only) */
 ThymosSearchResults *thymos_agent_search_shared(
     const ThymosAgent *handle,
     const char *query,
-    size_t limit
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* Search private and shared memories, merged by relevance (hybrid mode only).
+ * Each result's scope reports the backend it came from. */
+ThymosSearchResults *thymos_agent_search_scoped(
+    const ThymosAgent *handle,
+    const char *query,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* Search only among allowed_ids. An empty allowlist returns no results.
+ * limit=0 for no limit */
+ThymosSearchResults *thymos_agent_search_within(
+    const ThymosAgent *handle,
+    const char *query,
+    const char *const *allowed_ids,
+    size_t allowed_count,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* Search only memories of one type: 0 = episodic, 1 = fact,
+ * 2 = conversation. limit=0 for no limit */
+ThymosSearchResults *thymos_agent_search_by_type(
+    const ThymosAgent *handle,
+    const char *query,
+    int memory_type,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* Search memories created with since_ms <= created_at <= until_ms (Unix
+ * milliseconds, inclusive). limit=0 for no limit */
+ThymosSearchResults *thymos_agent_search_in_range(
+    const ThymosAgent *handle,
+    const char *query,
+    int64_t since_ms,
+    int64_t until_ms,
+    size_t limit,
+    ThymosError *out_error
 );
 
 /* Get memory by ID. Returns NULL if not found */
 ThymosMemory *thymos_agent_get_memory(
     const ThymosAgent *handle,
-    const char *memory_id
+    const char *memory_id,
+    ThymosError *out_error
+);
+
+/* Check whether a memory exists (not an access; reads it like
+ * thymos_agent_get_memory). Returns 1 if it exists, 0 if not, -1 on error */
+int thymos_agent_has_memory(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    ThymosError *out_error
+);
+
+/* Get the memories with the given IDs, in the order given; IDs with no memory
+ * are skipped (must free with thymos_free_search_results) */
+ThymosSearchResults *thymos_agent_get_memories(
+    const ThymosAgent *handle,
+    const char **memory_ids,
+    size_t count,
+    ThymosError *out_error
+);
+
+/* Get the lowercase hex SHA-256 of a memory's content. Fails with
+ * "memory not found" if absent. Must free with thymos_free_string */
+char *thymos_agent_fingerprint(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    ThymosError *out_error
+);
+
+/* List memories oldest first (ties by ID), skipping offset and returning up
+ * to limit (limit=0 for no limit). Empty results past the end */
+ThymosSearchResults *thymos_agent_list_memories(
+    const ThymosAgent *handle,
+    size_t offset,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* List memories created or changed (per their "modified_at" property) at or
+ * after since_ms (Unix milliseconds), oldest change first, ties by ID, up to
+ * limit (limit=0 for no limit). Accesses do not count as changes */
+ThymosSearchResults *thymos_agent_modified_since(
+    const ThymosAgent *handle,
+    int64_t since_ms,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* List the IDs of memories deleted at or after since_ms, oldest first, as
+ * noticed by this handle. Free with thymos_free_string_list */
+ThymosStringList *thymos_agent_deleted_since(
+    const ThymosAgent *handle,
+    int64_t since_ms,
+    ThymosError *out_error
+);
+
+/* Count stored memories from the store index. Returns count, -1 on error */
+int64_t thymos_agent_memory_count(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Count stored memories of one type (0 = episodic, 1 = fact,
+ * 2 = conversation). Loads every memory. Returns count, -1 on error */
+int64_t thymos_agent_memory_count_by_type(
+    const ThymosAgent *handle,
+    int memory_type,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Forgetting
+ * ============================================================================ */
+
+/* Forget memories with strength below threshold (0-1). Returns count, -1 on
+ * error */
+int64_t thymos_agent_prune(const ThymosAgent *handle, double threshold, ThymosError *out_error);
+
+/* List forgotten memories still within the grace period, newest first.
+ * limit=0 for no limit */
+ThymosSearchResults *thymos_agent_recently_forgotten(
+    const ThymosAgent *handle,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* Restore a recently forgotten memory. Returns 0 on success, -1 on error */
+int thymos_agent_restore_memory(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    ThymosError *out_error
+);
+
+/* Drop the handle's records (access counts and timelines, TTLs, concept index)
+ * of memories no longer in the store, and purge forgotten memories past the
+ * grace period. Returns the number dropped, -1 on error */
+int64_t thymos_agent_compact(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Suggest a decay rate (for thymos_memory_config_set_decay_rate) and retention
+ * floor from observed re-access intervals; the rate halves age decay over
+ * out_reuse_interval_ms. Returns 0 on success, -1 on error */
+int thymos_agent_suggest_forgetting(
+    const ThymosAgent *handle,
+    uint64_t *out_reuse_interval_ms,
+    double *out_decay_rate,
+    double *out_retention_floor,
+    size_t *out_sample_size,
+    ThymosError *out_error
+);
+
+/* Project a memory's strength to time at_ms (Unix ms), assuming no further
+ * access. Does not modify the memory. Returns 0 on success, -1 on error */
+int thymos_agent_project_retention(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    int64_t at_ms,
+    double *out_strength,
+    ThymosError *out_error
+);
+
+/* Reinforce a memory by setting its last-accessed time to now, restarting its
+ * forgetting curve. Fails with "memory not found" if absent.
+ * Returns 0 on success, -1 on error */
+int thymos_agent_reinforce(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Lifecycle State
+ * ============================================================================ */
+
+/* Export retention state of all memories as JSON (no content).
+ * Must free with thymos_free_string */
+char *thymos_agent_export_lifecycle(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Import retention state. Must match the current memory set exactly.
+ * Returns 0 on success, -1 on error */
+int thymos_agent_import_lifecycle(
+    const ThymosAgent *handle,
+    const char *state_json,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Store Analytics
+ * ============================================================================ */
+
+/* Get memory count history as JSON [{"at": "<rfc3339>", "count": n}], one
+ * point per interval_ms since since_ms (Unix ms), starting at the first
+ * sample and keeping at most the latest 10,000. Requires count sampling.
+ * Must free with thymos_free_string */
+char *thymos_agent_count_history(
+    const ThymosAgent *handle,
+    int64_t since_ms,
+    int64_t interval_ms,
+    ThymosError *out_error
+);
+
+/* Get a memory's access timestamps as a JSON array of RFC 3339 strings, oldest
+ * first. Requires access timeline tracking. Must free with thymos_free_string */
+char *thymos_agent_access_timeline(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    ThymosError *out_error
+);
+
+/* Count memories by age. boundaries_ms are ascending bucket upper bounds;
+ * out_counts must hold boundary_count + 1 entries (last = older than final
+ * boundary). Returns 0 on success, -1 on error */
+int thymos_agent_age_histogram(
+    const ThymosAgent *handle,
+    const int64_t *boundaries_ms,
+    size_t boundary_count,
+    size_t *out_counts,
+    ThymosError *out_error
+);
+
+/* Get the most common ISO 639-3 language code across memories, writing its
+ * share of language-detectable memories to out_fraction. Returns "" and 0 when
+ * nothing is detectable (must free with thymos_free_string) */
+char *thymos_agent_dominant_language(
+    const ThymosAgent *handle,
+    double *out_fraction,
+    ThymosError *out_error
+);
+
+/* Get raw term counts as JSON [{"term": "...", "count": n}], most frequent
+ * first. top_n=0 for all terms. Must free with thymos_free_string */
+char *thymos_agent_term_frequencies(
+    const ThymosAgent *handle,
+    size_t top_n,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Blob Attachments
+ * ============================================================================ */
+
+/* Store len bytes as blob name on a memory, replacing any existing blob.
+ * Returns 0 on success, -1 on error (including size limit exceeded) */
+int thymos_agent_attach_blob(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    const char *name,
+    const uint8_t *data,
+    size_t len,
+    ThymosError *out_error
+);
+
+/* Read a memory's blob, writing its length to out_len. Returns NULL on error.
+ * Must free with thymos_free_bytes(data, *out_len) */
+uint8_t *thymos_agent_get_blob(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    const char *name,
+    size_t *out_len,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Saved Queries
+ * ============================================================================ */
+
+/* Save a named query, persisted under the data directory. query_json is
+ * {"text": "...", "memory_type": 1} with memory_type optional (codes as for
+ * thymos_agent_search_by_type). Returns 0 on success, -1 on error */
+int thymos_agent_save_query(
+    const ThymosAgent *handle,
+    const char *name,
+    const char *query_json,
+    ThymosError *out_error
+);
+
+/* Run a saved query. Fails with "saved query not found" if absent.
+ * limit=0 for no limit. Free with thymos_free_search_results */
+ThymosSearchResults *thymos_agent_run_saved_query(
+    const ThymosAgent *handle,
+    const char *name,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* List saved query names, sorted. Free with thymos_free_string_list */
+ThymosStringList *thymos_agent_list_saved_queries(
+    const ThymosAgent *handle,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Embedding Repair
+ * ============================================================================ */
+
+/* List memories stored without an embedding, oldest first. limit=0 for no
+ * limit. Must free with thymos_free_search_results */
+ThymosSearchResults *thymos_agent_unembedded(
+    const ThymosAgent *handle,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* Embed all memories stored without an embedding.
+ * Returns count embedded, -1 on error */
+int64_t thymos_agent_embed_pending(const ThymosAgent *handle, ThymosError *out_error);
+
+/* ============================================================================
+ * Contradiction Detection
+ * ============================================================================ */
+
+/* Find memory pairs that appear to contradict each other. Pairs are flattened
+ * (memories 2i and 2i+1 form pair i), most confident first; pair i's
+ * confidence is (*out_confidences)[i]. limit=0 for no limit. Free results with
+ * thymos_free_search_results and *out_confidences with
+ * thymos_free_doubles(*out_confidences, count / 2) */
+ThymosSearchResults *thymos_agent_contradictions(
+    const ThymosAgent *handle,
+    size_t limit,
+    double **out_confidences,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Graph Export
+ * ============================================================================ */
+
+/* Export the memory/entity graph as JSON {"nodes": [...], "edges": [...]}.
+ * Node kinds: "memory", "entity". Edge kinds: "mentions", "co_occurs".
+ * Must free with thymos_free_string */
+char *thymos_agent_export_graph(const ThymosAgent *handle, ThymosError *out_error);
+
+/* ============================================================================
+ * Concept Index
+ * ============================================================================ */
+
+/* Rebuild the concept index, extracting up to batch_size memories per call
+ * (0 for all). The first call snapshots every memory; later calls resume it.
+ * The new index replaces the old one only when complete. out_done and
+ * out_total may be null.
+ * Returns 1 when complete, 0 if memories remain, -1 on error */
+int thymos_agent_rebuild_concepts(
+    const ThymosAgent *handle,
+    size_t batch_size,
+    uint64_t *out_done,
+    uint64_t *out_total,
+    ThymosError *out_error
+);
+
+/* Get the significant concepts extracted from a memory as JSON
+ * [{"name", "type", "confidence"}] (must free with thymos_free_string).
+ * Returns NULL on error; unknown IDs fail with THYMOS_ERROR_NOT_FOUND */
+char *thymos_agent_get_concepts(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    ThymosError *out_error
+);
+
+/* Find memories sharing extracted entities with memory_id, scored and ordered
+ * by connection strength (limit 0 = no limit). Memories missing from the
+ * concept index are extracted and added to it. Must free with
+ * thymos_free_search_results. Unknown IDs fail with THYMOS_ERROR_NOT_FOUND */
+ThymosSearchResults *thymos_agent_related_memories(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Knowledge Overlap
+ * ============================================================================ */
+
+/* Compute a 0-1 Jaccard-like overlap of two agents' memories by embedding
+ * similarity. Both agents must use the same embedding model and dimension.
+ * Stored embeddings are reused; the rest are embedded in batches of each
+ * agent's embedding batch size. Compares every pair of memories: O(N*M).
+ * Returns 0 on success, -1 on error */
+int thymos_knowledge_overlap(
+    const ThymosAgent *a,
+    const ThymosAgent *b,
+    double *out_score,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Embedding Outliers
+ * ============================================================================ */
+
+/* Memories whose stored embeddings are farthest (cosine distance) from the
+ * store centroid, farthest first. Unembedded memories are skipped.
+ * limit=0 for no limit. Free with thymos_free_search_results */
+ThymosSearchResults *thymos_agent_embedding_outliers(
+    const ThymosAgent *handle,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Agent Merge
+ * ============================================================================ */
+
+/* Copy all of src's memories into dst. on_conflict: 0 = keep dst, 1 = keep
+ * src, 2 = keep newer. Returns the number of memories written, -1 on error */
+int64_t thymos_merge_agents(
+    const ThymosAgent *dst,
+    const ThymosAgent *src,
+    int on_conflict,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Shared Batches
+ * ============================================================================ */
+
+/* Write contents[i] to the shared backend of handles[i] (hybrid mode only),
+ * one at a time. Not atomic: on failure, writes already applied are deleted
+ * again where possible and NULL is returned.
+ * Returns the new memory IDs in order (must free with thymos_free_string_list) */
+ThymosStringList *thymos_shared_batch_commit(
+    const ThymosAgent *const *handles,
+    const char *const *contents,
+    size_t count,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Ingest Benchmark
+ * ============================================================================ */
+
+/* Store contents as temporary memories, measure ingestion, then delete them.
+ * Returns JSON {"memories_per_second", "p50_embed_micros", "p99_embed_micros"}
+ * (must free with thymos_free_string), or NULL on error */
+char *thymos_agent_benchmark_ingest(
+    const ThymosAgent *handle,
+    const char *const *contents,
+    size_t count,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Pub/Sub
+ * ============================================================================ */
+
+/* Create an in-process pub/sub broker. Must free with thymos_broker_free */
+ThymosBroker *thymos_broker_new(ThymosError *out_error);
+
+/* Publish message to every subscription on topic. sender is the publishing
+ * agent's ID (NULL or "" for none). Returns 0 on success, -1 on error */
+int thymos_broker_publish(
+    const ThymosBroker *broker,
+    const char *sender,
+    const char *topic,
+    const char *message,
+    ThymosError *out_error
+);
+
+/* Subscribe to topic. Must free with thymos_subscription_free */
+ThymosSubscription *thymos_broker_subscribe(
+    const ThymosBroker *broker,
+    const char *topic,
+    ThymosError *out_error
+);
+
+/* Create a subscription with no topics, so one reader can wait on many.
+ * Must free with thymos_subscription_free */
+ThymosSubscription *thymos_subscription_new(void);
+
+/* Add topic on broker to a subscription. Returns the ID its messages carry
+ * in "subscription", 0 on error */
+uint64_t thymos_subscription_add(
+    const ThymosSubscription *subscription,
+    const ThymosBroker *broker,
+    const char *topic,
+    ThymosError *out_error
+);
+
+/* Stop delivering the topic added as id; messages already queued are still
+ * read. Returns 0 on success, -1 on error */
+int thymos_subscription_remove(
+    const ThymosSubscription *subscription,
+    uint64_t id,
+    ThymosError *out_error
+);
+
+/* Free a broker; existing subscriptions stay valid but go quiet */
+void thymos_broker_free(ThymosBroker *broker);
+
+/* Wait up to timeout_ms for the next message. On success *out_json holds
+ * {"subscription", "sender", "topic", "payload", "sent_at"} (must free with
+ * thymos_free_string).
+ * Returns 1 when a message was received, 0 on timeout, -1 on error */
+int thymos_subscription_next(
+    const ThymosSubscription *subscription,
+    uint64_t timeout_ms,
+    char **out_json,
+    ThymosError *out_error
+);
+
+/* Unsubscribe and free a subscription no longer used by any call */
+void thymos_subscription_free(ThymosSubscription *subscription);
+
+/* ============================================================================
+ * Memory Import
+ * ============================================================================ */
+
+/* Import a JSON array of {"content", "memory_type", "properties",
+ * "created_at", "last_accessed", "tags"} records (only content is required)
+ * as new, re-embedded memories. Nothing is stored if any record is invalid.
+ * *out_imported receives the number stored, also on error.
+ * Returns 0 on success, -1 on error */
+int thymos_agent_import_memories(
+    const ThymosAgent *handle,
+    const char *records_json,
+    size_t *out_imported,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Memory Export
+ * ============================================================================ */
+
+/* Open a cursor over the agent's local memories, fixed by one store snapshot
+ * (must free with thymos_memory_cursor_free). NULL on error */
+ThymosMemoryCursor *thymos_agent_memory_cursor(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Read the next page of up to limit (> 0) memories; an empty page means the
+ * cursor is exhausted. Memories forgotten or expired since the cursor was
+ * opened are skipped. Returns results (must free with
+ * thymos_free_search_results), or NULL on error */
+ThymosSearchResults *thymos_agent_memory_cursor_next(
+    const ThymosAgent *handle,
+    ThymosMemoryCursor *cursor,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* Free a memory cursor */
+void thymos_memory_cursor_free(ThymosMemoryCursor *cursor);
+
+/* ============================================================================
+ * Conversations
+ * ============================================================================ */
+
+/* Start a conversation. Returns an opaque conversation ID
+ * (must free with thymos_free_string) */
+char *thymos_agent_start_conversation(const ThymosAgent *handle, ThymosError *out_error);
+
+/* Store a conversation memory as the next turn of a conversation, tagged
+ * with "conversation_id" and "conversation_turn" properties.
+ * Returns memory ID (must free with thymos_free_string) */
+char *thymos_agent_remember_in_conversation(
+    const ThymosAgent *handle,
+    const char *conversation_id,
+    const char *content,
+    ThymosError *out_error
+);
+
+/* Get a conversation's memories in turn order; empty for unknown IDs
+ * (must free with thymos_free_search_results) */
+ThymosSearchResults *thymos_agent_conversation_history(
+    const ThymosAgent *handle,
+    const char *conversation_id,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Agent Stats
+ * ============================================================================ */
+
+/* Get storage and lifecycle metrics as a JSON object: total_memories,
+ * counts_by_type (type code -> count), average_strength, oldest, newest
+ * (RFC 3339 or null) and disk_bytes (must free with thymos_free_string) */
+char *thymos_agent_stats(const ThymosAgent *handle, ThymosError *out_error);
+
+/* ============================================================================
+ * Memory Expiry
+ * ============================================================================ */
+
+/* Store a memory that is hard-deleted once ttl_ms milliseconds have passed;
+ * it never appears in results once expired.
+ * Returns memory ID (must free with thymos_free_string) */
+char *thymos_agent_remember_with_ttl(
+    const ThymosAgent *handle,
+    const char *content,
+    uint64_t ttl_ms,
+    ThymosError *out_error
+);
+
+/* thymos_agent_remember_with_ttl, canceled like
+ * thymos_agent_remember_cancelable */
+char *thymos_agent_remember_with_ttl_cancelable(
+    const ThymosAgent *handle,
+    const char *content,
+    uint64_t ttl_ms,
+    const ThymosCancelToken *token,
+    ThymosError *out_error
+);
+
+/* Set an existing memory to expire ttl_ms milliseconds from now, or clear its
+ * TTL if ttl_ms is 0. Returns 0 on success, -1 on error */
+int thymos_agent_set_memory_ttl(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    uint64_t ttl_ms,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Tags
+ * ============================================================================ */
+
+/* Store a memory with tags (non-empty, matched exactly).
+ * Returns memory ID (must free with thymos_free_string) */
+char *thymos_agent_remember_with_tags(
+    const ThymosAgent *handle,
+    const char *content,
+    const char **tags,
+    size_t count,
+    ThymosError *out_error
+);
+
+/* thymos_agent_remember_with_tags, canceled like
+ * thymos_agent_remember_cancelable */
+char *thymos_agent_remember_with_tags_cancelable(
+    const ThymosAgent *handle,
+    const char *content,
+    const char **tags,
+    size_t count,
+    const ThymosCancelToken *token,
+    ThymosError *out_error
+);
+
+/* Find memories carrying all (match_all) or any of the tags, newest first.
+ * Searches the local store through a tag index; fails with
+ * THYMOS_ERROR_CONFIGURATION in server mode
+ * (must free with thymos_free_search_results) */
+ThymosSearchResults *thymos_agent_search_by_tags(
+    const ThymosAgent *handle,
+    const char **tags,
+    size_t count,
+    bool match_all,
+    size_t limit,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Summarization
+ * ============================================================================ */
+
+/* Summarize up to max_memories (0 = no limit) top matches for query with the
+ * agent's LLM provider and persona, also storing the summary as a fact if
+ * store is set. Returns JSON {"summary", "memory_id"} (must free with
+ * thymos_free_string); fails with THYMOS_ERROR_NO_LLM if no LLM provider is
+ * configured */
+char *thymos_agent_summarize(
+    const ThymosAgent *handle,
+    const char *query,
+    size_t max_memories,
+    bool store,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Embeddings
+ * ============================================================================ */
+
+/* Get a memory's stored embedding (the model's dimension). Returns NULL with
+ * no error if the memory has no embedding; fails with "memory not found" if
+ * there is no such memory. Must free with thymos_free_floats(data, *out_len) */
+float *thymos_agent_get_embedding(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    size_t *out_len,
+    ThymosError *out_error
+);
+
+/* Embed text with the agent's provider without storing it. Fails with
+ * THYMOS_ERROR_CONFIGURATION if there is no embedding provider.
+ * Must free with thymos_free_floats(data, *out_len) */
+float *thymos_agent_embed_text(
+    const ThymosAgent *handle,
+    const char *text,
+    size_t *out_len,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Clearing
+ * ============================================================================ */
+
+/* Delete every memory, keeping the agent, its config and its state. On error
+ * the deleted memories are stored again, best-effort: the error says how many
+ * could not be. Returns count deleted, -1 on error */
+int64_t thymos_agent_clear(const ThymosAgent *handle, ThymosError *out_error);
+
+/* ============================================================================
+ * Logging
+ * ============================================================================ */
+
+/* Receives native log records. level: 0 = error, 1 = warn, 2 = info,
+ * 3 = debug, 4 = trace. message is only valid during the call */
+typedef void (*ThymosLogCallback)(int level, const char *message);
+
+/* Forward internal logs to callback (NULL stops forwarding); filter them with
+ * the THYMOS_LOG environment variable. The callback may run on any thread and
+ * must not call back into Thymos. Fails with THYMOS_ERROR_CONFIGURATION if
+ * the process already has a global tracing subscriber.
+ * Returns 0 on success, -1 on error */
+int thymos_set_log_callback(ThymosLogCallback callback, ThymosError *out_error);
+
+/* ============================================================================
+ * Scope Changes
+ * ============================================================================ */
+
+/* Move a private memory to the shared backend (hybrid mode only), keeping
+ * its ID, tags, properties and embedding. Returns 0 on success, -1 on error,
+ * with THYMOS_ERROR_NOT_FOUND if neither backend has the memory */
+int thymos_agent_promote_to_shared(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    ThymosError *out_error
+);
+
+/* Move a shared memory to the private backend, keeping its ID (hybrid mode
+ * only). Returns 0 on success, -1 on error */
+int thymos_agent_demote_to_private(
+    const ThymosAgent *handle,
+    const char *memory_id,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Idempotent Writes
+ * ============================================================================ */
+
+/* Store a general memory once per key: if a memory stored under key still
+ * exists its ID is returned and content ignored. Returns the memory ID
+ * (must free with thymos_free_string), or NULL on error */
+char *thymos_agent_remember_idempotent(
+    const ThymosAgent *handle,
+    const char *key,
+    const char *content,
+    ThymosError *out_error
+);
+
+/* ============================================================================
+ * Deduplication
+ * ============================================================================ */
+
+/* Find clusters of memories whose pairwise similarity is at least threshold
+ * (0 < threshold <= 1): embedding cosine similarity where available, term
+ * overlap otherwise. Results are flattened, largest cluster first and oldest
+ * member first; *out_keys receives the ID of each result's oldest cluster
+ * member. limit caps the clusters returned (0 = no limit). Free the results
+ * with thymos_free_search_results and *out_keys with
+ * thymos_free_string_list */
+ThymosSearchResults *thymos_agent_find_duplicates(
+    const ThymosAgent *handle,
+    double threshold,
+    size_t limit,
+    ThymosStringList **out_keys,
+    ThymosError *out_error
+);
+
+/* Forget all but the oldest memory of each duplicate cluster (see
+ * thymos_agent_find_duplicates). Forgotten memories are recoverable until
+ * the grace period elapses. Returns the number forgotten, or -1 on error */
+int64_t thymos_agent_deduplicate(
+    const ThymosAgent *handle,
+    double threshold,
+    ThymosError *out_error
 );
 
 /* ============================================================================
@@ -13,8 +13,17 @@ else
     LIB_NAME="libthymos_go.so"
 fi
 
-# Try release first, then debug
-if [ -f "$WORKSPACE_ROOT/target/release/$LIB_NAME" ]; then
+# Honor THYMOS_LIB_DIR, then try release, then debug
+GO_TAGS=""
+if [ -n "$THYMOS_LIB_DIR" ]; then
+    if [ ! -f "$THYMOS_LIB_DIR/$LIB_NAME" ]; then
+        echo "Error: $LIB_NAME not found in THYMOS_LIB_DIR ($THYMOS_LIB_DIR)."
+        exit 1
+    fi
+    LIB_DIR="$THYMOS_LIB_DIR"
+    GO_TAGS="thymos_lib_dir"
+    export CGO_LDFLAGS="-L$LIB_DIR -Wl,-rpath,$LIB_DIR $CGO_LDFLAGS"
+elif [ -f "$WORKSPACE_ROOT/target/release/$LIB_NAME" ]; then
     LIB_DIR="$WORKSPACE_ROOT/target/release"
 elif [ -f "$WORKSPACE_ROOT/target/debug/$LIB_NAME" ]; then
     LIB_DIR="$WORKSPACE_ROOT/target/debug"
@@ -31,4 +40,4 @@ echo "Using library from: $LIB_DIR"
 echo ""
 
 cd "$SCRIPT_DIR"
-go run ./go/example
+go run -tags "$GO_TAGS" ./go/example
@@ -0,0 +1,253 @@
+//! Ranking formula parser for reranked search.
+//!
+//! Formulas are small arithmetic expressions over per-candidate variables,
+//! e.g. `0.7*score + 0.3*recency`. Supported syntax: numeric literals, the
+//! variables `score`, `recency`, `retention` and `access_count`, the binary
+//! operators `+ - * /`, unary minus, and parentheses.
+
+/// Per-candidate values a formula can reference.
+#[derive(Debug, Clone, Copy, Default)]
+pub struct ScoreVars {
+    pub score: f64,
+    pub recency: f64,
+    pub retention: f64,
+    pub access_count: f64,
+}
+
+#[derive(Debug, Clone, Copy)]
+pub enum Var {
+    Score,
+    Recency,
+    Retention,
+    AccessCount,
+}
+
+#[derive(Debug, Clone, Copy)]
+pub enum Op {
+    Add,
+    Sub,
+    Mul,
+    Div,
+}
+
+/// Parsed ranking formula.
+#[derive(Debug)]
+pub enum Formula {
+    Num(f64),
+    Var(Var),
+    Neg(Box<Formula>),
+    Bin(Box<Formula>, Op, Box<Formula>),
+}
+
+impl Formula {
+    /// Parse a formula, returning a message with the offending position on error.
+    pub fn parse(input: &str) -> Result<Self, String> {
+        let mut parser = Parser { input, pos: 0 };
+        let formula = parser.expr()?;
+        parser.skip_whitespace();
+        if parser.pos < input.len() {
+            return Err(format!(
+                "unexpected '{}' at position {}",
+                &input[parser.pos..],
+                parser.pos
+            ));
+        }
+        Ok(formula)
+    }
+
+    /// Evaluate the formula. Division by zero yields 0.
+    pub fn eval(&self, vars: &ScoreVars) -> f64 {
+        match self {
+            Self::Num(n) => *n,
+            Self::Var(Var::Score) => vars.score,
+            Self::Var(Var::Recency) => vars.recency,
+            Self::Var(Var::Retention) => vars.retention,
+            Self::Var(Var::AccessCount) => vars.access_count,
+            Self::Neg(inner) => -inner.eval(vars),
+            Self::Bin(lhs, op, rhs) => {
+                let (a, b) = (lhs.eval(vars), rhs.eval(vars));
+                match op {
+                    Op::Add => a + b,
+                    Op::Sub => a - b,
+                    Op::Mul => a * b,
+                    Op::Div if b == 0.0 => 0.0,
+                    Op::Div => a / b,
+                }
+            }
+        }
+    }
+}
+
+struct Parser<'a> {
+    input: &'a str,
+    pos: usize,
+}
+
+impl Parser<'_> {
+    fn skip_whitespace(&mut self) {
+        while self.peek().is_some_and(|c| c.is_ascii_whitespace()) {
+            self.pos += 1;
+        }
+    }
+
+    fn peek(&self) -> Option<char> {
+        self.input[self.pos..].chars().next()
+    }
+
+    fn eat(&mut self, c: char) -> bool {
+        self.skip_whitespace();
+        if self.peek() == Some(c) {
+            self.pos += 1;
+            true
+        } else {
+            false
+        }
+    }
+
+    // expr := term (('+' | '-') term)*
+    fn expr(&mut self) -> Result<Formula, String> {
+        let mut lhs = self.term()?;
+        loop {
+            let op = if self.eat('+') {
+                Op::Add
+            } else if self.eat('-') {
+                Op::Sub
+            } else {
+                return Ok(lhs);
+            };
+            lhs = Formula::Bin(Box::new(lhs), op, Box::new(self.term()?));
+        }
+    }
+
+    // term := factor (('*' | '/') factor)*
+    fn term(&mut self) -> Result<Formula, String> {
+        let mut lhs = self.factor()?;
+        loop {
+            let op = if self.eat('*') {
+                Op::Mul
+            } else if self.eat('/') {
+                Op::Div
+            } else {
+                return Ok(lhs);
+            };
+            lhs = Formula::Bin(Box::new(lhs), op, Box::new(self.factor()?));
+        }
+    }
+
+    // factor := '-' factor | '(' expr ')' | number | variable
+    fn factor(&mut self) -> Result<Formula, String> {
+        if self.eat('-') {
+            return Ok(Formula::Neg(Box::new(self.factor()?)));
+        }
+
+        if self.eat('(') {
+            let inner = self.expr()?;
+            if !self.eat(')') {
+                return Err(format!("expected ')' at position {}", self.pos));
+            }
+            return Ok(inner);
+        }
+
+        self.skip_whitespace();
+        let start = self.pos;
+        match self.peek() {
+            Some(c) if c.is_ascii_digit() || c == '.' => {
+                while self.peek().is_some_and(|c| c.is_ascii_digit() || c == '.') {
+                    self.pos += 1;
+                }
+                self.input[start..self.pos]
+                    .parse()
+                    .map(Formula::Num)
+                    .map_err(|_| format!("invalid number at position {}", start))
+            }
+            Some(c) if c.is_ascii_alphabetic() || c == '_' => {
+                while self.peek().is_some_and(|c| c.is_ascii_alphanumeric() || c == '_') {
+                    self.pos += 1;
+                }
+                match &self.input[start..self.pos] {
+                    "score" => Ok(Formula::Var(Var::Score)),
+                    "recency" => Ok(Formula::Var(Var::Recency)),
+                    "retention" => Ok(Formula::Var(Var::Retention)),
+                    "access_count" => Ok(Formula::Var(Var::AccessCount)),
+                    name => Err(format!(
+                        "unknown variable '{}' at position {} (expected score, recency, retention or access_count)",
+                        name, start
+                    )),
+                }
+            }
+            Some(c) => Err(format!("unexpected '{}' at position {}", c, start)),
+            None => Err("unexpected end of formula".to_string()),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn vars() -> ScoreVars {
+        ScoreVars {
+            score: 0.8,
+            recency: 0.5,
+            retention: 0.25,
+            access_count: 4.0,
+        }
+    }
+
+    #[test]
+    fn test_eval() {
+        let cases = [
+            ("score", 0.8),
+            ("0.7*score + 0.3*recency", 0.7 * 0.8 + 0.3 * 0.5),
+            ("1 + 2 * 3", 7.0),
+            ("(1 + 2) * 3", 9.0),
+            ("10 - 4 - 3", 3.0),
+            ("8 / 4 / 2", 1.0),
+            ("-score", -0.8),
+            ("--2", 2.0),
+            ("retention * access_count", 1.0),
+            (" score*( recency+1 ) ", 0.8 * 1.5),
+            (".5", 0.5),
+        ];
+        for (input, want) in cases {
+            let formula = Formula::parse(input).unwrap_or_else(|e| panic!("{}: {}", input, e));
+            let got = formula.eval(&vars());
+            assert!(
+                (got - want).abs() < 1e-12,
+                "{} = {}, want {}",
+                input,
+                got,
+                want
+            );
+        }
+    }
+
+    #[test]
+    fn test_division_by_zero_is_zero() {
+        let formula = Formula::parse("score / (recency - 0.5)").unwrap();
+        assert_eq!(formula.eval(&vars()), 0.0);
+    }
+
+    #[test]
+    fn test_parse_errors() {
+        let cases = [
+            ("", "unexpected end of formula"),
+            ("score +", "unexpected end of formula"),
+            (
+                "relevance",
+                "unknown variable 'relevance' at position 0 (expected score, recency, retention or access_count)",
+            ),
+            ("(score", "expected ')' at position 6"),
+            ("score score", "unexpected 'score' at position 6"),
+            ("1..2", "invalid number at position 0"),
+            ("score % 2", "unexpected '% 2' at position 6"),
+            ("2 * #", "unexpected '#' at position 4"),
+        ];
+        for (input, want) in cases {
+            match Formula::parse(input) {
+                Ok(formula) => panic!("{} parsed as {:?}, want error {}", input, formula, want),
+                Err(e) => assert_eq!(e, want, "error for {}", input),
+            }
+        }
+    }
+}
@@ -11,68 +11,170 @@
 //!
 //! ## Error Handling
 //!
-//! Functions that can fail return null pointers on error. Use `thymos_get_last_error()`
-//! to retrieve the error message.
+//! Functions that can fail return null pointers (or -1, or 0 where documented)
+//! on error and take a trailing `out_error: *mut ThymosError` that receives
+//! the failure's code and message. Errors are reported to the call that
+//! failed, so concurrent callers never see each other's errors.
 //!
 //! ## Thread Safety
 //!
 //! All functions are thread-safe and can be called from multiple goroutines.
+//! Agent functions take `*const ThymosAgent` and may run concurrently on the
+//! same handle: agent state lives behind the core agent's async locks and the
+//! handle's own caches and indexes behind mutexes. Updates that read a
+//! memory and write it back (`thymos_agent_update_memory`,
+//! `thymos_agent_set_memory_ttl`, `thymos_agent_reinforce` and
+//! `thymos_agent_embed_pending`) hold a per-memory lock, so concurrent
+//! updates of one memory are applied one after the other rather than one
+//! overwriting the other. Only `thymos_free_agent` needs exclusive access,
+//! and callers must not free a handle while other calls on it are in flight.
 
 #![allow(unsafe_op_in_unsafe_fn)]
 #![allow(clippy::not_unsafe_ptr_arg_deref)]
 
+mod formula;
+
 use once_cell::sync::Lazy;
+use serde::{Deserialize, Serialize};
+use formula::{Formula, ScoreVars};
+use sha2::{Digest, Sha256};
+use std::collections::{BTreeMap, BTreeSet, HashMap, HashSet, VecDeque};
 use std::ffi::{CStr, CString};
+use std::hash::{Hash, Hasher};
 use std::os::raw::{c_char, c_int};
 use std::path::PathBuf;
 use std::ptr;
 use std::sync::mpsc;
-use std::sync::Mutex;
+use std::sync::{Arc, Mutex};
+use std::time::{Duration, Instant};
 use thymos_core::agent::{Agent, AgentState, AgentStatus};
-use thymos_core::config::{MemoryConfig, MemoryMode, ThymosConfig};
-use thymos_core::error::{Result, ThymosError};
+use thymos_core::concepts::{BasicConceptExtractor, Concept, ConceptExtractionConfig, ConceptExtractor};
+use thymos_core::config::{
+    EmbeddingProvider, EmbeddingsConfig, MemoryConfig, MemoryMode, ThymosConfig,
+};
+use thymos_core::consolidation::{ConsolidationConfig, ConsolidationEngine};
+use thymos_core::error::{Result, ThymosError as CoreError};
+use thymos_core::memory::{MemorySystem, RememberOptions, SearchScope};
+use thymos_core::pubsub::{PubSub, PubSubBuilder, PubSubInstance, SubscriptionHandle};
 
 // ============================================================================
 // Error Handling
 // ============================================================================
 
+// Error codes reported in `ThymosError::code`.
+const ERROR_NONE: c_int = 0;
+const ERROR_INTERNAL: c_int = 1;
+const ERROR_INVALID_ARGUMENT: c_int = 2;
+const ERROR_STORAGE: c_int = 3;
+const ERROR_NOT_FOUND: c_int = 4;
+const ERROR_NOT_HYBRID: c_int = 5;
+const ERROR_CONFIGURATION: c_int = 6;
+const ERROR_CANCELED: c_int = 7;
+const ERROR_READ_ONLY: c_int = 8;
+const ERROR_NO_LLM: c_int = 9;
+
+// The error recorded by the current call. FFI calls run on their caller's
+// thread, so the error is recorded here while the call runs and moved into
+// the call's `out_error` by its `ErrorOut` when it returns.
 thread_local! {
     static LAST_ERROR: std::cell::RefCell<Option<CString>> = const { std::cell::RefCell::new(None) };
+    static LAST_ERROR_CODE: std::cell::Cell<c_int> = const { std::cell::Cell::new(ERROR_NONE) };
 }
 
+/// Record a usage error (invalid argument).
 fn set_error(message: impl Into<String>) {
+    set_error_with_code(ERROR_INVALID_ARGUMENT, message);
+}
+
+/// Record an error with an explicit code.
+fn set_error_with_code(code: c_int, message: impl Into<String>) {
     LAST_ERROR.with(|e| {
         *e.borrow_mut() = Some(
             CString::new(message.into())
                 .unwrap_or_else(|_| CString::new("Failed to create error message").unwrap()),
         );
     });
+    LAST_ERROR_CODE.with(|c| c.set(code));
+}
+
+/// Record a Thymos error, deriving its code from the variant.
+fn set_thymos_error(error: &CoreError) {
+    let code = match error {
+        CoreError::Storage(_)
+        | CoreError::Io(_)
+        | CoreError::Memory(_)
+        | CoreError::MemoryInit(_) => ERROR_STORAGE,
+        CoreError::NotHybrid(_) => ERROR_NOT_HYBRID,
+        CoreError::Configuration(_) => ERROR_CONFIGURATION,
+        CoreError::InvalidContext(_) | CoreError::Serialization(_) => ERROR_INVALID_ARGUMENT,
+        CoreError::AgentNotFound(_) => ERROR_NOT_FOUND,
+        _ => ERROR_INTERNAL,
+    };
+    set_error_with_code(code, error.to_string());
+}
+
+/// Record a read-only error and return false if `agent` was opened read-only.
+fn check_writable(agent: &ThymosAgent) -> bool {
+    if agent.options.read_only {
+        set_error_with_code(ERROR_READ_ONLY, "Agent is read-only");
+        return false;
+    }
+    true
 }
 
 
-/// Get the last error message.
+/// Error reported by a failed FFI call.
 ///
-/// Returns a pointer to the error message string, or null if no error occurred.
-/// The returned pointer is valid until the next FFI call that may set an error.
+/// Pass a pointer to one as a function's `out_error`; it is zeroed when the
+/// call starts and, if the call fails, receives the error's code (see the
+/// `ERROR_*` constants) and message. Storage errors may be transient; the
+/// others are not worth retrying. The message must be freed with
+/// `thymos_free_string`. `out_error` may be null to ignore the error.
+#[repr(C)]
+pub struct ThymosError {
+    pub code: c_int,
+    pub message: *mut c_char,
+}
+
+/// Moves the error recorded during an FFI call into its `out_error` when
+/// dropped.
 ///
-/// # Safety
-/// The returned pointer must not be freed by the caller.
-#[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_get_last_error() -> *const c_char {
-    LAST_ERROR.with(|e| {
-        e.borrow()
-            .as_ref()
-            .map(|s| s.as_ptr())
-            .unwrap_or(ptr::null())
-    })
+/// Every function taking `out_error` creates one first. Nested calls may
+/// pass their caller's `out_error` on: the innermost call to fail reports,
+/// and callers that record no error of their own leave its report in place.
+struct ErrorOut(*mut ThymosError);
+
+impl ErrorOut {
+    unsafe fn new(out_error: *mut ThymosError) -> Self {
+        if !out_error.is_null() {
+            LAST_ERROR.with(|e| *e.borrow_mut() = None);
+            LAST_ERROR_CODE.with(|c| c.set(ERROR_NONE));
+            *out_error = ThymosError {
+                code: ERROR_NONE,
+                message: ptr::null_mut(),
+            };
+        }
+        ErrorOut(out_error)
+    }
 }
 
-/// Clear the last error.
-#[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_clear_error() {
-    LAST_ERROR.with(|e| {
-        *e.borrow_mut() = None;
-    });
+impl Drop for ErrorOut {
+    fn drop(&mut self) {
+        if self.0.is_null() {
+            return;
+        }
+        if let Some(message) = LAST_ERROR.with(|e| e.borrow_mut().take()) {
+            let code = LAST_ERROR_CODE.with(|c| c.replace(ERROR_NONE));
+            // SAFETY: `new` checked the pointer, which the caller keeps valid
+            // for the duration of the call.
+            unsafe {
+                *self.0 = ThymosError {
+                    code,
+                    message: message.into_raw(),
+                };
+            }
+        }
+    }
 }
 
 // ============================================================================
@@ -102,9 +204,8 @@ where
         let _ = tx.send(result);
     });
 
-    rx.recv().map_err(|_| {
-        ThymosError::Configuration("Failed to receive result from async task".to_string())
-    })?
+    rx.recv()
+        .map_err(|_| CoreError::Other("Failed to receive result from async task".to_string()))?
 }
 
 fn block_on_value<F, T>(future: F) -> T
@@ -143,6 +244,19 @@ fn string_to_cstring(s: String) -> *mut c_char {
 }
 
 
+/// Read an array of C strings.
+///
+/// Returns None if any entry is null or not valid UTF-8.
+unsafe fn cstr_array_to_vec(ptr: *const *const c_char, count: usize) -> Option<Vec<String>> {
+    if count == 0 {
+        return Some(Vec::new());
+    }
+    if ptr.is_null() {
+        return None;
+    }
+    (0..count).map(|i| cstr_to_string(*ptr.add(i))).collect()
+}
+
 /// Free a string allocated by Thymos.
 ///
 /// # Safety
@@ -162,12 +276,617 @@ pub unsafe extern "C" fn thymos_free_string(s: *mut c_char) {
 #[repr(C)]
 pub struct ThymosAgent {
     inner: Agent,
+    options: AgentOptions,
+    forgotten: Arc<Mutex<Vec<ForgottenMemory>>>,
+    access_counts: Arc<Mutex<HashMap<String, u64>>>,
+    count_samples: Option<Arc<Mutex<VecDeque<CountSample>>>>,
+    count_sampler: Option<tokio::task::JoinHandle<()>>,
+    query_cache: Option<Mutex<QueryCache>>,
+    /// Memory configuration the agent was built with
+    memory_config: MemoryConfig,
+    /// Embedding model named in the configuration the agent was built with
+    embedding_model: Option<String>,
+    /// Local storage directory (None in server mode)
+    data_dir: Option<PathBuf>,
+    access_timelines: Option<Mutex<HashMap<String, VecDeque<chrono::DateTime<chrono::Utc>>>>>,
+    concept_index: Mutex<HashMap<String, IndexedConcepts>>,
+    concept_rebuild: Mutex<Option<ConceptRebuild>>,
+    /// Last turn number stored in each known conversation
+    conversation_turns: Mutex<HashMap<String, u64>>,
+    /// Memory ID stored under each idempotency key, loaded on first use
+    idempotency_keys: Mutex<Option<HashMap<String, String>>>,
+    /// IDs of the local memories carrying each tag, loaded on first use
+    tag_index: Mutex<Option<HashMap<String, HashSet<String>>>>,
+    /// Serializes read-modify-write updates of a memory
+    memory_locks: Arc<MemoryLocks>,
+    /// Local store size and last threshold sweep, for `enforce_retention`
+    retention: Mutex<RetentionState>,
+    /// Creation order of the local memories, for `thymos_agent_list_memories`
+    creation_index: Mutex<CreationIndex>,
+    /// Modification order of the local memories, for
+    /// `thymos_agent_modified_since`
+    modification_index: Mutex<ModificationIndex>,
+    /// IDs of memories changed through this handle since the modification
+    /// index last synced
+    changed_ids: Arc<Mutex<HashSet<String>>>,
+    /// Expiry time of every memory with a TTL, by memory ID
+    expiries: Arc<Mutex<HashMap<String, chrono::DateTime<chrono::Utc>>>>,
+    /// Deletes expired memories; started on demand by `ensure_expiry_sweeper`
+    expiry_sweeper: Mutex<Option<tokio::task::JoinHandle<()>>>,
+    /// IDs of the memories a hybrid agent last fetched from its shared
+    /// backend, which `memory_scopes` reports as shared
+    shared_ids: Arc<Mutex<HashSet<String>>>,
+}
+
+/// Maximum number of access timestamps retained per memory.
+const MAX_TIMELINE_ENTRIES: usize = 10_000;
+
+/// Minimum time between checks of the forget threshold after inserts.
+const RETENTION_SWEEP_INTERVAL: Duration = Duration::from_secs(60);
+
+/// What `enforce_retention` remembers between inserts.
+#[derive(Default)]
+struct RetentionState {
+    /// Memories in the local store; None until first counted
+    count: Option<usize>,
+    /// When memories were last checked against the forget threshold
+    last_sweep: Option<Instant>,
+}
+
+impl ThymosAgent {
+    fn new(
+        agent: Agent,
+        options: AgentOptions,
+        memory_config: MemoryConfig,
+        embedding_model: Option<String>,
+    ) -> Self {
+        let (count_samples, count_sampler) = match options.count_sample_interval {
+            Some(interval) => {
+                let samples = Arc::new(Mutex::new(VecDeque::new()));
+                let sampler = start_count_sampler(agent.clone(), samples.clone(), interval);
+                (Some(samples), Some(sampler))
+            }
+            None => (None, None),
+        };
+        let query_cache = options
+            .query_cache
+            .map(|(capacity, ttl)| Mutex::new(QueryCache::new(capacity, ttl)));
+        let access_timelines = options
+            .track_access_timeline
+            .then(|| Mutex::new(HashMap::new()));
+        let access_counts = Arc::new(Mutex::new(HashMap::new()));
+        let expiries = Arc::new(Mutex::new(HashMap::new()));
+
+        Self {
+            inner: agent,
+            options,
+            forgotten: Arc::new(Mutex::new(Vec::new())),
+            access_counts,
+            count_samples,
+            count_sampler,
+            query_cache,
+            data_dir: local_data_dir(&memory_config),
+            memory_config,
+            embedding_model,
+            access_timelines,
+            concept_index: Mutex::new(HashMap::new()),
+            concept_rebuild: Mutex::new(None),
+            conversation_turns: Mutex::new(HashMap::new()),
+            idempotency_keys: Mutex::new(None),
+            tag_index: Mutex::new(None),
+            memory_locks: Arc::new(MemoryLocks::new()),
+            retention: Mutex::new(RetentionState::default()),
+            creation_index: Mutex::new(CreationIndex::default()),
+            modification_index: Mutex::new(ModificationIndex::default()),
+            changed_ids: Arc::new(Mutex::new(HashSet::new())),
+            expiries,
+            expiry_sweeper: Mutex::new(None),
+            shared_ids: Arc::new(Mutex::new(HashSet::new())),
+        }
+    }
+
+    /// Drop all cached search results after a write.
+    fn invalidate_query_cache(&self) {
+        if let Some(cache) = &self.query_cache {
+            cache.lock().unwrap().invalidate();
+        }
+    }
+
+    /// Start the expiry sweeper unless it is running or the agent is
+    /// read-only.
+    ///
+    /// Agents that never use TTLs never scan their store for expiry times:
+    /// the sweeper starts on the first TTL stored or set through this handle,
+    /// or on the first expired memory dropped from results, which covers TTLs
+    /// persisted by earlier processes.
+    fn ensure_expiry_sweeper(&self) {
+        if self.options.read_only {
+            return;
+        }
+        let mut sweeper = self.expiry_sweeper.lock().unwrap();
+        if sweeper.is_none() {
+            *sweeper = Some(start_expiry_sweeper(
+                self.inner.clone(),
+                self.expiries.clone(),
+                self.access_counts.clone(),
+            ));
+        }
+    }
+
+    /// Drop the tag index after a write that may have stored tagged memories
+    /// in bulk; the next tag search loads it again.
+    fn invalidate_tag_index(&self) {
+        *self.tag_index.lock().unwrap() = None;
+    }
+
+    /// Apply the configured memory capacity and forget threshold after
+    /// `inserted` memories were added to the local store, or an unknown number
+    /// if None, in which case the store is recounted.
+    ///
+    /// The store's size is carried from insert to insert, so its memories are
+    /// only loaded once it exceeds capacity, and then only the weakest beyond
+    /// capacity are forgotten. Memories below the threshold are looked for at
+    /// most once per `RETENTION_SWEEP_INTERVAL`. Only local memories are ever
+    /// forgotten, so writes to the shared backend pass `Some(0)`. Forgotten
+    /// memories stay recoverable for the forget grace period. Enforcement is
+    /// best-effort: a failure here does not undo the insert that triggered it.
+    fn enforce_retention(&self, inserted: Option<usize>) {
+        let threshold = self.options.forget_threshold;
+        let capacity = self.options.max_memories;
+        if self.options.read_only
+            || inserted == Some(0)
+            || (threshold.is_none() && capacity.is_none())
+        {
+            return;
+        }
+
+        // Held throughout so concurrent inserts don't evict for the same
+        // overflow twice.
+        let mut state = self.retention.lock().unwrap();
+        let count = match (state.count, inserted) {
+            (Some(count), Some(inserted)) => count + inserted,
+            _ => {
+                let agent = self.inner.clone();
+                match block_on(async move { count_memories(&agent).await }) {
+                    Ok(count) => count,
+                    Err(_) => return,
+                }
+            }
+        };
+        state.count = Some(count);
+
+        let over_capacity = capacity.is_some_and(|c| count > c);
+        let sweep = threshold.filter(|_| {
+            state
+                .last_sweep
+                .is_none_or(|at| at.elapsed() >= RETENTION_SWEEP_INTERVAL)
+        });
+        if !over_capacity && sweep.is_none() {
+            return;
+        }
+        if sweep.is_some() {
+            state.last_sweep = Some(Instant::now());
+        }
+
+        let agent = self.inner.clone();
+        let forgotten = self.forgotten.clone();
+        let grace = self.options.forget_grace_period;
+        let result = block_on(async move {
+            let mut scored: Vec<_> = load_all_memories(&agent)
+                .await?
+                .into_iter()
+                .map(|memory| (agent.memory().calculate_strength(&memory), memory))
+                .collect();
+            let total = scored.len();
+
+            let mut evicted = Vec::new();
+            if let Some(capacity) = capacity.filter(|c| total > *c) {
+                // Strongest first; newer memories win ties.
+                scored.select_nth_unstable_by(capacity, |a, b| {
+                    b.0.total_cmp(&a.0)
+                        .then_with(|| b.1.created_at.cmp(&a.1.created_at))
+                });
+                evicted.extend(scored.drain(capacity..));
+            }
+            if let Some(threshold) = sweep {
+                evicted.extend(
+                    scored
+                        .into_iter()
+                        .filter(|(strength, _)| *strength < threshold),
+                );
+            }
+            let evicted = evicted.into_iter().map(|(_, memory)| memory).collect();
+            let forgotten = forget_memories(&agent, &forgotten, grace, evicted).await?;
+            Ok::<_, CoreError>((total, forgotten as usize))
+        });
+        match result {
+            Ok((total, forgotten)) => {
+                state.count = Some(total - forgotten);
+                drop(state);
+                if forgotten > 0 {
+                    self.invalidate_query_cache();
+                }
+            }
+            Err(_) => state.count = None,
+        }
+    }
+
+    /// Count a retrieval of each memory through this handle.
+    fn record_access(&self, memories: &[locai::models::Memory]) {
+        let mut counts = self.access_counts.lock().unwrap();
+        for memory in memories {
+            *counts.entry(memory.id.clone()).or_insert(0) += 1;
+        }
+
+        if let Some(timelines) = &self.access_timelines {
+            let now = chrono::Utc::now();
+            let mut timelines = timelines.lock().unwrap();
+            for memory in memories {
+                let timeline = timelines.entry(memory.id.clone()).or_default();
+                if timeline.len() >= MAX_TIMELINE_ENTRIES {
+                    timeline.pop_front();
+                }
+                timeline.push_back(now);
+            }
+        }
+    }
+
+    /// Drop expired search results and those scored below `min_score`, then
+    /// truncate them to `limit`, keeping search order.
+    ///
+    /// Expired memories go before the limit is applied, so a full page is
+    /// returned while enough live results remain.
+    fn live_ranked(
+        &self,
+        memories: Vec<locai::models::Memory>,
+        scores: Vec<f64>,
+        limit: usize,
+        min_score: f64,
+    ) -> ScoredMemories {
+        let now = chrono::Utc::now();
+        let mut expired = false;
+        let live = memories
+            .into_iter()
+            .zip(scores)
+            .filter(|(memory, _)| {
+                let alive = !memory_expired(memory, now);
+                expired |= !alive;
+                alive
+            })
+            .filter(|(_, score)| *score >= min_score)
+            .take(if limit > 0 { limit } else { usize::MAX })
+            .unzip();
+        if expired {
+            self.ensure_expiry_sweeper();
+        }
+        live
+    }
+
+    /// Box memories as search results for the caller to free.
+    fn results(&self, memories: &[locai::models::Memory]) -> *mut ThymosSearchResults {
+        self.scored_results(memories, &[])
+    }
+
+    /// Box memories carrying the parallel `scores` as search results.
+    fn scored_results(
+        &self,
+        memories: &[locai::models::Memory],
+        scores: &[f64],
+    ) -> *mut ThymosSearchResults {
+        let now = chrono::Utc::now();
+        if !memories.iter().any(|m| memory_expired(m, now)) {
+            return self.checked_results(memories, scores);
+        }
+
+        // Drop memories whose TTL passed before the sweeper deleted them
+        self.ensure_expiry_sweeper();
+        let live: Vec<usize> = (0..memories.len())
+            .filter(|&i| !memory_expired(&memories[i], now))
+            .collect();
+        let memories: Vec<_> = live.iter().map(|&i| memories[i].clone()).collect();
+        let scores: Vec<_> = live
+            .iter()
+            .filter_map(|&i| scores.get(i).copied())
+            .collect();
+        self.checked_results(&memories, &scores)
+    }
+
+    /// Convert memories already checked for expiry, such as results paired
+    /// with a parallel output array that dropping entries would misalign.
+    fn checked_results(
+        &self,
+        memories: &[locai::models::Memory],
+        scores: &[f64],
+    ) -> *mut ThymosSearchResults {
+        self.scoped_results(memories, scores, &[])
+    }
+
+    /// Convert memories already checked for expiry, carrying the parallel
+    /// `scores` and `scopes`.
+    fn scoped_results(
+        &self,
+        memories: &[locai::models::Memory],
+        scores: &[f64],
+        scopes: &[c_int],
+    ) -> *mut ThymosSearchResults {
+        let looked_up;
+        let scopes = if scopes.is_empty() {
+            looked_up = self.memory_scopes(memories);
+            &looked_up
+        } else {
+            scopes
+        };
+        // Copy only the results' counts rather than the whole map
+        let access_counts: HashMap<String, u64> = {
+            let counts = self.access_counts.lock().unwrap();
+            memories
+                .iter()
+                .filter_map(|m| Some((m.id.clone(), *counts.get(&m.id)?)))
+                .collect()
+        };
+        let results =
+            ThymosSearchResults::from_scored(&self.inner, memories, scores, scopes, &access_counts);
+        Box::into_raw(Box::new(results))
+    }
+
+    /// Resolve the backend each memory lives in.
+    ///
+    /// Agents outside hybrid mode have a single store, so every memory is
+    /// `SCOPE_DEFAULT`. A hybrid agent's memories are shared if it last
+    /// fetched them from its shared backend (see `search_noting_shared` and
+    /// `get_noting_shared`) and private otherwise, since every other read
+    /// goes to the private store.
+    fn memory_scopes(&self, memories: &[locai::models::Memory]) -> Vec<c_int> {
+        if !self.inner.memory().is_hybrid() {
+            return vec![SCOPE_DEFAULT; memories.len()];
+        }
+        let shared_ids = self.shared_ids.lock().unwrap();
+        memories
+            .iter()
+            .map(|m| {
+                if shared_ids.contains(&m.id) {
+                    SCOPE_SHARED
+                } else {
+                    SCOPE_PRIVATE
+                }
+            })
+            .collect()
+    }
+}
+
+impl Drop for ThymosAgent {
+    fn drop(&mut self) {
+        if let Some(sampler) = self.count_sampler.take() {
+            sampler.abort();
+        }
+        if let Some(sweeper) = self.expiry_sweeper.get_mut().unwrap().take() {
+            sweeper.abort();
+        }
+    }
+}
+
+/// Number of lock stripes in `MemoryLocks`.
+const MEMORY_LOCK_STRIPES: usize = 64;
+
+/// Striped locks for updates that read a memory, change it and write it back.
+///
+/// Two such updates of one memory running at once would each write back
+/// their own copy, losing the other's change, so each holds the lock for the
+/// memory's ID from the read to the write. Memories are spread over a fixed
+/// set of stripes, so unrelated updates only occasionally wait on each other.
+struct MemoryLocks {
+    stripes: Vec<tokio::sync::Mutex<()>>,
+}
+
+impl MemoryLocks {
+    fn new() -> Self {
+        Self {
+            stripes: (0..MEMORY_LOCK_STRIPES)
+                .map(|_| tokio::sync::Mutex::new(()))
+                .collect(),
+        }
+    }
+
+    /// Wait for the lock of the memory with ID `id`.
+    async fn lock(&self, id: &str) -> tokio::sync::MutexGuard<'_, ()> {
+        let mut hasher = std::collections::hash_map::DefaultHasher::new();
+        id.hash(&mut hasher);
+        let stripe = hasher.finish() as usize % self.stripes.len();
+        self.stripes[stripe].lock().await
+    }
+}
+
+/// Search results and their relevance scores cached by (query, limit).
+struct QueryCache {
+    capacity: usize,
+    ttl: Duration,
+    entries: HashMap<(String, usize), (Instant, ScoredMemories)>,
+    /// Bumped on every invalidation so in-flight searches don't cache stale results
+    generation: u64,
+    hits: u64,
+    misses: u64,
+}
+
+impl QueryCache {
+    fn new(capacity: usize, ttl: Duration) -> Self {
+        Self {
+            capacity,
+            ttl,
+            entries: HashMap::new(),
+            generation: 0,
+            hits: 0,
+            misses: 0,
+        }
+    }
+
+    /// Look up unexpired results, counting the hit or miss.
+    fn get(&mut self, query: &str, limit: usize) -> Option<ScoredMemories> {
+        let key = (query.to_string(), limit);
+        match self.entries.get(&key) {
+            Some((cached_at, results)) if cached_at.elapsed() < self.ttl => {
+                self.hits += 1;
+                Some(results.clone())
+            }
+            _ => {
+                self.entries.remove(&key);
+                self.misses += 1;
+                None
+            }
+        }
+    }
+
+    /// Drop all entries.
+    fn invalidate(&mut self) {
+        self.entries.clear();
+        self.generation += 1;
+    }
+
+    /// Cache results fetched at `generation`, evicting the oldest entry when
+    /// full. Results are discarded if the cache was invalidated since.
+    fn insert(&mut self, generation: u64, query: String, limit: usize, results: ScoredMemories) {
+        if generation != self.generation {
+            return;
+        }
+        if self.entries.len() >= self.capacity {
+            let oldest = self
+                .entries
+                .iter()
+                .min_by_key(|(_, (cached_at, _))| *cached_at)
+                .map(|(key, _)| key.clone());
+            if let Some(key) = oldest {
+                self.entries.remove(&key);
+            }
+        }
+        self.entries
+            .insert((query, limit), (Instant::now(), results));
+    }
 }
 
+/// Search results with a parallel list of relevance scores.
+type ScoredMemories = (Vec<locai::models::Memory>, Vec<f64>);
+
 /// Opaque handle for MemoryConfig
 #[repr(C)]
 pub struct ThymosMemoryConfig {
     inner: MemoryConfig,
+    options: AgentOptions,
+}
+
+impl ThymosMemoryConfig {
+    fn new(config: MemoryConfig) -> Self {
+        Self {
+            inner: config,
+            options: AgentOptions::default(),
+        }
+    }
+}
+
+/// Binding-level options applied to agents created from a ThymosMemoryConfig.
+#[derive(Debug, Clone, Serialize, Deserialize)]
+#[serde(default)]
+struct AgentOptions {
+    /// How long forgotten memories stay recoverable before they are purged
+    forget_grace_period: Duration,
+    /// How often to record the memory count for count history (None = disabled)
+    count_sample_interval: Option<Duration>,
+    /// Search result cache capacity and TTL (None = disabled)
+    query_cache: Option<(usize, Duration)>,
+    /// Largest blob accepted by `thymos_agent_attach_blob`, in bytes
+    max_blob_size: usize,
+    /// Record a timestamp for every memory access
+    track_access_timeline: bool,
+    /// Most memories kept after an insert; the weakest are forgotten (None = unbounded)
+    max_memories: Option<usize>,
+    /// Strength below which memories are forgotten after an insert (None = disabled)
+    forget_threshold: Option<f64>,
+    /// Contents embedded per provider call by `thymos_agent_remember_batch`
+    /// and `thymos_agent_import_file`
+    embedding_batch_size: usize,
+    /// Reject every write and run no background maintenance on the store
+    #[serde(skip)]
+    read_only: bool,
+}
+
+impl Default for AgentOptions {
+    fn default() -> Self {
+        Self {
+            forget_grace_period: Duration::from_secs(3600),
+            count_sample_interval: None,
+            query_cache: None,
+            max_blob_size: 16 * 1024 * 1024,
+            track_access_timeline: false,
+            max_memories: None,
+            forget_threshold: None,
+            embedding_batch_size: 32,
+            read_only: false,
+        }
+    }
+}
+
+/// Directory holding an agent's local storage, if it has any.
+fn local_data_dir(config: &MemoryConfig) -> Option<PathBuf> {
+    match &config.mode {
+        MemoryMode::Embedded { data_dir } => Some(data_dir.clone()),
+        MemoryMode::Hybrid {
+            private_data_dir, ..
+        } => Some(private_data_dir.clone()),
+        MemoryMode::Server { .. } => None,
+    }
+}
+
+/// File in an agent's local data directory holding the configuration the
+/// agent was created with. `thymos_agent_open` reopens the store with it and
+/// treats directories without it as holding no store.
+const AGENT_CONFIG_FILE: &str = "agent_config.json";
+
+/// Contents of `AGENT_CONFIG_FILE`.
+#[derive(Serialize, Deserialize)]
+struct StoredConfig {
+    config: ThymosConfig,
+    options: AgentOptions,
+}
+
+/// Save the configuration of a new agent to its local data directory, if it
+/// has one and no configuration is saved there yet. An agent created over an
+/// existing store, such as the default `./data/memory`, leaves the saved
+/// configuration of the agent that created the store in place. API keys are
+/// left out; providers read them from the environment when the store is
+/// reopened.
+fn save_agent_config(config: &ThymosConfig, options: &AgentOptions) -> Result<()> {
+    let Some(dir) = local_data_dir(&config.memory) else {
+        return Ok(());
+    };
+    if dir.join(AGENT_CONFIG_FILE).exists() {
+        return Ok(());
+    }
+
+    let mut config = config.clone();
+    if let MemoryMode::Hybrid { shared_api_key, .. } = &mut config.memory.mode {
+        *shared_api_key = None;
+    }
+    if let Some(llm) = &mut config.llm {
+        llm.api_key = None;
+    }
+    if let Some(embeddings) = &mut config.embeddings {
+        embeddings.api_key = None;
+    }
+
+    let stored = StoredConfig {
+        config,
+        options: options.clone(),
+    };
+    std::fs::create_dir_all(&dir)?;
+    std::fs::write(
+        dir.join(AGENT_CONFIG_FILE),
+        serde_json::to_vec_pretty(&stored)?,
+    )?;
+    Ok(())
+}
+
+/// A forgotten memory retained for the grace period.
+struct ForgottenMemory {
+    memory: locai::models::Memory,
+    forgotten_at: Instant,
 }
 
 /// Opaque handle for ThymosConfig
@@ -188,10 +907,89 @@ pub struct ThymosMemory {
     pub properties_json: *mut c_char,
     pub created_at: *mut c_char,
     pub last_accessed: *mut c_char,
+    /// Relevance for ranked searches, 0 otherwise
+    pub score: f64,
+    /// Memory type code (see `memory_type_code`)
+    pub memory_type: c_int,
+    /// Current strength on the forgetting curve (0.0-1.0)
+    pub strength: f64,
+    /// Tags as a JSON array of strings
+    pub tags_json: *mut c_char,
+    /// Backend the memory lives in: 0 = default (not hybrid mode),
+    /// 1 = private, 2 = shared
+    pub scope: c_int,
+    /// Retrievals of the memory through this handle, including this one
+    pub access_count: u64,
+}
+
+// Memory scopes reported in `ThymosMemory::scope`.
+const SCOPE_DEFAULT: c_int = 0;
+const SCOPE_PRIVATE: c_int = 1;
+const SCOPE_SHARED: c_int = 2;
+
+/// Search `agent`'s memories like `MemorySystem::search`, noting in
+/// `shared_ids` which results a hybrid agent found in its shared backend.
+///
+/// Hybrid agents search each backend separately, so every result is tagged
+/// with the backend it came from, and merge the results newest first as the
+/// hybrid backend does.
+async fn search_noting_shared(
+    agent: &Agent,
+    shared_ids: &Mutex<HashSet<String>>,
+    query: &str,
+    limit: Option<usize>,
+) -> Result<Vec<locai::models::Memory>> {
+    let memory = agent.memory();
+    if !memory.is_hybrid() {
+        return memory.search(query, limit).await;
+    }
+
+    let (private, shared) = tokio::join!(
+        memory.search_with_scope(query, SearchScope::Private, limit),
+        memory.search_with_scope(query, SearchScope::Shared, limit)
+    );
+    let (mut memories, shared) = (private?, shared?);
+    {
+        let mut ids = shared_ids.lock().unwrap();
+        for m in &memories {
+            ids.remove(&m.id);
+        }
+        ids.extend(shared.iter().map(|m| m.id.clone()));
+    }
+    memories.extend(shared);
+    memories.sort_by(|a, b| b.created_at.cmp(&a.created_at));
+    Ok(memories)
+}
+
+/// Get a memory like `Agent::get_memory`, noting in `shared_ids` whether a
+/// hybrid agent found it in its shared backend.
+async fn get_noting_shared(
+    agent: &Agent,
+    shared_ids: &Mutex<HashSet<String>>,
+    id: &str,
+) -> Result<Option<locai::models::Memory>> {
+    if !agent.memory().is_hybrid() {
+        return agent.get_memory(id).await;
+    }
+
+    let private = local_store(agent)?
+        .manager()
+        .get_memory(id)
+        .await
+        .map_err(|e| CoreError::Memory(e.to_string()))?;
+    if private.is_some() {
+        shared_ids.lock().unwrap().remove(id);
+        return Ok(private);
+    }
+    let shared = agent.get_memory(id).await?;
+    if shared.is_some() {
+        shared_ids.lock().unwrap().insert(id.to_string());
+    }
+    Ok(shared)
 }
 
 impl ThymosMemory {
-    fn from_locai(memory: &locai::models::Memory) -> Self {
+    fn from_locai(agent: &Agent, memory: &locai::models::Memory) -> Self {
         Self {
             id: string_to_cstring(memory.id.clone()),
             content: string_to_cstring(memory.content.clone()),
@@ -204,6 +1002,15 @@ impl ThymosMemory {
                 .last_accessed
                 .map(|dt| string_to_cstring(dt.to_rfc3339()))
                 .unwrap_or(ptr::null_mut()),
+            score: 0.0,
+            memory_type: memory_type_code(&memory.memory_type),
+            strength: agent.memory().calculate_strength(memory),
+            tags_json: serde_json::to_string(&memory.tags)
+                .ok()
+                .map(string_to_cstring)
+                .unwrap_or(ptr::null_mut()),
+            scope: SCOPE_DEFAULT,
+            access_count: 0,
         }
     }
 
@@ -213,11 +1020,13 @@ impl ThymosMemory {
         thymos_free_string(self.properties_json);
         thymos_free_string(self.created_at);
         thymos_free_string(self.last_accessed);
+        thymos_free_string(self.tags_json);
         self.id = ptr::null_mut();
         self.content = ptr::null_mut();
         self.properties_json = ptr::null_mut();
         self.created_at = ptr::null_mut();
         self.last_accessed = ptr::null_mut();
+        self.tags_json = ptr::null_mut();
     }
 }
 
@@ -229,6 +1038,46 @@ pub struct ThymosSearchResults {
     pub capacity: usize,
 }
 
+impl ThymosSearchResults {
+    /// Build results whose memories carry the parallel `scores` (0 where
+    /// absent) and `scopes` (`SCOPE_DEFAULT` where absent), and their
+    /// `access_counts`.
+    fn from_scored(
+        agent: &Agent,
+        memories: &[locai::models::Memory],
+        scores: &[f64],
+        scopes: &[c_int],
+        access_counts: &HashMap<String, u64>,
+    ) -> Self {
+        let mut results: Vec<ThymosMemory> = memories
+            .iter()
+            .enumerate()
+            .map(|(i, m)| ThymosMemory {
+                score: scores.get(i).copied().unwrap_or(0.0),
+                scope: scopes.get(i).copied().unwrap_or(SCOPE_DEFAULT),
+                access_count: access_counts.get(&m.id).copied().unwrap_or(0),
+                ..ThymosMemory::from_locai(agent, m)
+            })
+            .collect();
+
+        let count = results.len();
+        let capacity = results.capacity();
+        let ptr = if count > 0 {
+            let p = results.as_mut_ptr();
+            std::mem::forget(results);
+            p
+        } else {
+            ptr::null_mut()
+        };
+
+        Self {
+            memories: ptr,
+            count,
+            capacity,
+        }
+    }
+}
+
 /// Agent state structure.
 #[repr(C)]
 pub struct ThymosAgentState {
@@ -255,6 +1104,36 @@ impl ThymosAgentState {
     }
 }
 
+/// String list structure for returning arrays of strings.
+#[repr(C)]
+pub struct ThymosStringList {
+    pub items: *mut *mut c_char,
+    pub count: usize,
+    pub capacity: usize,
+}
+
+impl ThymosStringList {
+    fn from_strings(strings: Vec<String>) -> Self {
+        let mut items: Vec<*mut c_char> = strings.into_iter().map(string_to_cstring).collect();
+
+        let count = items.len();
+        let capacity = items.capacity();
+        let ptr = if count > 0 {
+            let p = items.as_mut_ptr();
+            std::mem::forget(items);
+            p
+        } else {
+            ptr::null_mut()
+        };
+
+        Self {
+            items: ptr,
+            count,
+            capacity,
+        }
+    }
+}
+
 // ============================================================================
 // Memory Deallocation
 // ============================================================================
@@ -288,6 +1167,56 @@ pub unsafe extern "C" fn thymos_free_search_results(results: *mut ThymosSearchRe
     }
 }
 
+/// Free a ThymosStringList structure.
+///
+/// # Safety
+/// The pointer must be valid and allocated by Thymos, or null.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_free_string_list(list: *mut ThymosStringList) {
+    if !list.is_null() {
+        let sl = Box::from_raw(list);
+        if !sl.items.is_null() && sl.count > 0 {
+            let items = Vec::from_raw_parts(sl.items, sl.count, sl.capacity);
+            for item in items {
+                thymos_free_string(item);
+            }
+        }
+    }
+}
+
+/// Free a byte buffer returned by Thymos.
+///
+/// # Safety
+/// `data` and `len` must come from the same Thymos call, or `data` must be null.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_free_bytes(data: *mut u8, len: usize) {
+    if !data.is_null() {
+        let _ = Box::from_raw(ptr::slice_from_raw_parts_mut(data, len));
+    }
+}
+
+/// Free a double buffer returned by Thymos.
+///
+/// # Safety
+/// `data` and `len` must come from the same Thymos call, or `data` must be null.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_free_doubles(data: *mut f64, len: usize) {
+    if !data.is_null() {
+        let _ = Box::from_raw(ptr::slice_from_raw_parts_mut(data, len));
+    }
+}
+
+/// Free a float buffer returned by Thymos.
+///
+/// # Safety
+/// `data` and `len` must come from the same Thymos call, or `data` must be null.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_free_floats(data: *mut f32, len: usize) {
+    if !data.is_null() {
+        let _ = Box::from_raw(ptr::slice_from_raw_parts_mut(data, len));
+    }
+}
+
 /// Free a ThymosAgent handle.
 ///
 /// # Safety
@@ -346,9 +1275,7 @@ pub unsafe extern "C" fn thymos_free_agent_state(state: *mut ThymosAgentState) {
 /// Must be freed with `thymos_free_memory_config`.
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn thymos_memory_config_new() -> *mut ThymosMemoryConfig {
-    Box::into_raw(Box::new(ThymosMemoryConfig {
-        inner: MemoryConfig::default(),
-    }))
+    Box::into_raw(Box::new(ThymosMemoryConfig::new(MemoryConfig::default())))
 }
 
 /// Create a memory configuration with a custom data directory.
@@ -358,7 +1285,9 @@ pub unsafe extern "C" fn thymos_memory_config_new() -> *mut ThymosMemoryConfig {
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn thymos_memory_config_with_data_dir(
     data_dir: *const c_char,
+    out_error: *mut ThymosError,
 ) -> *mut ThymosMemoryConfig {
+    let _error_out = ErrorOut::new(out_error);
     let Some(dir) = cstr_to_string(data_dir) else {
         set_error("Invalid data_dir: not valid UTF-8");
         return ptr::null_mut();
@@ -369,7 +1298,7 @@ pub unsafe extern "C" fn thymos_memory_config_with_data_dir(
         data_dir: PathBuf::from(dir),
     };
 
-    Box::into_raw(Box::new(ThymosMemoryConfig { inner: config }))
+    Box::into_raw(Box::new(ThymosMemoryConfig::new(config)))
 }
 
 /// Create a memory configuration for server mode (connects to Locai server).
@@ -385,7 +1314,9 @@ pub unsafe extern "C" fn thymos_memory_config_with_data_dir(
 pub unsafe extern "C" fn thymos_memory_config_server(
     server_url: *const c_char,
     api_key: *const c_char,
+    out_error: *mut ThymosError,
 ) -> *mut ThymosMemoryConfig {
+    let _error_out = ErrorOut::new(out_error);
     let Some(url) = cstr_to_string(server_url) else {
         set_error("Invalid server_url: not valid UTF-8");
         return ptr::null_mut();
@@ -396,7 +1327,7 @@ pub unsafe extern "C" fn thymos_memory_config_server(
     let mut config = MemoryConfig::default();
     config.mode = MemoryMode::Server { url, api_key };
 
-    Box::into_raw(Box::new(ThymosMemoryConfig { inner: config }))
+    Box::into_raw(Box::new(ThymosMemoryConfig::new(config)))
 }
 
 /// Create a memory configuration for hybrid mode (private + shared).
@@ -414,7 +1345,9 @@ pub unsafe extern "C" fn thymos_memory_config_hybrid(
     private_data_dir: *const c_char,
     shared_url: *const c_char,
     shared_api_key: *const c_char,
+    out_error: *mut ThymosError,
 ) -> *mut ThymosMemoryConfig {
+    let _error_out = ErrorOut::new(out_error);
     let Some(dir) = cstr_to_string(private_data_dir) else {
         set_error("Invalid private_data_dir: not valid UTF-8");
         return ptr::null_mut();
@@ -434,46 +1367,394 @@ pub unsafe extern "C" fn thymos_memory_config_hybrid(
         shared_api_key: api_key,
     };
 
-    Box::into_raw(Box::new(ThymosMemoryConfig { inner: config }))
+    Box::into_raw(Box::new(ThymosMemoryConfig::new(config)))
 }
 
-/// Create a default Thymos configuration.
+/// Set how long forgotten memories stay recoverable before they are purged.
 ///
-/// Returns a handle to the configuration, or null on error.
-/// Must be freed with `thymos_free_config`.
-#[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_config_new() -> *mut ThymosConfigHandle {
-    Box::into_raw(Box::new(ThymosConfigHandle {
-        inner: ThymosConfig::default(),
-    }))
-}
-
-/// Load Thymos configuration from file and environment.
+/// A grace period of 0 purges forgotten memories immediately.
 ///
-/// Searches for thymos.toml, thymos.yaml, or thymos.json in standard locations.
-/// Environment variables with THYMOS_ prefix override file settings.
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
 ///
-/// Returns a handle to the configuration, or null on error.
-/// Must be freed with `thymos_free_config`.
+/// Returns 0 on success, -1 on error.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_config_load() -> *mut ThymosConfigHandle {
-    match ThymosConfig::load() {
-        Ok(config) => Box::into_raw(Box::new(ThymosConfigHandle { inner: config })),
-        Err(e) => {
-            set_error(e.to_string());
-            ptr::null_mut()
-        }
+pub unsafe extern "C" fn thymos_memory_config_set_forget_grace_period(
+    config: *mut ThymosMemoryConfig,
+    grace_ms: u64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
     }
+
+    (*config).options.forget_grace_period = Duration::from_millis(grace_ms);
+    0
 }
 
-/// Load Thymos configuration from a specific file.
+/// Enable periodic memory count sampling for count history.
+///
+/// An interval of 0 disables sampling. Intervals below one second are raised
+/// to one second.
+///
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_config_set_count_sampling(
+    config: *mut ThymosMemoryConfig,
+    interval_ms: u64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
+    }
+
+    (*config).options.count_sample_interval = if interval_ms == 0 {
+        None
+    } else {
+        Some(Duration::from_millis(interval_ms.max(1000)))
+    };
+    0
+}
+
+/// Cache search results by (query, limit).
+///
+/// Any write through the agent handle clears the cache; entries also expire
+/// after `ttl_ms`. A `size` of 0 disables the cache.
+///
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_config_set_query_cache(
+    config: *mut ThymosMemoryConfig,
+    size: usize,
+    ttl_ms: u64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
+    }
+
+    if size > 0 && ttl_ms == 0 {
+        set_error("Invalid ttl: must be positive");
+        return -1;
+    }
+
+    (*config).options.query_cache = if size == 0 {
+        None
+    } else {
+        Some((size, Duration::from_millis(ttl_ms)))
+    };
+    0
+}
+
+/// Set the largest blob `thymos_agent_attach_blob` accepts (default 16 MiB).
+///
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_config_set_max_blob_size(
+    config: *mut ThymosMemoryConfig,
+    max_bytes: usize,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
+    }
+
+    (*config).options.max_blob_size = max_bytes;
+    0
+}
+
+/// Record a timestamp for every memory access, for
+/// `thymos_agent_access_timeline`.
+///
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_config_set_track_access_timeline(
+    config: *mut ThymosMemoryConfig,
+    enabled: bool,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
+    }
+
+    (*config).options.track_access_timeline = enabled;
+    0
+}
+
+/// Cap the number of memories an agent keeps.
+///
+/// After an insert that takes the local store (the private backend in hybrid
+/// mode) beyond `max_memories`, its weakest memories beyond the cap are
+/// forgotten (recoverable for the forget grace period). Shared memories are
+/// not counted. A value of 0 removes the cap.
+///
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_config_set_max_memories(
+    config: *mut ThymosMemoryConfig,
+    max_memories: usize,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
+    }
+
+    (*config).options.max_memories = (max_memories > 0).then_some(max_memories);
+    0
+}
+
+/// Set how many contents `thymos_agent_remember_batch`,
+/// `thymos_agent_import_file` and `thymos_knowledge_overlap` embed per call to
+/// the embedding provider (default 32).
+///
+/// Larger batches make better use of batched or GPU-backed providers, at the
+/// cost of holding a whole batch of embeddings in memory and failing the whole
+/// batch if its embedding call fails.
+///
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
+///
+/// Returns 0 on success, -1 on error (including a size of 0).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_config_set_embedding_batch_size(
+    config: *mut ThymosMemoryConfig,
+    batch_size: usize,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
+    }
+
+    if batch_size == 0 {
+        set_error_with_code(
+            ERROR_INVALID_ARGUMENT,
+            "Invalid batch_size: must be at least 1",
+        );
+        return -1;
+    }
+
+    (*config).options.embedding_batch_size = batch_size;
+    0
+}
+
+/// Set the base decay rate applied to memory strength as memories age.
+///
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_config_set_decay_rate(
+    config: *mut ThymosMemoryConfig,
+    rate: f64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
+    }
+
+    if !rate.is_finite() || rate < 0.0 {
+        set_error("Invalid decay rate: must be a non-negative number");
+        return -1;
+    }
+
+    (*config).inner.base_decay_rate = rate;
+    0
+}
+
+/// Forget memories whose strength falls below `threshold` after inserts.
+///
+/// The local store is checked after an insert at most once a minute, since
+/// strengths change slowly. Forgotten memories stay recoverable for the
+/// forget grace period. A threshold of 0 disables automatic forgetting.
+///
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_config_set_forget_threshold(
+    config: *mut ThymosMemoryConfig,
+    threshold: f64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
+    }
+
+    if !(0.0..=1.0).contains(&threshold) {
+        set_error("Invalid threshold: must be between 0 and 1");
+        return -1;
+    }
+
+    (*config).options.forget_threshold = (threshold > 0.0).then_some(threshold);
+    0
+}
+
+/// Check a directory setting: non-empty, and a directory if it exists.
+fn check_dir(name: &str, dir: &std::path::Path) -> std::result::Result<(), String> {
+    if dir.as_os_str().is_empty() {
+        return Err(format!("{} must not be empty", name));
+    }
+    if dir.exists() && !dir.is_dir() {
+        return Err(format!("{} is not a directory: {}", name, dir.display()));
+    }
+    Ok(())
+}
+
+/// Check a server URL setting: an http or https URL.
+fn check_url(name: &str, url: &str) -> std::result::Result<(), String> {
+    if url.is_empty() {
+        return Err(format!("{} must not be empty", name));
+    }
+    if !url.starts_with("http://") && !url.starts_with("https://") {
+        return Err(format!("{} must be an http or https URL: {}", name, url));
+    }
+    Ok(())
+}
+
+/// Find the first problem in a memory configuration, if any.
+fn validate_memory_config(config: &MemoryConfig) -> std::result::Result<(), String> {
+    match &config.mode {
+        MemoryMode::Embedded { data_dir } => check_dir("data_dir", data_dir)?,
+        MemoryMode::Server { url, .. } => check_url("url", url)?,
+        MemoryMode::Hybrid {
+            private_data_dir,
+            shared_url,
+            ..
+        } => {
+            check_dir("private_data_dir", private_data_dir)?;
+            check_url("shared_url", shared_url)?;
+        }
+    }
+
+    if !(config.recency_decay_hours.is_finite() && config.recency_decay_hours > 0.0) {
+        return Err("recency_decay_hours must be > 0".to_string());
+    }
+    if !(config.base_decay_rate.is_finite() && config.base_decay_rate >= 0.0) {
+        return Err("base_decay_rate must be >= 0".to_string());
+    }
+    if !(config.access_count_weight.is_finite() && config.access_count_weight >= 0.0) {
+        return Err("access_count_weight must be >= 0".to_string());
+    }
+    if !(config.emotional_weight_multiplier.is_finite() && config.emotional_weight_multiplier > 0.0)
+    {
+        return Err("emotional_weight_multiplier must be > 0".to_string());
+    }
+    if let Some(search) = &config.hybrid_search {
+        if !(0.0..=1.0).contains(&search.semantic_weight) {
+            return Err("hybrid_search.semantic_weight must be between 0 and 1".to_string());
+        }
+    }
+    Ok(())
+}
+
+/// Check a memory configuration without creating an agent.
+///
+/// Reports the first problem found, such as an empty data directory, a shared
+/// URL that is not http(s), or a non-positive decay setting. Passing does not
+/// guarantee agent creation succeeds: servers and stores are not contacted.
+///
+/// # Safety
+/// `config` must be a valid ThymosMemoryConfig handle.
+///
+/// Returns 0 if the configuration is valid, -1 with ERROR_CONFIGURATION
+/// describing the problem otherwise.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_config_validate(
+    config: *const ThymosMemoryConfig,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Memory config is null");
+        return -1;
+    }
+
+    match validate_memory_config(&(*config).inner) {
+        Ok(()) => 0,
+        Err(message) => {
+            set_error_with_code(ERROR_CONFIGURATION, message);
+            -1
+        }
+    }
+}
+
+/// Create a default Thymos configuration.
+///
+/// Returns a handle to the configuration, or null on error.
+/// Must be freed with `thymos_free_config`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_config_new() -> *mut ThymosConfigHandle {
+    Box::into_raw(Box::new(ThymosConfigHandle {
+        inner: ThymosConfig::default(),
+    }))
+}
+
+/// Load Thymos configuration from file and environment.
+///
+/// Searches for thymos.toml, thymos.yaml, or thymos.json in standard locations.
+/// Environment variables with THYMOS_ prefix override file settings.
+///
+/// Returns a handle to the configuration, or null on error.
+/// Must be freed with `thymos_free_config`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_config_load(
+    out_error: *mut ThymosError,
+) -> *mut ThymosConfigHandle {
+    let _error_out = ErrorOut::new(out_error);
+    match ThymosConfig::load() {
+        Ok(config) => Box::into_raw(Box::new(ThymosConfigHandle { inner: config })),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Load Thymos configuration from a specific file.
 ///
 /// # Safety
 /// `path` must be a valid null-terminated UTF-8 string.
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn thymos_config_load_from_file(
     path: *const c_char,
+    out_error: *mut ThymosError,
 ) -> *mut ThymosConfigHandle {
+    let _error_out = ErrorOut::new(out_error);
     let Some(path_str) = cstr_to_string(path) else {
         set_error("Invalid path: not valid UTF-8");
         return ptr::null_mut();
@@ -482,598 +1763,9209 @@ pub unsafe extern "C" fn thymos_config_load_from_file(
     match ThymosConfig::from_file(&path_str) {
         Ok(config) => Box::into_raw(Box::new(ThymosConfigHandle { inner: config })),
         Err(e) => {
-            set_error(e.to_string());
+            set_thymos_error(&e);
             ptr::null_mut()
         }
     }
 }
 
-// ============================================================================
-// Agent Creation
-// ============================================================================
-
-/// Create a new agent with default configuration.
+/// Select the embedding model.
+///
+/// Without an embeddings section the local provider is used. An empty model
+/// removes the embeddings configuration, so agents have no embedding provider.
 ///
 /// # Safety
-/// `agent_id` must be a valid null-terminated UTF-8 string.
+/// `config` must be a valid ThymosConfigHandle.
+/// `model` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_new(agent_id: *const c_char) -> *mut ThymosAgent {
-    let Some(id) = cstr_to_string(agent_id) else {
-        set_error("Invalid agent_id: not valid UTF-8");
-        return ptr::null_mut();
+pub unsafe extern "C" fn thymos_config_set_embedding_model(
+    config: *mut ThymosConfigHandle,
+    model: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Config is null");
+        return -1;
+    }
+
+    let Some(model) = cstr_to_string(model) else {
+        set_error("Invalid model: not valid UTF-8");
+        return -1;
     };
 
-    match block_on(async move { Agent::builder().id(id).build().await }) {
-        Ok(agent) => Box::into_raw(Box::new(ThymosAgent { inner: agent })),
-        Err(e) => {
-            set_error(e.to_string());
-            ptr::null_mut()
-        }
+    if model.is_empty() {
+        (*config).inner.embeddings = None;
+        return 0;
     }
+    let embeddings = (*config)
+        .inner
+        .embeddings
+        .get_or_insert_with(|| EmbeddingsConfig {
+            provider: EmbeddingProvider::Local,
+            model: String::new(),
+            api_key: None,
+            base_url: None,
+            dimensions: None,
+        });
+    embeddings.model = model;
+    0
 }
 
-/// Create a new agent with custom memory configuration.
+/// Set the embedding dimensions the model is expected to produce.
+///
+/// Agent creation fails if the model's dimensions differ. 0 removes the
+/// expectation. Requires an embedding model to be configured.
 ///
 /// # Safety
-/// `agent_id` must be a valid null-terminated UTF-8 string.
-/// `config` must be a valid ThymosMemoryConfig handle.
+/// `config` must be a valid ThymosConfigHandle.
+///
+/// Returns 0 on success, -1 on error.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_new_with_memory_config(
-    agent_id: *const c_char,
-    config: *const ThymosMemoryConfig,
-) -> *mut ThymosAgent {
-    let Some(id) = cstr_to_string(agent_id) else {
-        set_error("Invalid agent_id: not valid UTF-8");
+pub unsafe extern "C" fn thymos_config_set_embedding_dimensions(
+    config: *mut ThymosConfigHandle,
+    dimensions: usize,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Config is null");
+        return -1;
+    }
+
+    let Some(embeddings) = &mut (*config).inner.embeddings else {
+        set_error_with_code(ERROR_CONFIGURATION, "No embedding model configured");
+        return -1;
+    };
+    embeddings.dimensions = (dimensions > 0).then_some(dimensions);
+    0
+}
+
+/// Get the configured embedding model.
+///
+/// Returns an empty string when no embedding model is configured.
+///
+/// # Safety
+/// `config` must be a valid ThymosConfigHandle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_config_embedding_model(
+    config: *const ThymosConfigHandle,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Config is null");
+        return ptr::null_mut();
+    }
+
+    let model = (*config).inner.embeddings.as_ref().map(|e| e.model.clone());
+    string_to_cstring(model.unwrap_or_default())
+}
+
+/// Set the local storage directory of a Thymos configuration.
+///
+/// Sets the data directory in embedded mode and the private data directory
+/// in hybrid mode. Server mode has no local storage and fails with a
+/// configuration error.
+///
+/// # Safety
+/// `config` must be a valid ThymosConfigHandle.
+/// `data_dir` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_config_set_data_dir(
+    config: *mut ThymosConfigHandle,
+    data_dir: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Config is null");
+        return -1;
+    }
+
+    let Some(dir) = cstr_to_string(data_dir) else {
+        set_error("Invalid data_dir: not valid UTF-8");
+        return -1;
+    };
+
+    if dir.is_empty() {
+        set_error("Invalid data_dir: must not be empty");
+        return -1;
+    }
+
+    match &mut (*config).inner.memory.mode {
+        MemoryMode::Embedded { data_dir } => *data_dir = PathBuf::from(dir),
+        MemoryMode::Hybrid {
+            private_data_dir, ..
+        } => *private_data_dir = PathBuf::from(dir),
+        MemoryMode::Server { .. } => {
+            set_error_with_code(
+                ERROR_CONFIGURATION,
+                "Server mode has no local data directory",
+            );
+            return -1;
+        }
+    }
+    0
+}
+
+/// Switch a configuration to hybrid mode (private + shared).
+///
+/// The current local data directory becomes the private data directory; a
+/// server-mode configuration gets the default one.
+///
+/// # Safety
+/// `config` must be a valid ThymosConfigHandle.
+/// `shared_url` must be a valid null-terminated UTF-8 string.
+/// `shared_api_key` can be null or a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_config_set_hybrid(
+    config: *mut ThymosConfigHandle,
+    shared_url: *const c_char,
+    shared_api_key: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Config is null");
+        return -1;
+    }
+
+    let Some(url) = cstr_to_string(shared_url) else {
+        set_error("Invalid shared_url: not valid UTF-8");
+        return -1;
+    };
+
+    if url.is_empty() {
+        set_error("Invalid shared_url: must not be empty");
+        return -1;
+    }
+
+    let api_key = cstr_to_string(shared_api_key);
+
+    let memory = &mut (*config).inner.memory;
+    let private_data_dir = local_data_dir(memory)
+        .or_else(|| local_data_dir(&MemoryConfig::default()))
+        .unwrap_or_default();
+    memory.mode = MemoryMode::Hybrid {
+        private_data_dir,
+        shared_url: url,
+        shared_api_key: api_key,
+    };
+    0
+}
+
+/// Check a Thymos configuration without creating an agent.
+///
+/// Checks the memory settings like `thymos_memory_config_validate`, then the
+/// embedding and LLM settings: models must be named, embedding_dimensions
+/// must be > 0 when set, and base URLs must be http(s).
+///
+/// # Safety
+/// `config` must be a valid ThymosConfigHandle.
+///
+/// Returns 0 if the configuration is valid, -1 with ERROR_CONFIGURATION
+/// describing the problem otherwise.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_config_validate(
+    config: *const ThymosConfigHandle,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if config.is_null() {
+        set_error("Config is null");
+        return -1;
+    }
+
+    let config = &(*config).inner;
+    let result = validate_memory_config(&config.memory).and_then(|()| {
+        if let Some(embeddings) = &config.embeddings {
+            if embeddings.model.is_empty() {
+                return Err("embeddings.model must not be empty".to_string());
+            }
+            if embeddings.dimensions == Some(0) {
+                return Err("embedding_dimensions must be > 0".to_string());
+            }
+            if let Some(url) = &embeddings.base_url {
+                check_url("embeddings.base_url", url)?;
+            }
+        }
+        if let Some(llm) = &config.llm {
+            if llm.model.is_empty() {
+                return Err("llm.model must not be empty".to_string());
+            }
+            if let Some(url) = &llm.base_url {
+                check_url("llm.base_url", url)?;
+            }
+        }
+        Ok(())
+    });
+
+    match result {
+        Ok(()) => 0,
+        Err(message) => {
+            set_error_with_code(ERROR_CONFIGURATION, message);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Agent Creation
+// ============================================================================
+
+/// Create a new agent with default configuration.
+///
+/// # Safety
+/// `agent_id` must be a valid null-terminated UTF-8 string.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_new(
+    agent_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut ThymosAgent {
+    let _error_out = ErrorOut::new(out_error);
+    let Some(id) = cstr_to_string(agent_id) else {
+        set_error("Invalid agent_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let result = block_on(async move { Agent::builder().id(id).build().await }).and_then(|agent| {
+        save_agent_config(&ThymosConfig::default(), &AgentOptions::default())?;
+        Ok(agent)
+    });
+    match result {
+        Ok(agent) => {
+            let agent = ThymosAgent::new(
+                agent,
+                AgentOptions::default(),
+                MemoryConfig::default(),
+                None,
+            );
+            Box::into_raw(Box::new(agent))
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Create a new agent with custom memory configuration.
+///
+/// The configuration is copied, so `config` may be freed as soon as this
+/// returns.
+///
+/// # Safety
+/// `agent_id` must be a valid null-terminated UTF-8 string.
+/// `config` must be a valid ThymosMemoryConfig handle.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_new_with_memory_config(
+    agent_id: *const c_char,
+    config: *const ThymosMemoryConfig,
+    out_error: *mut ThymosError,
+) -> *mut ThymosAgent {
+    let _error_out = ErrorOut::new(out_error);
+    let Some(id) = cstr_to_string(agent_id) else {
+        set_error("Invalid agent_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    if config.is_null() {
+        set_error("Memory config is null");
+        return ptr::null_mut();
+    }
+
+    let memory_config = (*config).inner.clone();
+    let options = (*config).options.clone();
+    let embedding_model = None;
+
+    let builder_config = memory_config.clone();
+    let result = block_on(async move {
+        Agent::builder()
+            .id(id)
+            .with_memory_config(builder_config)
+            .build()
+            .await
+    })
+    .and_then(|agent| {
+        let config = ThymosConfig {
+            memory: memory_config.clone(),
+            ..ThymosConfig::default()
+        };
+        save_agent_config(&config, &options)?;
+        Ok(agent)
+    });
+    match result {
+        Ok(agent) => Box::into_raw(Box::new(ThymosAgent::new(
+            agent,
+            options,
+            memory_config,
+            embedding_model,
+        ))),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Create a new agent with full Thymos configuration.
+///
+/// The configuration is copied, so `config` may be freed as soon as this
+/// returns.
+///
+/// # Safety
+/// `agent_id` must be a valid null-terminated UTF-8 string.
+/// `config` must be a valid ThymosConfigHandle.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_new_with_config(
+    agent_id: *const c_char,
+    config: *const ThymosConfigHandle,
+    out_error: *mut ThymosError,
+) -> *mut ThymosAgent {
+    let _error_out = ErrorOut::new(out_error);
+    let Some(id) = cstr_to_string(agent_id) else {
+        set_error("Invalid agent_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    if config.is_null() {
+        set_error("Config is null");
+        return ptr::null_mut();
+    }
+
+    let thymos_config = (*config).inner.clone();
+    let memory_config = thymos_config.memory.clone();
+    let embedding_model = thymos_config.embeddings.as_ref().map(|e| e.model.clone());
+
+    let saved_config = thymos_config.clone();
+    let result =
+        block_on(async move { Agent::builder().id(id).config(thymos_config).build().await })
+            .and_then(|agent| {
+                save_agent_config(&saved_config, &AgentOptions::default())?;
+                Ok(agent)
+            });
+    match result {
+        Ok(agent) => {
+            let agent = ThymosAgent::new(
+                agent,
+                AgentOptions::default(),
+                memory_config,
+                embedding_model,
+            );
+            Box::into_raw(Box::new(agent))
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Reopen an existing agent store.
+///
+/// Builds the agent with the configuration and options it was created with,
+/// which every agent with local storage saves in its data directory, so an
+/// agent created with an embedding model, query cache or retention limit
+/// comes back with them. The saved configuration leaves out API keys, which
+/// providers then read from the environment. `data_dir` replaces the saved
+/// local data directory, so stores can be moved. A store without a saved
+/// configuration, created before agents saved theirs, is opened with the
+/// default configuration and options; reopen it with the constructor it was
+/// created with instead if it needs an embedding model or other settings.
+/// Fails with THYMOS_ERROR_NOT_FOUND instead of creating a new store when
+/// `data_dir` is missing or empty.
+///
+/// # Safety
+/// `agent_id` and `data_dir` must be valid null-terminated UTF-8 strings.
+/// The returned handle must be freed with `thymos_free_agent`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_open(
+    agent_id: *const c_char,
+    data_dir: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut ThymosAgent {
+    let _error_out = ErrorOut::new(out_error);
+    open_agent(agent_id, data_dir, false)
+}
+
+/// Reopen an existing embedded agent store for reading only.
+///
+/// Opens the store like `thymos_agent_open`, but every call that would write
+/// to it fails with ERROR_READ_ONLY, and the agent runs no background work
+/// that writes: no TTL expiry sweeps and no retention after inserts. Searches
+/// are the exception: the store records each match's last access time, which
+/// it does on every search, and the handle counts accesses in memory.
+///
+/// Locai has no shared or read-only open, so the store is opened as for
+/// writing and holds its exclusive lock: a second process cannot open the
+/// same data directory, even read-only.
+///
+/// # Safety
+/// `agent_id` and `data_dir` must be valid null-terminated UTF-8 strings.
+/// The returned handle must be freed with `thymos_free_agent`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_open_read_only(
+    agent_id: *const c_char,
+    data_dir: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut ThymosAgent {
+    let _error_out = ErrorOut::new(out_error);
+    open_agent(agent_id, data_dir, true)
+}
+
+/// Reopen the store at `data_dir` with its saved configuration.
+unsafe fn open_agent(
+    agent_id: *const c_char,
+    data_dir: *const c_char,
+    read_only: bool,
+) -> *mut ThymosAgent {
+    let Some(id) = cstr_to_string(agent_id) else {
+        set_error("Invalid agent_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(dir) = cstr_to_string(data_dir) else {
+        set_error("Invalid data_dir: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let data_dir = PathBuf::from(dir);
+    let config_path = data_dir.join(AGENT_CONFIG_FILE);
+    let stored = if config_path.is_file() {
+        std::fs::read(&config_path)
+            .map_err(CoreError::from)
+            .and_then(|json| Ok(serde_json::from_slice::<StoredConfig>(&json)?))
+    } else if std::fs::read_dir(&data_dir).is_ok_and(|mut entries| entries.next().is_some()) {
+        // Stores created before configurations were saved open with defaults.
+        Ok(StoredConfig {
+            config: ThymosConfig::default(),
+            options: AgentOptions::default(),
+        })
+    } else {
+        set_error_with_code(
+            ERROR_NOT_FOUND,
+            format!("no agent store at {}", data_dir.display()),
+        );
+        return ptr::null_mut();
+    };
+    let StoredConfig {
+        config: mut thymos_config,
+        options,
+    } = match stored {
+        Ok(stored) => stored,
+        Err(e) => {
+            set_error_with_code(
+                ERROR_CONFIGURATION,
+                format!(
+                    "invalid agent configuration {}: {}",
+                    config_path.display(),
+                    e
+                ),
+            );
+            return ptr::null_mut();
+        }
+    };
+    match &mut thymos_config.memory.mode {
+        MemoryMode::Embedded { data_dir: dir }
+        | MemoryMode::Hybrid {
+            private_data_dir: dir,
+            ..
+        } => *dir = data_dir,
+        MemoryMode::Server { .. } => {
+            set_error_with_code(
+                ERROR_CONFIGURATION,
+                format!(
+                    "agent configuration {} has no local store",
+                    config_path.display()
+                ),
+            );
+            return ptr::null_mut();
+        }
+    }
+
+    let options = AgentOptions {
+        read_only,
+        ..options
+    };
+    let memory_config = thymos_config.memory.clone();
+    let embedding_model = thymos_config.embeddings.as_ref().map(|e| e.model.clone());
+    match block_on(async move {
+        Agent::builder().id(id).config(thymos_config).build().await
+    }) {
+        Ok(agent) => Box::into_raw(Box::new(ThymosAgent::new(
+            agent,
+            options,
+            memory_config,
+            embedding_model,
+        ))),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Copy a directory tree, creating `dst`.
+fn copy_dir_all(src: &std::path::Path, dst: &std::path::Path) -> std::io::Result<()> {
+    std::fs::create_dir_all(dst)?;
+    for entry in std::fs::read_dir(src)? {
+        let entry = entry?;
+        let target = dst.join(entry.file_name());
+        if entry.file_type()?.is_dir() {
+            copy_dir_all(&entry.path(), &target)?;
+        } else {
+            std::fs::copy(entry.path(), target)?;
+        }
+    }
+    Ok(())
+}
+
+/// Directory `thymos_agent_fork` creates for a fork of the agent stored in
+/// `parent_dir` under `agent_id`.
+fn fork_dir(parent_dir: &std::path::Path, agent_id: &str) -> PathBuf {
+    let mut dir_name = parent_dir.file_name().unwrap_or_default().to_os_string();
+    dir_name.push(format!(".fork-{}", agent_id));
+    parent_dir.with_file_name(dir_name)
+}
+
+/// Fork an agent into an independent copy with its own storage.
+///
+/// The fork is eager: every memory is copied, with its ID and timestamps, into
+/// a new data directory next to the parent's, named
+/// `<parent dir>.fork-<new_agent_id>`. Blobs, saved queries and the saved
+/// agent configuration are copied too, so the fork needs about as much disk
+/// as the parent and `thymos_agent_open` can reopen it. The fork shares the
+/// parent's embedding provider, concept extractor, LLM provider and memory
+/// options, but no storage: later writes to either agent are not seen by the
+/// other. Only embedded mode is supported. If the copy fails, the new
+/// directory is removed again.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `new_agent_id` must be a valid null-terminated UTF-8 string.
+///
+/// Returns a handle to the fork, or null on error.
+/// Must be freed with `thymos_free_agent`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_fork(
+    handle: *const ThymosAgent,
+    new_agent_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut ThymosAgent {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(new_id) = cstr_to_string(new_agent_id) else {
+        set_error("Invalid new_agent_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    if let Err(e) = validate_path_component("agent ID", &new_id) {
+        set_thymos_error(&e);
+        return ptr::null_mut();
+    }
+
+    let handle = &*handle;
+    let MemoryMode::Embedded {
+        data_dir: parent_dir,
+    } = &handle.memory_config.mode
+    else {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            "Fork requires an agent in embedded mode",
+        );
+        return ptr::null_mut();
+    };
+
+    let fork_dir = fork_dir(parent_dir, &new_id);
+    if fork_dir.exists() {
+        set_error(format!(
+            "Fork directory already exists: {}",
+            fork_dir.display()
+        ));
+        return ptr::null_mut();
+    }
+
+    let memory_config = MemoryConfig {
+        mode: MemoryMode::Embedded {
+            data_dir: fork_dir.clone(),
+        },
+        ..handle.memory_config.clone()
+    };
+
+    let parent = handle.inner.clone();
+    let builder_config = memory_config.clone();
+    let result = block_on(async move {
+        let mut builder = Agent::builder()
+            .id(new_id)
+            .description(parent.current_description().await)
+            .with_memory_config(builder_config);
+        if let Some(provider) = parent.embedding_provider() {
+            builder = builder.embedding_provider(provider.clone());
+        }
+        if let Some(extractor) = parent.concept_extractor() {
+            builder = builder.concept_extractor(extractor.clone());
+        }
+        if let Some(provider) = parent.llm_provider() {
+            builder = builder.llm_provider(provider.clone());
+        }
+        let fork = builder.build().await?;
+
+        let store = local_store(&fork)?;
+        for memory in load_all_memories(&parent).await? {
+            store
+                .manager()
+                .store_memory(memory)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+        }
+        Ok(fork)
+    });
+
+    let copied = result.and_then(|fork| {
+        let blobs = parent_dir.join("blobs");
+        if blobs.is_dir() {
+            copy_dir_all(&blobs, &fork_dir.join("blobs"))?;
+        }
+        for file in ["saved_queries.json", AGENT_CONFIG_FILE] {
+            if parent_dir.join(file).is_file() {
+                std::fs::copy(parent_dir.join(file), fork_dir.join(file))?;
+            }
+        }
+        Ok(fork)
+    });
+
+    match copied {
+        Ok(fork) => Box::into_raw(Box::new(ThymosAgent::new(
+            fork,
+            handle.options.clone(),
+            memory_config,
+            handle.embedding_model.clone(),
+        ))),
+        Err(e) => {
+            let _ = std::fs::remove_dir_all(&fork_dir);
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Agent Properties
+// ============================================================================
+
+/// Get the agent ID.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_id(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    string_to_cstring((*handle).inner.id().to_string())
+}
+
+/// Change the agent ID in place, keeping its memories and storage.
+///
+/// Stores are located by data directory rather than by ID, so the only store
+/// an ID names is the directory `thymos_agent_fork` would create for it; the
+/// rename fails if that directory exists. Only embedded mode is supported.
+/// Renaming to the current ID does nothing.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle that no other call is using.
+/// `new_agent_id` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_rename(
+    handle: *mut ThymosAgent,
+    new_agent_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    let Some(new_id) = cstr_to_string(new_agent_id) else {
+        set_error("Invalid new_agent_id: not valid UTF-8");
+        return -1;
+    };
+
+    if let Err(e) = validate_path_component("agent ID", &new_id) {
+        set_thymos_error(&e);
+        return -1;
+    }
+
+    let handle = &mut *handle;
+    let MemoryMode::Embedded { data_dir } = &handle.memory_config.mode else {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            "Rename requires an agent in embedded mode",
+        );
+        return -1;
+    };
+
+    if new_id == handle.inner.id() {
+        return 0;
+    }
+
+    let existing = fork_dir(data_dir, &new_id);
+    if existing.exists() {
+        set_error(format!(
+            "A store for agent ID '{}' already exists: {}",
+            new_id,
+            existing.display()
+        ));
+        return -1;
+    }
+
+    handle.inner.id = new_id;
+    0
+}
+
+/// Get the agent description.
+///
+/// Reflects `thymos_agent_set_description`.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_description(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    string_to_cstring(block_on_value(
+        async move { agent.current_description().await },
+    ))
+}
+
+/// Set the agent description, stored in the agent state.
+///
+/// An empty string restores the description the agent was created with.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `description` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_set_description(
+    handle: *const ThymosAgent,
+    description: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(description_str) = cstr_to_string(description) else {
+        set_error("Invalid description: not valid UTF-8");
+        return -1;
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move { agent.set_description(description_str).await }) {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Get the agent persona (system context).
+///
+/// Returns an empty string when no persona is set.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_persona(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    let persona = block_on_value(async move { agent.persona().await });
+    string_to_cstring(persona.unwrap_or_default())
+}
+
+/// Set the agent persona (system context). An empty string clears it.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `persona` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_set_persona(
+    handle: *const ThymosAgent,
+    persona: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(persona_str) = cstr_to_string(persona) else {
+        set_error("Invalid persona: not valid UTF-8");
+        return -1;
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move { agent.set_persona(persona_str).await }) {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Agent Status
+// ============================================================================
+
+/// Get agent status as a string.
+///
+/// Returns one of: "Active", "Listening", "Dormant", "Archived"
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_status(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    let status = block_on_value(async move { agent.status().await });
+    string_to_cstring(format!("{:?}", status))
+}
+
+/// Set agent status.
+///
+/// Valid statuses: "active", "listening", "dormant", "archived" (case-insensitive)
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `status` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_set_status(
+    handle: *const ThymosAgent,
+    status: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(status_str) = cstr_to_string(status) else {
+        set_error("Invalid status: not valid UTF-8");
+        return -1;
+    };
+
+    let agent_status = match status_str.to_lowercase().as_str() {
+        "active" => AgentStatus::Active,
+        "listening" => AgentStatus::Listening,
+        "dormant" => AgentStatus::Dormant,
+        "archived" => AgentStatus::Archived,
+        _ => {
+            set_error(format!(
+                "Invalid status: {}. Valid values: active, listening, dormant, archived",
+                status_str
+            ));
+            return -1;
+        }
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move { agent.set_status(agent_status).await }) {
+        Ok(_) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Get full agent state.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned state must be freed with `thymos_free_agent_state`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_state(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut ThymosAgentState {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    let state = block_on_value(async move { agent.state().await });
+
+    Box::into_raw(Box::new(ThymosAgentState::from_state(&state)))
+}
+
+/// Check that the agent's local store is open and writable.
+///
+/// Stores a probe memory, reads it back and deletes it again, so a full disk
+/// or a corrupt database surfaces as a storage error. The probe is visible to
+/// concurrent readers for the duration of the call. An agent opened read-only
+/// is only checked to be readable: the probe counts its memories instead.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_ping(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    let agent = (*handle).inner.clone();
+    let read_only = (*handle).options.read_only;
+    let result = block_on(async move {
+        if read_only {
+            return count_memories(&agent).await.map(|_| ());
+        }
+
+        let manager = local_store(&agent)?.manager();
+        let mut probe = locai::models::MemoryBuilder::new_with_content("thymos ping").build();
+        probe.properties = serde_json::json!({ "thymos_ping": true });
+        let id = manager
+            .store_memory(probe)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?;
+
+        let read = manager.get_memory(&id).await;
+        let deleted = manager.delete_memory(&id).await;
+        match read.map_err(|e| CoreError::Memory(e.to_string()))? {
+            Some(memory) if memory.content == "thymos ping" => {}
+            _ => {
+                return Err(CoreError::Memory(
+                    "Ping probe could not be read back".to_string(),
+                ));
+            }
+        }
+        deleted.map_err(|e| CoreError::Memory(e.to_string()))?;
+        Ok(())
+    });
+
+    match result {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Memory Operations
+// ============================================================================
+
+/// Store a memory.
+///
+/// Returns the memory ID on success, or null on error.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `content` must be a valid null-terminated UTF-8 string.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    thymos_agent_remember_cancelable(handle, content, ptr::null(), out_error)
+}
+
+/// Like `thymos_agent_remember`, abandoning the write if `token` is canceled.
+///
+/// See `block_on_cancelable` for what a canceled write leaves behind.
+///
+/// # Safety
+/// Same as `thymos_agent_remember`; `token` must be a valid token or null (not
+/// cancelable).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_cancelable(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    token: *const ThymosCancelToken,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on_cancelable(token, async move { agent.remember(content_str).await }) {
+        Ok(Some(id)) => {
+            (*handle).invalidate_query_cache();
+            (*handle).enforce_retention(Some(1));
+            string_to_cstring(id)
+        }
+        Ok(None) => {
+            set_error_with_code(ERROR_CANCELED, CANCELED);
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Store a fact memory (durable, context-independent knowledge).
+///
+/// Facts are intended for knowledge like "Paris is the capital of France".
+///
+/// # Safety
+/// Same as `thymos_agent_remember`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_fact(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    thymos_agent_remember_fact_cancelable(handle, content, ptr::null(), out_error)
+}
+
+/// Like `thymos_agent_remember_fact`, abandoning the write if `token` is
+/// canceled.
+///
+/// See `block_on_cancelable` for what a canceled write leaves behind.
+///
+/// # Safety
+/// Same as `thymos_agent_remember_fact`; `token` must be a valid token or null
+/// (not cancelable).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_fact_cancelable(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    token: *const ThymosCancelToken,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    // Facts go to the shared backend in hybrid mode
+    let local = !matches!(agent.memory(), MemorySystem::Hybrid { .. });
+    match block_on_cancelable(token, async move { agent.remember_fact(content_str).await }) {
+        Ok(Some(id)) => {
+            (*handle).invalidate_query_cache();
+            (*handle).enforce_retention(Some(usize::from(local)));
+            string_to_cstring(id)
+        }
+        Ok(None) => {
+            set_error_with_code(ERROR_CANCELED, CANCELED);
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Store a conversation memory (dialogue context).
+///
+/// Conversation memories are intended for dialogue history and ephemeral context.
+///
+/// # Safety
+/// Same as `thymos_agent_remember`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_conversation(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    thymos_agent_remember_conversation_cancelable(handle, content, ptr::null(), out_error)
+}
+
+/// Like `thymos_agent_remember_conversation`, abandoning the write if `token`
+/// is canceled.
+///
+/// See `block_on_cancelable` for what a canceled write leaves behind.
+///
+/// # Safety
+/// Same as `thymos_agent_remember_conversation`; `token` must be a valid token
+/// or null (not cancelable).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_conversation_cancelable(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    token: *const ThymosCancelToken,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on_cancelable(token, async move {
+        agent.remember_conversation(content_str).await
+    }) {
+        Ok(Some(id)) => {
+            (*handle).invalidate_query_cache();
+            (*handle).enforce_retention(Some(1));
+            string_to_cstring(id)
+        }
+        Ok(None) => {
+            set_error_with_code(ERROR_CANCELED, CANCELED);
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Store a memory in the private backend (hybrid mode only).
+///
+/// # Safety
+/// Same as `thymos_agent_remember`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_private(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    thymos_agent_remember_private_cancelable(handle, content, ptr::null(), out_error)
+}
+
+/// Like `thymos_agent_remember_private`, abandoning the write if `token` is
+/// canceled.
+///
+/// See `block_on_cancelable` for what a canceled write leaves behind.
+///
+/// # Safety
+/// Same as `thymos_agent_remember_private`; `token` must be a valid token or
+/// null (not cancelable).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_private_cancelable(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    token: *const ThymosCancelToken,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on_cancelable(
+        token,
+        async move { agent.remember_private(content_str).await },
+    ) {
+        Ok(Some(id)) => {
+            (*handle).invalidate_query_cache();
+            (*handle).enforce_retention(Some(1));
+            string_to_cstring(id)
+        }
+        Ok(None) => {
+            set_error_with_code(ERROR_CANCELED, CANCELED);
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Store a memory in the shared backend (hybrid mode only).
+///
+/// # Safety
+/// Same as `thymos_agent_remember`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_shared(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    thymos_agent_remember_shared_cancelable(handle, content, ptr::null(), out_error)
+}
+
+/// Like `thymos_agent_remember_shared`, abandoning the write if `token` is
+/// canceled.
+///
+/// See `block_on_cancelable` for what a canceled write leaves behind.
+///
+/// # Safety
+/// Same as `thymos_agent_remember_shared`; `token` must be a valid token or
+/// null (not cancelable).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_shared_cancelable(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    token: *const ThymosCancelToken,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on_cancelable(
+        token,
+        async move { agent.remember_shared(content_str).await },
+    ) {
+        Ok(Some(id)) => {
+            (*handle).invalidate_query_cache();
+            string_to_cstring(id)
+        }
+        Ok(None) => {
+            set_error_with_code(ERROR_CANCELED, CANCELED);
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Store a memory with arbitrary properties.
+///
+/// `properties_json` must be a JSON object; its entries are stored as the
+/// memory's properties and round-trip through `thymos_agent_get_memory`.
+/// Memories go to the local store (the private backend in hybrid mode) and
+/// are embedded like those from `thymos_agent_remember`.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `content` and `properties_json` must be valid null-terminated UTF-8 strings.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_with_properties(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    properties_json: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    thymos_agent_remember_with_properties_cancelable(
+        handle,
+        content,
+        properties_json,
+        ptr::null(),
+        out_error,
+    )
+}
+
+/// Like `thymos_agent_remember_with_properties`, abandoning the write if
+/// `token` is canceled.
+///
+/// See `block_on_cancelable` for what a canceled write leaves behind.
+///
+/// # Safety
+/// Same as `thymos_agent_remember_with_properties`; `token` must be a valid
+/// token or null (not cancelable).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_with_properties_cancelable(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    properties_json: *const c_char,
+    token: *const ThymosCancelToken,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(properties_str) = cstr_to_string(properties_json) else {
+        set_error("Invalid properties_json: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let properties = match serde_json::from_str::<serde_json::Value>(&properties_str) {
+        Ok(value @ serde_json::Value::Object(_)) => value,
+        Ok(_) => {
+            set_error("Invalid properties_json: expected a JSON object");
+            return ptr::null_mut();
+        }
+        Err(e) => {
+            set_error(format!("Invalid properties_json: {}", e));
+            return ptr::null_mut();
+        }
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on_cancelable(token, async move {
+        store_embedded(
+            &agent,
+            content_str,
+            locai::models::MemoryType::Episodic,
+            properties,
+        )
+        .await
+    }) {
+        Ok(Some(id)) => {
+            (*handle).invalidate_query_cache();
+            (*handle).enforce_retention(Some(1));
+            string_to_cstring(id)
+        }
+        Ok(None) => {
+            set_error_with_code(ERROR_CANCELED, CANCELED);
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Store many memories in one call.
+///
+/// Each content is stored like `thymos_agent_remember`, in order. When the
+/// agent has an embedding provider and a local store, contents are embedded
+/// in batches of the configured embedding batch size, and an embedding call
+/// that fails, or returns a different number of embeddings than it was
+/// given contents, fails every item of its batch. A failed item does not stop
+/// the batch:
+/// `*out_ids` and `*out_errors` are parallel to `contents`, holding the new
+/// memory ID or "" and the error message or "" respectively.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `contents` must point to `count` valid null-terminated UTF-8 strings.
+/// `out_ids` and `out_errors` must be valid pointers.
+/// `*out_ids` and `*out_errors` must be freed with `thymos_free_string_list`.
+///
+/// Returns the number of memories stored, or -1 on error (nothing stored).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_batch(
+    handle: *const ThymosAgent,
+    contents: *const *const c_char,
+    count: usize,
+    out_ids: *mut *mut ThymosStringList,
+    out_errors: *mut *mut ThymosStringList,
+    out_error: *mut ThymosError,
+) -> i64 {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    if out_ids.is_null() || out_errors.is_null() {
+        set_error("out_ids or out_errors is null");
+        return -1;
+    }
+
+    let Some(contents) = cstr_array_to_vec(contents, count) else {
+        set_error("Invalid contents: null or not valid UTF-8");
+        return -1;
+    };
+
+    let agent = (*handle).inner.clone();
+    let batch_size = (*handle).options.embedding_batch_size;
+    let outcomes = block_on_value(async move {
+        let mut outcomes = Vec::with_capacity(contents.len());
+        let (Some(provider), Ok(store)) = (agent.embedding_provider(), local_store(&agent)) else {
+            for content in contents {
+                outcomes.push(agent.remember(content).await.map_err(|e| e.to_string()));
+            }
+            return outcomes;
+        };
+
+        for chunk in contents.chunks(batch_size) {
+            let texts: Vec<&str> = chunk.iter().map(String::as_str).collect();
+            let embeddings = match provider.embed_batch(&texts).await {
+                Ok(embeddings) if embeddings.len() == chunk.len() => embeddings,
+                Ok(embeddings) => {
+                    let message = format!(
+                        "Embedding provider returned {} embeddings for {} contents",
+                        embeddings.len(),
+                        chunk.len()
+                    );
+                    outcomes.extend(chunk.iter().map(|_| Err(message.clone())));
+                    continue;
+                }
+                Err(e) => {
+                    let message = e.to_string();
+                    outcomes.extend(chunk.iter().map(|_| Err(message.clone())));
+                    continue;
+                }
+            };
+            for (content, embedding) in chunk.iter().zip(embeddings) {
+                let mut memory =
+                    locai::models::MemoryBuilder::new_with_content(content.clone()).build();
+                memory.memory_type = locai::models::MemoryType::Episodic;
+                memory.embedding = Some(embedding);
+                outcomes.push(
+                    store
+                        .manager()
+                        .store_memory(memory)
+                        .await
+                        .map_err(|e| CoreError::Memory(e.to_string()).to_string()),
+                );
+            }
+        }
+        outcomes
+    });
+    (*handle).invalidate_query_cache();
+
+    let mut stored = 0;
+    let mut ids = Vec::with_capacity(outcomes.len());
+    let mut errors = Vec::with_capacity(outcomes.len());
+    for outcome in outcomes {
+        match outcome {
+            Ok(id) => {
+                stored += 1;
+                ids.push(id);
+                errors.push(String::new());
+            }
+            Err(e) => {
+                ids.push(String::new());
+                errors.push(e);
+            }
+        }
+    }
+    (*handle).enforce_retention(Some(stored as usize));
+    *out_ids = Box::into_raw(Box::new(ThymosStringList::from_strings(ids)));
+    *out_errors = Box::into_raw(Box::new(ThymosStringList::from_strings(errors)));
+    stored
+}
+
+/// Delete a memory by ID.
+///
+/// Fails with "memory not found" if no memory has the ID.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_forget(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return -1;
+    };
+
+    let agent = (*handle).inner.clone();
+    let forgotten_id = id.clone();
+    match block_on(async move { agent.forget(&id).await }) {
+        Ok(true) => {
+            let handle = &*handle;
+            handle.invalidate_query_cache();
+            handle.access_counts.lock().unwrap().remove(&forgotten_id);
+            if let Some(timelines) = &handle.access_timelines {
+                timelines.lock().unwrap().remove(&forgotten_id);
+            }
+            0
+        }
+        Ok(false) => {
+            set_error_with_code(ERROR_NOT_FOUND, "memory not found");
+            -1
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Replace the content of a stored memory, keeping its ID and creation time.
+///
+/// The memory is re-embedded with the agent's embedding provider; without a
+/// provider its stale embedding is dropped. `last_accessed` is set to now.
+/// Fails with "memory not found" if no local memory has the ID.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` and `content` must be valid null-terminated UTF-8 strings.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_update_memory(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    content: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return -1;
+    };
+
+    let Some(content) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return -1;
+    };
+
+    let agent = (*handle).inner.clone();
+    let locks = (*handle).memory_locks.clone();
+    let changed_ids = (*handle).changed_ids.clone();
+    let result = block_on(async move {
+        let _guard = locks.lock(&id).await;
+        let store = local_store(&agent)?;
+        let Some(mut memory) = store
+            .manager()
+            .get_memory(&id)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?
+        else {
+            return Ok(false);
+        };
+
+        memory.embedding = match agent.embedding_provider() {
+            Some(provider) => Some(provider.embed(&content).await?),
+            None => None,
+        };
+        memory.content = content;
+        let now = chrono::Utc::now();
+        memory.last_accessed = Some(now);
+        stamp_modified(&mut memory, now);
+        store
+            .manager()
+            .update_memory(memory)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?;
+        changed_ids.lock().unwrap().insert(id);
+        Ok(true)
+    });
+    (*handle).invalidate_query_cache();
+
+    match result {
+        Ok(true) => 0,
+        Ok(false) => {
+            set_error_with_code(ERROR_NOT_FOUND, "memory not found");
+            -1
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Bulk Import
+// ============================================================================
+
+/// Store a memory of the given type with properties in the agent's local
+/// store, embedding it with the agent's provider if it has one.
+async fn store_embedded(
+    agent: &Agent,
+    content: String,
+    memory_type: locai::models::MemoryType,
+    properties: serde_json::Value,
+) -> Result<String> {
+    let store = local_store(agent)?;
+    let mut memory = locai::models::MemoryBuilder::new_with_content(content).build();
+    memory.memory_type = memory_type;
+    memory.properties = properties;
+    if let Some(provider) = agent.embedding_provider() {
+        memory.embedding = Some(provider.embed(&memory.content).await?);
+    }
+    store
+        .manager()
+        .store_memory(memory)
+        .await
+        .map_err(|e| CoreError::Memory(e.to_string()))
+}
+
+/// Store records in order like `store_embedded`, as episodic memories,
+/// embedding them `batch_size` at a time with one provider call per batch.
+///
+/// Stops at the first failure, leaving the memories stored before it;
+/// `stored` counts them.
+async fn store_embedded_batch(
+    agent: &Agent,
+    records: &[ImportRecord],
+    batch_size: usize,
+    stored: &mut usize,
+) -> Result<()> {
+    let store = local_store(agent)?;
+    for chunk in records.chunks(batch_size) {
+        let embeddings: Vec<Option<Vec<f32>>> = match agent.embedding_provider() {
+            Some(provider) => {
+                let texts: Vec<&str> = chunk.iter().map(|r| r.content.as_str()).collect();
+                let embeddings = provider.embed_batch(&texts).await?;
+                if embeddings.len() != chunk.len() {
+                    return Err(CoreError::Memory(format!(
+                        "Embedding provider returned {} embeddings for {} contents",
+                        embeddings.len(),
+                        chunk.len()
+                    )));
+                }
+                embeddings.into_iter().map(Some).collect()
+            }
+            None => vec![None; chunk.len()],
+        };
+        for (record, embedding) in chunk.iter().zip(embeddings) {
+            let mut memory =
+                locai::models::MemoryBuilder::new_with_content(record.content.clone()).build();
+            memory.memory_type = locai::models::MemoryType::Episodic;
+            memory.properties = record.properties.clone();
+            memory.embedding = embedding;
+            store
+                .manager()
+                .store_memory(memory)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+            *stored += 1;
+        }
+    }
+    Ok(())
+}
+
+/// A parsed import row.
+struct ImportRecord {
+    content: String,
+    properties: serde_json::Value,
+}
+
+/// A malformed import row.
+struct ImportRowError {
+    line: usize,
+    message: String,
+}
+
+/// Longest content, in bytes, an import row may hold: the Go binding's
+/// `MaxContentLength`, which its other write methods check before calling in.
+const MAX_IMPORT_CONTENT_LENGTH: usize = 1 << 20;
+
+/// Reject the content of the row on `line` if it is too long to store.
+fn check_import_content(content: &str, line: usize) -> std::result::Result<(), ImportRowError> {
+    if content.len() > MAX_IMPORT_CONTENT_LENGTH {
+        return Err(ImportRowError {
+            line,
+            message: format!(
+                "content too large: {} bytes (limit {} bytes)",
+                content.len(),
+                MAX_IMPORT_CONTENT_LENGTH
+            ),
+        });
+    }
+    Ok(())
+}
+
+/// Parse a JSONL file of `{"content": "...", "properties": {...}}` objects.
+///
+/// Blank lines are skipped.
+fn parse_jsonl(data: &str) -> std::result::Result<Vec<ImportRecord>, ImportRowError> {
+    #[derive(Deserialize)]
+    struct Row {
+        content: String,
+        #[serde(default)]
+        properties: serde_json::Map<String, serde_json::Value>,
+    }
+
+    let mut records = Vec::new();
+    for (index, line) in data.lines().enumerate() {
+        if line.trim().is_empty() {
+            continue;
+        }
+        let row: Row = serde_json::from_str(line).map_err(|e| ImportRowError {
+            line: index + 1,
+            message: e.to_string(),
+        })?;
+        check_import_content(&row.content, index + 1)?;
+        records.push(ImportRecord {
+            content: row.content,
+            properties: serde_json::Value::Object(row.properties),
+        });
+    }
+    Ok(records)
+}
+
+/// Parse a CSV file with a header row.
+///
+/// `content_column` names the content column; `property_columns` names the
+/// columns stored as string properties (empty = every other column).
+fn parse_csv(
+    data: &str,
+    content_column: &str,
+    property_columns: &[String],
+) -> std::result::Result<Vec<ImportRecord>, ImportRowError> {
+    let mut reader = csv::ReaderBuilder::new().from_reader(data.as_bytes());
+    let headers = reader
+        .headers()
+        .map_err(|e| ImportRowError {
+            line: 1,
+            message: e.to_string(),
+        })?
+        .clone();
+
+    let Some(content_index) = headers.iter().position(|h| h == content_column) else {
+        return Err(ImportRowError {
+            line: 1,
+            message: format!("content column '{}' not found in header", content_column),
+        });
+    };
+
+    let mut property_indexes = Vec::new();
+    for (index, header) in headers.iter().enumerate() {
+        let wanted = if property_columns.is_empty() {
+            index != content_index
+        } else {
+            property_columns.iter().any(|c| c == header)
+        };
+        if wanted {
+            property_indexes.push((index, header.to_string()));
+        }
+    }
+    if let Some(missing) = property_columns
+        .iter()
+        .find(|c| !headers.iter().any(|h| h == c.as_str()))
+    {
+        return Err(ImportRowError {
+            line: 1,
+            message: format!("property column '{}' not found in header", missing),
+        });
+    }
+
+    let mut records = Vec::new();
+    // Line of the last row read, to place errors the reader gives no
+    // position for just after it
+    let mut last_line = 1;
+    for row in reader.records() {
+        let row = row.map_err(|e| ImportRowError {
+            line: e.position().map_or(last_line + 1, |p| p.line() as usize),
+            message: e.to_string(),
+        })?;
+        let line = row.position().map_or(last_line + 1, |p| p.line() as usize);
+        last_line = line;
+
+        let content = row.get(content_index).unwrap_or_default();
+        if content.trim().is_empty() {
+            return Err(ImportRowError {
+                line,
+                message: "empty content".to_string(),
+            });
+        }
+        check_import_content(content, line)?;
+
+        let mut properties = serde_json::Map::new();
+        for (index, name) in &property_indexes {
+            if let Some(value) = row.get(*index) {
+                properties.insert(name.clone(), serde_json::Value::String(value.to_string()));
+            }
+        }
+        records.push(ImportRecord {
+            content: content.to_string(),
+            properties: serde_json::Value::Object(properties),
+        });
+    }
+    Ok(records)
+}
+
+/// Import memories from a JSONL or CSV file.
+///
+/// The whole file is parsed before anything is stored, so a malformed row, or
+/// one whose content is longer than `MAX_IMPORT_CONTENT_LENGTH`, imports
+/// nothing; its 1-based line number is written to `out_error_line`.
+/// Rows are stored as episodic memories and embedded like
+/// `thymos_agent_remember_batch`, in batches of the configured embedding
+/// batch size. A failure while storing leaves the memories stored before it
+/// in place.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `path` - File path
+/// * `format` - 0 = JSONL, 1 = CSV
+/// * `content_column` - CSV content column (null = "content")
+/// * `property_columns` - CSV property columns (empty = all other columns)
+/// * `property_count` - Number of property columns
+/// * `out_error_line` - Receives the line of the first malformed row, or 0
+/// * `out_imported` - Receives the number of memories stored, on failure as
+///   well as on success
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `path` must be a valid null-terminated UTF-8 string.
+/// `property_columns` must point to `property_count` valid strings.
+/// `out_error_line` and `out_imported` must be valid pointers.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_import_file(
+    handle: *const ThymosAgent,
+    path: *const c_char,
+    format: c_int,
+    content_column: *const c_char,
+    property_columns: *const *const c_char,
+    property_count: usize,
+    out_error_line: *mut usize,
+    out_imported: *mut usize,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if out_error_line.is_null() || out_imported.is_null() {
+        set_error("Output pointers are null");
+        return -1;
+    }
+    *out_error_line = 0;
+    *out_imported = 0;
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(path_str) = cstr_to_string(path) else {
+        set_error("Invalid path: not valid UTF-8");
+        return -1;
+    };
+
+    let Some(columns) = cstr_array_to_vec(property_columns, property_count) else {
+        set_error("Invalid property_columns: null or not valid UTF-8");
+        return -1;
+    };
+    let content_column = cstr_to_string(content_column).unwrap_or_else(|| "content".to_string());
+
+    let data = match std::fs::read_to_string(&path_str) {
+        Ok(data) => data,
+        Err(e) => {
+            set_error_with_code(ERROR_STORAGE, format!("Failed to read {}: {}", path_str, e));
+            return -1;
+        }
+    };
+
+    let parsed = match format {
+        0 => parse_jsonl(&data),
+        1 => parse_csv(&data, &content_column, &columns),
+        _ => {
+            set_error(format!("Invalid import format: {}", format));
+            return -1;
+        }
+    };
+    let records = match parsed {
+        Ok(records) => records,
+        Err(e) => {
+            *out_error_line = e.line;
+            set_error(format!("{}: line {}: {}", path_str, e.line, e.message));
+            return -1;
+        }
+    };
+
+    let agent = (*handle).inner.clone();
+    let batch_size = (*handle).options.embedding_batch_size;
+    let (imported, result) = block_on_value(async move {
+        let mut imported = 0;
+        let result = store_embedded_batch(&agent, &records, batch_size, &mut imported).await;
+        (imported, result)
+    });
+    *out_imported = imported;
+    (*handle).invalidate_query_cache();
+    (*handle).enforce_retention(Some(imported));
+
+    match result {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Cancellation
+// ============================================================================
+
+/// Error message set when an operation is canceled through its token.
+const CANCELED: &str = "operation canceled";
+
+/// Shared state of a cancellation token.
+#[derive(Default)]
+struct CancelState {
+    canceled: std::sync::atomic::AtomicBool,
+    notify: tokio::sync::Notify,
+}
+
+impl CancelState {
+    /// Resolve once the token is canceled.
+    async fn canceled(&self) {
+        loop {
+            let notified = self.notify.notified();
+            if self.canceled.load(std::sync::atomic::Ordering::SeqCst) {
+                return;
+            }
+            notified.await;
+        }
+    }
+}
+
+/// Run a future to completion, or until `token` is canceled.
+///
+/// Returns `Ok(None)` if the token was canceled first. The future is then
+/// dropped at its next await point: a write canceled while its content is
+/// being embedded stores nothing, but one canceled once the store has started
+/// writing may still be stored.
+///
+/// # Safety
+/// `token` must be a valid token or null (not cancelable).
+unsafe fn block_on_cancelable<F, T>(token: *const ThymosCancelToken, future: F) -> Result<Option<T>>
+where
+    F: std::future::Future<Output = Result<T>> + Send + 'static,
+    T: Send + 'static,
+{
+    let cancel = token.as_ref().map(|t| t.state.clone());
+    block_on(async move {
+        match cancel {
+            Some(cancel) => tokio::select! {
+                result = future => result.map(Some),
+                _ = cancel.canceled() => Ok(None),
+            },
+            None => future.await.map(Some),
+        }
+    })
+}
+
+/// Opaque handle for a cancellation token.
+pub struct ThymosCancelToken {
+    state: Arc<CancelState>,
+}
+
+/// Create a cancellation token.
+///
+/// The returned token must be freed with `thymos_cancel_token_free`.
+#[unsafe(no_mangle)]
+pub extern "C" fn thymos_cancel_token_new() -> *mut ThymosCancelToken {
+    Box::into_raw(Box::new(ThymosCancelToken {
+        state: Arc::new(CancelState::default()),
+    }))
+}
+
+/// Cancel every operation running with the token.
+///
+/// Canceled operations fail with "operation canceled". Canceling twice is a
+/// no-op. May be called from any thread while an operation is running.
+///
+/// # Safety
+/// `token` must be a valid token or null.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_cancel_token_cancel(token: *const ThymosCancelToken) {
+    if let Some(token) = token.as_ref() {
+        token
+            .state
+            .canceled
+            .store(true, std::sync::atomic::Ordering::SeqCst);
+        token.state.notify.notify_waiters();
+    }
+}
+
+/// Free a cancellation token.
+///
+/// # Safety
+/// `token` must have been created by `thymos_cancel_token_new`, or be null,
+/// and no operation may still be using it.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_cancel_token_free(token: *mut ThymosCancelToken) {
+    if !token.is_null() {
+        drop(Box::from_raw(token));
+    }
+}
+
+// ============================================================================
+// Memory Search
+// ============================================================================
+
+/// Search memories.
+///
+/// Results are served from the query cache when one is configured with
+/// `thymos_memory_config_set_query_cache`.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `query` - Search query string
+/// * `limit` - Maximum number of results (0 = no limit)
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `query` must be a valid null-terminated UTF-8 string.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_memories(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    thymos_agent_search_memories_cancelable(handle, query, limit, ptr::null(), out_error)
+}
+
+/// Search memories, abandoning the search if `token` is canceled.
+///
+/// Behaves like `thymos_agent_search_memories`; a search canceled before it
+/// completes fails with "operation canceled".
+///
+/// # Safety
+/// Same as `thymos_agent_search_memories`; `token` must be a valid token or
+/// null (not cancelable).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_memories_cancelable(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    token: *const ThymosCancelToken,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    search_ranked(handle, query, limit, 0.0, token)
+}
+
+/// Search memories, dropping results scored below `min_score`.
+///
+/// Behaves like `thymos_agent_search_memories`, except that results whose
+/// `score` is below `min_score` are dropped before `limit` is applied, so up to
+/// `limit` results at or above the threshold are returned.
+///
+/// # Safety
+/// Same as `thymos_agent_search_memories`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_memories_threshold(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    min_score: f64,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if min_score.is_nan() {
+        set_error("Invalid min_score: NaN");
+        return ptr::null_mut();
+    }
+    search_ranked(handle, query, limit, min_score, ptr::null())
+}
+
+/// Run a ranked search, optionally cancelable, keeping up to `limit` results
+/// scored at least `min_score`.
+unsafe fn search_ranked(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    min_score: f64,
+    token: *const ThymosCancelToken,
+) -> *mut ThymosSearchResults {
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let cache = (*handle).query_cache.as_ref();
+    let mut generation = 0;
+    if let Some(cache) = cache {
+        let mut cache = cache.lock().unwrap();
+        if let Some((memories, scores)) = cache.get(&query_str, limit) {
+            return ranked_results(&*handle, memories, scores, limit, min_score);
+        }
+        generation = cache.generation;
+    }
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    let cache_key = query_str.clone();
+    match block_on_cancelable(token, async move {
+        scored_search(&agent, &shared_ids, &query_str).await
+    }) {
+        Ok(Some((memories, scores))) => {
+            if let Some(cache) = cache {
+                cache.lock().unwrap().insert(
+                    generation,
+                    cache_key,
+                    limit,
+                    (memories.clone(), scores.clone()),
+                );
+            }
+            ranked_results(&*handle, memories, scores, limit, min_score)
+        }
+        Ok(None) => {
+            set_error_with_code(ERROR_CANCELED, CANCELED);
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Search `agent`'s memories for `query`, scoring each result with
+/// `relevance_scores`.
+async fn scored_search(
+    agent: &Agent,
+    shared_ids: &Mutex<HashSet<String>>,
+    query: &str,
+) -> Result<ScoredMemories> {
+    let memories = search_noting_shared(agent, shared_ids, query, None).await?;
+    let scores = relevance_scores(agent, query, &memories).await?;
+    Ok((memories, scores))
+}
+
+/// Relevance of each memory to `query`, from 0.0 (unrelated) to 1.0.
+///
+/// The store does not expose its own scores, so they are computed here. A
+/// memory whose embedding has the dimension of the query's embedding scores
+/// their cosine similarity, floored at 0. Other memories, and all memories of
+/// an agent without an embedding provider, score the fraction of the query's
+/// distinct terms found in their content. The scores do not depend on the
+/// other results, so they can be compared across queries, backends and
+/// agents.
+async fn relevance_scores(
+    agent: &Agent,
+    query: &str,
+    memories: &[locai::models::Memory],
+) -> Result<Vec<f64>> {
+    let query_embedding = match agent.embedding_provider() {
+        Some(provider) if memories.iter().any(|m| m.embedding.is_some()) => {
+            Some(provider.embed(query).await?)
+        }
+        _ => None,
+    };
+    let query_terms: HashSet<String> = tokenize(query).collect();
+    Ok(memories
+        .iter()
+        .map(|memory| match (&query_embedding, &memory.embedding) {
+            (Some(target), Some(embedding)) if target.len() == embedding.len() => {
+                cosine_similarity(embedding, target).max(0.0) as f64
+            }
+            _ => term_overlap(&query_terms, &memory.content),
+        })
+        .collect())
+}
+
+/// Fraction of `query_terms` that occur in `content`.
+fn term_overlap(query_terms: &HashSet<String>, content: &str) -> f64 {
+    if query_terms.is_empty() {
+        return 0.0;
+    }
+    let terms: HashSet<String> = tokenize(content).collect();
+    query_terms.intersection(&terms).count() as f64 / query_terms.len() as f64
+}
+
+/// Box the live search results up to `limit` scored at least `min_score`
+/// (see `ThymosAgent::live_ranked`), counting an access of each.
+fn ranked_results(
+    handle: &ThymosAgent,
+    memories: Vec<locai::models::Memory>,
+    scores: Vec<f64>,
+    limit: usize,
+    min_score: f64,
+) -> *mut ThymosSearchResults {
+    let (memories, scores) = handle.live_ranked(memories, scores, limit, min_score);
+    handle.record_access(&memories);
+    handle.checked_results(&memories, &scores)
+}
+
+/// Run many searches in one call.
+///
+/// Each query is searched like `thymos_agent_search_memories` with the same
+/// `limit`, and queries the query cache cannot serve are searched
+/// concurrently. Results are returned flattened in query order, with
+/// `out_counts[i]` receiving the number of results for query i. A failed query
+/// fails the whole call.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `queries` must point to `count` valid null-terminated UTF-8 strings.
+/// `out_counts` must point to `count` writable values.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_many(
+    handle: *const ThymosAgent,
+    queries: *const *const c_char,
+    count: usize,
+    limit: usize,
+    out_counts: *mut usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if out_counts.is_null() {
+        set_error("out_counts is null");
+        return ptr::null_mut();
+    }
+
+    let Some(queries) = cstr_array_to_vec(queries, count) else {
+        set_error("Invalid queries: null or not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let cache = (*handle).query_cache.as_ref();
+    let mut generation = 0;
+    let mut found: Vec<Option<ScoredMemories>> = vec![None; count];
+    if let Some(cache) = cache {
+        let mut cache = cache.lock().unwrap();
+        for (slot, query) in found.iter_mut().zip(&queries) {
+            *slot = cache.get(query, limit);
+        }
+        generation = cache.generation;
+    }
+
+    let misses: Vec<(usize, String)> = queries
+        .iter()
+        .enumerate()
+        .filter(|(i, _)| found[*i].is_none())
+        .map(|(i, query)| (i, query.clone()))
+        .collect();
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    let searched = block_on(async move {
+        let tasks: Vec<_> = misses
+            .into_iter()
+            .map(|(i, query)| {
+                let agent = agent.clone();
+                let shared_ids = shared_ids.clone();
+                (
+                    i,
+                    tokio::spawn(async move { scored_search(&agent, &shared_ids, &query).await }),
+                )
+            })
+            .collect();
+        let mut searched = Vec::with_capacity(tasks.len());
+        for (i, task) in tasks {
+            let results = task.await.map_err(|e| CoreError::Memory(e.to_string()))??;
+            searched.push((i, results));
+        }
+        Ok(searched)
+    });
+
+    let searched = match searched {
+        Ok(searched) => searched,
+        Err(e) => {
+            set_thymos_error(&e);
+            return ptr::null_mut();
+        }
+    };
+    for (i, results) in searched {
+        if let Some(cache) = cache {
+            cache
+                .lock()
+                .unwrap()
+                .insert(generation, queries[i].clone(), limit, results.clone());
+        }
+        found[i] = Some(results);
+    }
+
+    // Rank each query's results as search_ranked does, so a query returns
+    // the same results here as from thymos_agent_search_memories
+    let counts = std::slice::from_raw_parts_mut(out_counts, count);
+    let mut memories = Vec::new();
+    let mut scores = Vec::new();
+    for (slot, results) in counts.iter_mut().zip(found) {
+        let (results, scored) = results.unwrap_or_default();
+        let (live, live_scores) = (*handle).live_ranked(results, scored, limit, 0.0);
+        *slot = live.len();
+        memories.extend(live);
+        scores.extend(live_scores);
+    }
+    (*handle).record_access(&memories);
+    (*handle).checked_results(&memories, &scores)
+}
+
+/// Get query cache hit and miss counts.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `out_hits` and `out_misses` must be valid pointers.
+///
+/// Returns 0 on success, -1 on error (including when no cache is configured).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_query_cache_stats(
+    handle: *const ThymosAgent,
+    out_hits: *mut u64,
+    out_misses: *mut u64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if out_hits.is_null() || out_misses.is_null() {
+        set_error("Output pointers are null");
+        return -1;
+    }
+
+    let Some(cache) = &(*handle).query_cache else {
+        set_error_with_code(ERROR_CONFIGURATION, "Query cache is not enabled");
+        return -1;
+    };
+
+    let cache = cache.lock().unwrap();
+    *out_hits = cache.hits;
+    *out_misses = cache.misses;
+    0
+}
+
+/// Search memories and report which results the search reinforced.
+///
+/// The local store (the private backend in hybrid mode) updates the last
+/// access time of every memory a search retrieves from it, so the reinforced
+/// IDs are the returned memories that came from the local store; server and
+/// shared backends keep their own access bookkeeping and are not reported.
+/// `limit` is passed to the store, so no memory is reinforced without being
+/// returned. A hybrid agent fills the limit with private matches first and
+/// tops it up with shared ones, unlike the newest-first merge of
+/// `thymos_agent_search_memories`, so no private match is cut off after the
+/// store has reinforced it.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `query` - Search query string
+/// * `limit` - Maximum number of results (0 = no limit)
+/// * `out_reinforced` - Receives the reinforced memory IDs
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `query` must be a valid null-terminated UTF-8 string.
+/// `out_reinforced` must be a valid pointer.
+/// The returned results must be freed with `thymos_free_search_results` and
+/// `*out_reinforced` with `thymos_free_string_list`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_memories_reinforced(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    out_reinforced: *mut *mut ThymosStringList,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if out_reinforced.is_null() {
+        set_error("out_reinforced is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    let limit = (limit > 0).then_some(limit);
+    match block_on(async move {
+        let memory = agent.memory();
+        let (memories, reinforced) = match memory {
+            MemorySystem::Single { .. } => {
+                let memories = memory.search(&query_str, limit).await?;
+                let reinforced: Vec<String> = memories.iter().map(|m| m.id.clone()).collect();
+                (memories, reinforced)
+            }
+            MemorySystem::Server { .. } => (memory.search(&query_str, limit).await?, Vec::new()),
+            MemorySystem::Hybrid { .. } => {
+                let mut memories = memory
+                    .search_with_scope(&query_str, SearchScope::Private, limit)
+                    .await?;
+                let reinforced: Vec<String> = memories.iter().map(|m| m.id.clone()).collect();
+                let remaining = limit.map(|limit| limit.saturating_sub(memories.len()));
+                let shared = if remaining == Some(0) {
+                    Vec::new()
+                } else {
+                    memory
+                        .search_with_scope(&query_str, SearchScope::Shared, remaining)
+                        .await?
+                };
+                {
+                    let mut ids = shared_ids.lock().unwrap();
+                    for id in &reinforced {
+                        ids.remove(id);
+                    }
+                    ids.extend(shared.iter().map(|m| m.id.clone()));
+                }
+                memories.extend(shared);
+                memories.sort_by(|a, b| b.created_at.cmp(&a.created_at));
+                (memories, reinforced)
+            }
+        };
+        // Expired memories are not returned, so they are not reported either
+        let now = chrono::Utc::now();
+        let (memories, expired): (Vec<_>, Vec<_>) =
+            memories.into_iter().partition(|m| !memory_expired(m, now));
+        let reinforced = reinforced
+            .into_iter()
+            .filter(|id| !expired.iter().any(|m| &m.id == id))
+            .collect::<Vec<_>>();
+        Ok((memories, reinforced))
+    }) {
+        Ok((memories, reinforced)) => {
+            (*handle).record_access(&memories);
+            *out_reinforced = Box::into_raw(Box::new(ThymosStringList::from_strings(reinforced)));
+            (*handle).results(&memories)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Group key for a memory: the property value as a string, or "" if absent.
+fn property_group_key(memory: &locai::models::Memory, key: &str) -> String {
+    match memory.properties.get(key) {
+        None | Some(serde_json::Value::Null) => String::new(),
+        Some(serde_json::Value::String(s)) => s.clone(),
+        Some(other) => other.to_string(),
+    }
+}
+
+/// Search memories and group the results by a property.
+///
+/// Results are returned in relevance order with a parallel list of group keys
+/// (`*out_keys`), one per memory. Memories without the property are grouped
+/// under "". At most `limit` memories are returned per group.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `query` - Search query string
+/// * `limit` - Maximum number of results per group (0 = no limit)
+/// * `group_by` - Property key to group by
+/// * `out_keys` - Receives the group key of each returned memory
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `query` and `group_by` must be valid null-terminated UTF-8 strings.
+/// `out_keys` must be a valid pointer.
+/// The returned results must be freed with `thymos_free_search_results` and
+/// `*out_keys` with `thymos_free_string_list`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_grouped(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    group_by: *const c_char,
+    out_keys: *mut *mut ThymosStringList,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if out_keys.is_null() {
+        set_error("out_keys is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(group_key) = cstr_to_string(group_by) else {
+        set_error("Invalid group_by: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    match block_on(async move {
+        // Every match is needed, as a small group may rank behind large ones
+        let mut group_sizes: HashMap<String, usize> = HashMap::new();
+        let mut memories = Vec::new();
+        let mut keys = Vec::new();
+
+        for memory in filtered_search(&agent, &shared_ids, &query_str, 0, |_| true).await? {
+            let key = property_group_key(&memory, &group_key);
+            let size = group_sizes.entry(key.clone()).or_insert(0);
+            if limit > 0 && *size >= limit {
+                continue;
+            }
+            *size += 1;
+            memories.push(memory);
+            keys.push(key);
+        }
+        Ok((memories, keys))
+    }) {
+        Ok((memories, keys)) => {
+            (*handle).record_access(&memories);
+            *out_keys = Box::into_raw(Box::new(ThymosStringList::from_strings(keys)));
+            (*handle).checked_results(&memories, &[])
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Hours after which the `recency` ranking variable halves.
+const RECENCY_HALF_LIFE_HOURS: f64 = 168.0;
+
+/// Compute the ranking variables for the candidate at `rank` of `total`.
+fn score_vars(
+    agent: &Agent,
+    memory: &locai::models::Memory,
+    score: f64,
+    access_counts: &HashMap<String, u64>,
+) -> ScoreVars {
+    let last = memory.last_accessed.unwrap_or(memory.created_at);
+    let hours = (chrono::Utc::now() - last).num_seconds().max(0) as f64 / 3600.0;
+    ScoreVars {
+        score,
+        recency: 0.5f64.powf(hours / RECENCY_HALF_LIFE_HOURS),
+        retention: agent.memory().calculate_strength(memory),
+        access_count: access_counts.get(&memory.id).copied().unwrap_or(0) as f64,
+    }
+}
+
+/// Search memories and rerank them with a custom formula.
+///
+/// The formula is evaluated per candidate over these variables:
+/// * `score` - relevance to the query, as in `thymos_agent_search_memories`
+/// * `recency` - 0.5^(hours since last access / 168), 1.0 when just accessed
+/// * `retention` - current strength on the forgetting curve (0.0-1.0)
+/// * `access_count` - retrievals of the memory through this agent handle
+///
+/// Results are sorted by formula value, highest first.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `query` - Search query string
+/// * `limit` - Maximum number of results (0 = no limit)
+/// * `formula` - Ranking expression, e.g. "0.7*score + 0.3*recency"
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `query` and `formula` must be valid null-terminated UTF-8 strings.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_reranked(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    formula: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(formula_str) = cstr_to_string(formula) else {
+        set_error("Invalid formula: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let ranking = match Formula::parse(&formula_str) {
+        Ok(ranking) => ranking,
+        Err(e) => {
+            set_error(format!("Invalid formula: {}", e));
+            return ptr::null_mut();
+        }
+    };
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    let access_counts = (*handle).access_counts.lock().unwrap().clone();
+    match block_on(async move {
+        let candidates =
+            search_candidates(&agent, &shared_ids, &query_str, limit.max(10) * 5).await?;
+        let relevance = relevance_scores(&agent, &query_str, &candidates).await?;
+
+        let mut scored: Vec<(f64, locai::models::Memory)> = candidates
+            .into_iter()
+            .zip(relevance)
+            .map(|(memory, relevance)| {
+                let vars = score_vars(&agent, &memory, relevance, &access_counts);
+                (ranking.eval(&vars), memory)
+            })
+            .collect();
+
+        scored.sort_by(|a, b| b.0.total_cmp(&a.0));
+        if limit > 0 {
+            scored.truncate(limit);
+        }
+        let (scores, memories): (Vec<f64>, Vec<_>) = scored.into_iter().unzip();
+        Ok((memories, scores))
+    }) {
+        Ok((memories, scores)) => {
+            (*handle).record_access(&memories);
+            (*handle).scored_results(&memories, &scores)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Search memories and explain each result's score.
+///
+/// Results are in regular search order with a parallel list of JSON objects
+/// (`*out_components`), one per memory, holding its score components:
+/// * `semantic` - relevance to the query, as in `thymos_agent_search_memories`
+/// * `recency_boost` - 0.5^(hours since last access / 168)
+/// * `retention_factor` - current strength on the forgetting curve
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `query` - Search query string
+/// * `limit` - Maximum number of results (0 = no limit)
+/// * `out_components` - Receives the score components of each returned memory
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `query` must be a valid null-terminated UTF-8 string.
+/// `out_components` must be a valid pointer.
+/// The returned results must be freed with `thymos_free_search_results` and
+/// `*out_components` with `thymos_free_string_list`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_explained(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    out_components: *mut *mut ThymosStringList,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if out_components.is_null() {
+        set_error("out_components is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    let access_counts = (*handle).access_counts.lock().unwrap().clone();
+    match block_on(async move {
+        let now = chrono::Utc::now();
+        let mut memories = search_noting_shared(&agent, &shared_ids, &query_str, None).await?;
+        memories.retain(|m| !memory_expired(m, now));
+        if limit > 0 {
+            memories.truncate(limit);
+        }
+        let relevance = relevance_scores(&agent, &query_str, &memories).await?;
+
+        let mut scores = Vec::with_capacity(memories.len());
+        let mut components = Vec::with_capacity(memories.len());
+        for (memory, relevance) in memories.iter().zip(relevance) {
+            let vars = score_vars(&agent, memory, relevance, &access_counts);
+            scores.push(vars.score);
+            components.push(
+                serde_json::json!({
+                    "semantic": vars.score,
+                    "recency_boost": vars.recency,
+                    "retention_factor": vars.retention,
+                })
+                .to_string(),
+            );
+        }
+        Ok((memories, scores, components))
+    }) {
+        Ok((memories, scores, components)) => {
+            (*handle).record_access(&memories);
+            *out_components = Box::into_raw(Box::new(ThymosStringList::from_strings(components)));
+            (*handle).checked_results(&memories, &scores)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// BM25 term saturation parameter.
+const BM25_K1: f64 = 1.2;
+/// BM25 document length normalization parameter.
+const BM25_B: f64 = 0.75;
+
+/// Score tokenized documents against query terms with Okapi BM25.
+fn bm25_scores(docs: &[Vec<String>], query_terms: &[String]) -> Vec<f64> {
+    let n = docs.len() as f64;
+    let avg_len = docs.iter().map(|d| d.len()).sum::<usize>() as f64 / n.max(1.0);
+
+    let idf: Vec<f64> = query_terms
+        .iter()
+        .map(|term| {
+            let df = docs.iter().filter(|d| d.contains(term)).count() as f64;
+            ((n - df + 0.5) / (df + 0.5) + 1.0).ln()
+        })
+        .collect();
+
+    docs.iter()
+        .map(|doc| {
+            let len_norm = 1.0 - BM25_B + BM25_B * doc.len() as f64 / avg_len.max(1.0);
+            query_terms
+                .iter()
+                .zip(&idf)
+                .map(|(term, idf)| {
+                    let tf = doc.iter().filter(|t| *t == term).count() as f64;
+                    idf * tf * (BM25_K1 + 1.0) / (tf + BM25_K1 * len_norm)
+                })
+                .sum()
+        })
+        .collect()
+}
+
+/// Scale scores to [0, 1] by the maximum; all-zero input stays zero.
+fn normalize_scores(scores: &mut [f64]) {
+    let max = scores.iter().cloned().fold(0.0, f64::max);
+    if max > 0.0 {
+        for score in scores.iter_mut() {
+            *score /= max;
+        }
+    }
+}
+
+/// Number of text-search matches the keyword half of a hybrid search scores,
+/// unless the search's limit is larger.
+const HYBRID_KEYWORD_CANDIDATES: usize = 1000;
+
+/// Search memories by fused semantic and keyword relevance.
+///
+/// Keyword candidates come from the store's text search, up to
+/// `HYBRID_KEYWORD_CANDIDATES` (or `limit` if larger), and get a BM25 score
+/// computed over those candidates. With `alpha` above 0 every memory also gets
+/// a semantic score (cosine similarity of its stored embedding to the query
+/// embedding; 0 for unembedded memories); stored embeddings can have any
+/// dimension, so this half reads the whole store. Both scores are scaled to
+/// [0, 1] and fused as `alpha * semantic + (1 - alpha) * keyword`. Memories
+/// with a fused score of 0 are omitted.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `query` - Search query string
+/// * `limit` - Maximum number of results (0 = no limit)
+/// * `alpha` - Semantic weight in [0, 1]; 1 is pure semantic, 0 pure keyword
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `query` must be a valid null-terminated UTF-8 string.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_hybrid(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    alpha: f64,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    if !(0.0..=1.0).contains(&alpha) {
+        set_error("Invalid alpha: must be between 0.0 and 1.0");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    let provider = agent.embedding_provider().cloned();
+    if alpha > 0.0 && provider.is_none() {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            format!(
+                "Agent '{}' has no embedding provider configured; use alpha 0 for keyword search",
+                agent.id()
+            ),
+        );
+        return ptr::null_mut();
+    }
+
+    match block_on(async move {
+        let store = local_store(&agent)?;
+        let keyword_matches = if alpha < 1.0 {
+            store
+                .search_for(&query_str)
+                .limit(limit.max(HYBRID_KEYWORD_CANDIDATES))
+                .mode(locai::memory::SearchMode::Text)
+                .execute()
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?
+        } else {
+            Vec::new()
+        };
+
+        let docs: Vec<Vec<String>> = keyword_matches
+            .iter()
+            .map(|m| tokenize(&m.content).collect())
+            .collect();
+        let query_terms: Vec<String> = tokenize(&query_str).collect();
+        let mut keyword_scores = bm25_scores(&docs, &query_terms);
+        normalize_scores(&mut keyword_scores);
+        let keyword: HashMap<String, f64> = keyword_matches
+            .iter()
+            .map(|m| m.id.clone())
+            .zip(keyword_scores)
+            .collect();
+
+        let memories = if alpha > 0.0 {
+            let mut memories = load_all_memories(&agent).await?;
+            let loaded: HashSet<String> = memories.iter().map(|m| m.id.clone()).collect();
+            memories.extend(
+                keyword_matches
+                    .into_iter()
+                    .filter(|m| !loaded.contains(&m.id)),
+            );
+            memories
+        } else {
+            keyword_matches
+        };
+
+        let mut semantic = vec![0.0; memories.len()];
+        if let Some(provider) = provider.filter(|_| alpha > 0.0) {
+            let query_embedding = provider.embed(&query_str).await?;
+            for (score, memory) in semantic.iter_mut().zip(&memories) {
+                if let Some(embedding) = &memory.embedding {
+                    if embedding.len() == query_embedding.len() {
+                        *score = cosine_similarity(embedding, &query_embedding).max(0.0) as f64;
+                    }
+                }
+            }
+        }
+        normalize_scores(&mut semantic);
+
+        let mut scored: Vec<(f64, locai::models::Memory)> = memories
+            .into_iter()
+            .zip(semantic)
+            .map(|(memory, semantic)| {
+                let keyword = keyword.get(&memory.id).copied().unwrap_or(0.0);
+                (alpha * semantic + (1.0 - alpha) * keyword, memory)
+            })
+            .filter(|(score, _)| *score > 0.0)
+            .collect();
+
+        scored.sort_by(|a, b| b.0.total_cmp(&a.0));
+        if limit > 0 {
+            scored.truncate(limit);
+        }
+        let (scores, memories): (Vec<f64>, Vec<_>) = scored.into_iter().unzip();
+        Ok((memories, scores))
+    }) {
+        Ok((memories, scores)) => {
+            (*handle).record_access(&memories);
+            (*handle).scored_results(&memories, &scores)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Search private memories (hybrid mode only).
+///
+/// # Safety
+/// Same as `thymos_agent_search_memories`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_private(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let now = chrono::Utc::now();
+        let mut memories = agent.search_private(&query_str).await?;
+        memories.retain(|m| !memory_expired(m, now));
+        if limit > 0 {
+            memories.truncate(limit);
+        }
+        Ok(memories)
+    }) {
+        Ok(memories) => {
+            let scopes = vec![SCOPE_PRIVATE; memories.len()];
+            (*handle).record_access(&memories);
+            (*handle).scoped_results(&memories, &[], &scopes)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Search shared memories (hybrid mode only).
+///
+/// # Safety
+/// Same as `thymos_agent_search_memories`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_shared(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let now = chrono::Utc::now();
+        let mut memories = agent.search_shared(&query_str).await?;
+        memories.retain(|m| !memory_expired(m, now));
+        if limit > 0 {
+            memories.truncate(limit);
+        }
+        Ok(memories)
+    }) {
+        Ok(memories) => {
+            let scopes = vec![SCOPE_SHARED; memories.len()];
+            (*handle).record_access(&memories);
+            (*handle).scoped_results(&memories, &[], &scopes)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Search both hybrid backends and merge the results by relevance.
+///
+/// Results are merged by relevance score, as in
+/// `thymos_agent_search_memories`; ties keep private results first. Every
+/// result reports the backend it came from in `scope`.
+///
+/// # Safety
+/// Same as `thymos_agent_search_memories`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_scoped(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let (private, shared) = tokio::join!(
+            agent.search_private(&query_str),
+            agent.search_shared(&query_str)
+        );
+        let (private, shared) = (private?, shared?);
+        let private_scores = relevance_scores(&agent, &query_str, &private).await?;
+        let shared_scores = relevance_scores(&agent, &query_str, &shared).await?;
+        Ok(((private, private_scores), (shared, shared_scores)))
+    }) {
+        Ok((private, shared)) => {
+            let now = chrono::Utc::now();
+            let mut merged = Vec::new();
+            for ((memories, scores), scope) in [(private, SCOPE_PRIVATE), (shared, SCOPE_SHARED)] {
+                merged.extend(
+                    memories
+                        .into_iter()
+                        .zip(scores)
+                        .filter(|(m, _)| !memory_expired(m, now))
+                        .map(|(m, s)| (m, s, scope)),
+                );
+            }
+            // Stable, so equal scores keep private results ahead of shared
+            merged.sort_by(|a, b| b.1.total_cmp(&a.1));
+            if limit > 0 {
+                merged.truncate(limit);
+            }
+
+            let memories: Vec<_> = merged.iter().map(|(m, _, _)| m.clone()).collect();
+            let scores: Vec<_> = merged.iter().map(|(_, s, _)| *s).collect();
+            let scopes: Vec<_> = merged.iter().map(|(_, _, scope)| *scope).collect();
+            (*handle).record_access(&memories);
+            (*handle).scoped_results(&memories, &scores, &scopes)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Search with an explicit candidate pool size.
+///
+/// Unlike `Agent::search_memories`, which uses the store's default limit, this
+/// asks the store for up to `candidates` results.
+async fn search_candidates(
+    agent: &Agent,
+    shared_ids: &Mutex<HashSet<String>>,
+    query: &str,
+    candidates: usize,
+) -> Result<Vec<locai::models::Memory>> {
+    search_noting_shared(agent, shared_ids, query, Some(candidates)).await
+}
+
+/// Search memories, keeping the unexpired ones `keep` accepts in search order.
+///
+/// The store cannot filter a search, so the pool of candidates doubles until
+/// `limit` matches are found or the store runs out of results; a match is
+/// never missed however many other memories outrank it. A limit of 0 returns
+/// every match.
+async fn filtered_search(
+    agent: &Agent,
+    shared_ids: &Mutex<HashSet<String>>,
+    query: &str,
+    limit: usize,
+    keep: impl Fn(&locai::models::Memory) -> bool,
+) -> Result<Vec<locai::models::Memory>> {
+    let now = chrono::Utc::now();
+    let mut pool = limit.max(10) * 10;
+    loop {
+        let candidates = search_candidates(agent, shared_ids, query, pool).await?;
+        let exhausted = candidates.len() < pool;
+        let mut matches: Vec<_> = candidates
+            .into_iter()
+            .filter(|m| !memory_expired(m, now) && keep(m))
+            .collect();
+        if limit > 0 && matches.len() >= limit {
+            matches.truncate(limit);
+            return Ok(matches);
+        }
+        if exhausted {
+            return Ok(matches);
+        }
+        pool = pool.saturating_mul(2);
+    }
+}
+
+/// Search memories, restricted to an allowlist of memory IDs.
+///
+/// Ranking follows the regular search; memories outside the allowlist are
+/// never returned. An empty allowlist returns no results.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `query` - Search query string
+/// * `allowed_ids` - Memory IDs that may be returned
+/// * `allowed_count` - Number of allowed IDs
+/// * `limit` - Maximum number of results (0 = no limit)
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `query` must be a valid null-terminated UTF-8 string.
+/// `allowed_ids` must point to `allowed_count` valid strings.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_within(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    allowed_ids: *const *const c_char,
+    allowed_count: usize,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(allowed) = cstr_array_to_vec(allowed_ids, allowed_count) else {
+        set_error("Invalid allowed_ids: null or not valid UTF-8");
+        return ptr::null_mut();
+    };
+    let allowed: HashSet<String> = allowed.into_iter().collect();
+
+    if allowed.is_empty() {
+        return (*handle).results(&[]);
+    }
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    match block_on(async move {
+        // Stop once every allowed memory is found
+        let wanted = match limit {
+            0 => allowed.len(),
+            limit => limit.min(allowed.len()),
+        };
+        filtered_search(&agent, &shared_ids, &query_str, wanted, |m| {
+            allowed.contains(&m.id)
+        })
+        .await
+    }) {
+        Ok(memories) => {
+            (*handle).record_access(&memories);
+            (*handle).results(&memories)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Locai memory type for a C memory type code.
+///
+/// Codes: 0 = episodic (`remember`), 1 = fact, 2 = conversation.
+fn memory_type_from_code(code: c_int) -> Option<locai::models::MemoryType> {
+    match code {
+        0 => Some(locai::models::MemoryType::Episodic),
+        1 => Some(locai::models::MemoryType::Fact),
+        2 => Some(locai::models::MemoryType::Conversation),
+        _ => None,
+    }
+}
+
+/// C memory type code for a Locai memory type, or -1 for types without a code.
+fn memory_type_code(memory_type: &locai::models::MemoryType) -> c_int {
+    match memory_type {
+        locai::models::MemoryType::Episodic => 0,
+        locai::models::MemoryType::Fact => 1,
+        locai::models::MemoryType::Conversation => 2,
+        _ => -1,
+    }
+}
+
+/// Search memories of a single type.
+///
+/// Ranking follows the regular search; memories of other types are never
+/// returned, and no match yields an empty result.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `query` - Search query string
+/// * `memory_type` - 0 = episodic, 1 = fact, 2 = conversation
+/// * `limit` - Maximum number of results (0 = no limit)
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `query` must be a valid null-terminated UTF-8 string.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_by_type(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    memory_type: c_int,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(wanted) = memory_type_from_code(memory_type) else {
+        set_error(format!("Invalid memory_type: {}", memory_type));
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    match block_on(async move {
+        filtered_search(&agent, &shared_ids, &query_str, limit, |m| {
+            m.memory_type == wanted
+        })
+        .await
+    }) {
+        Ok(memories) => {
+            (*handle).record_access(&memories);
+            (*handle).results(&memories)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Search memories created within a time window.
+///
+/// Only memories with `since_ms <= created_at <= until_ms` (Unix milliseconds)
+/// are returned; ranking among them follows the regular search. No match
+/// yields an empty result.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `query` - Search query string
+/// * `since_ms` - Earliest creation time, inclusive
+/// * `until_ms` - Latest creation time, inclusive
+/// * `limit` - Maximum number of results (0 = no limit)
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `query` must be a valid null-terminated UTF-8 string.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_search_in_range(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    since_ms: i64,
+    until_ms: i64,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    if since_ms > until_ms {
+        set_error("Invalid time range: since is after until");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    match block_on(async move {
+        filtered_search(&agent, &shared_ids, &query_str, limit, |m| {
+            (since_ms..=until_ms).contains(&m.created_at.timestamp_millis())
+        })
+        .await
+    }) {
+        Ok(memories) => {
+            (*handle).record_access(&memories);
+            (*handle).results(&memories)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Get a memory by ID.
+///
+/// Returns the memory on success, or null if not found or on error.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+/// The returned memory must be freed with `thymos_free_memory`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_get_memory(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut ThymosMemory {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    match block_on(async move { get_noting_shared(&agent, &shared_ids, &id).await }) {
+        Ok(Some(memory)) if !memory_expired(&memory, chrono::Utc::now()) => {
+            let handle = &*handle;
+            handle.record_access(std::slice::from_ref(&memory));
+            let scope = handle.memory_scopes(std::slice::from_ref(&memory))[0];
+            let access_count = handle
+                .access_counts
+                .lock()
+                .unwrap()
+                .get(&memory.id)
+                .copied()
+                .unwrap_or(0);
+            Box::into_raw(Box::new(ThymosMemory {
+                scope,
+                access_count,
+                ..ThymosMemory::from_locai(&handle.inner, &memory)
+            }))
+        }
+        Ok(_) => ptr::null_mut(),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Check whether a memory exists.
+///
+/// Returns 1 if a memory with the ID exists (and has not expired), 0 if not,
+/// -1 on error. The memory is read from the store as by
+/// `thymos_agent_get_memory`, but the check does not count as an access and
+/// nothing is copied out to the caller.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_has_memory(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return -1;
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move { agent.get_memory(&id).await }) {
+        Ok(Some(memory)) if !memory_expired(&memory, chrono::Utc::now()) => 1,
+        Ok(_) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Get many memories by ID in one call.
+///
+/// The results hold the memories that exist, in the order their IDs were
+/// given; IDs with no memory are skipped, and a repeated ID yields its memory
+/// once per occurrence. Every memory returned counts as an access.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_ids` must point to `count` valid null-terminated UTF-8 strings.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_get_memories(
+    handle: *const ThymosAgent,
+    memory_ids: *const *const c_char,
+    count: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(ids) = cstr_array_to_vec(memory_ids, count) else {
+        set_error("Invalid memory_ids: null or not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let shared_ids = (*handle).shared_ids.clone();
+    match block_on(async move {
+        let mut memories = Vec::with_capacity(ids.len());
+        for id in &ids {
+            if let Some(memory) = get_noting_shared(&agent, &shared_ids, id).await? {
+                memories.push(memory);
+            }
+        }
+        Ok(memories)
+    }) {
+        Ok(memories) => {
+            let handle = &*handle;
+            handle.record_access(&memories);
+            handle.results(&memories)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Fingerprint of memory content: the lowercase hex SHA-256 of its UTF-8 bytes.
+fn content_fingerprint(content: &str) -> String {
+    format!("{:x}", Sha256::digest(content.as_bytes()))
+}
+
+/// Get the content fingerprint of a memory.
+///
+/// The fingerprint is the lowercase hex SHA-256 of the memory's content, so it
+/// is stable across restarts and replicas. Fails with "memory not found" if no
+/// memory has the ID.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_fingerprint(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move { agent.get_memory(&id).await }) {
+        Ok(Some(memory)) => string_to_cstring(content_fingerprint(&memory.content)),
+        Ok(None) => {
+            set_error_with_code(ERROR_NOT_FOUND, "memory not found");
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// The local memories in listing order, kept by the agent handle between
+/// `thymos_agent_list_memories` calls.
+#[derive(Default)]
+struct CreationIndex {
+    /// Creation time and ID of each indexed memory, oldest first
+    order: BTreeSet<(chrono::DateTime<chrono::Utc>, String)>,
+    /// Creation time of each indexed memory, by ID
+    created: HashMap<String, chrono::DateTime<chrono::Utc>>,
+}
+
+impl CreationIndex {
+    /// Bring the index in line with the IDs of a store snapshot, loading only
+    /// memories not indexed yet.
+    async fn sync(&mut self, store: &locai::prelude::Locai) -> Result<()> {
+        let snapshot = store
+            .create_snapshot(None, None)
+            .await
+            .map_err(|e| CoreError::Memory(format!("Failed to create snapshot: {}", e)))?;
+        let stored: HashSet<&String> = snapshot.version_map.keys().collect();
+
+        let Self { order, created } = self;
+        created.retain(|id, at| {
+            let kept = stored.contains(id);
+            if !kept {
+                order.remove(&(*at, id.clone()));
+            }
+            kept
+        });
+        for id in stored {
+            if created.contains_key(id) {
+                continue;
+            }
+            let memory = store
+                .manager()
+                .get_memory(id)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+            if let Some(memory) = memory {
+                order.insert((memory.created_at, memory.id.clone()));
+                created.insert(memory.id, memory.created_at);
+            }
+        }
+        Ok(())
+    }
+}
+
+/// List stored memories a page at a time, oldest first.
+///
+/// Memories are ordered by creation time, ties broken by ID, so pages are
+/// stable while the store is unchanged. Skips `offset` memories and returns up
+/// to `limit` (0 for no limit); an offset past the end yields empty results.
+/// Listing does not count as an access.
+///
+/// The handle keeps the creation order of the store's memories: each call
+/// compares it with the IDs in a store snapshot, loads only memories stored
+/// since the last call, and then reads just the requested page.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_list_memories(
+    handle: *const ThymosAgent,
+    offset: usize,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    // Held across the sync so concurrent calls don't load the same memories
+    let mut guard = (*handle).creation_index.lock().unwrap();
+    let mut index = std::mem::take(&mut *guard);
+    let agent = (*handle).inner.clone();
+    let (index, result) = block_on_value(async move {
+        let result = async {
+            let store = local_store(&agent)?;
+            index.sync(store).await?;
+            let limit = if limit > 0 { limit } else { usize::MAX };
+            let mut memories = Vec::new();
+            for (_, id) in index.order.iter().skip(offset).take(limit) {
+                let memory = store
+                    .manager()
+                    .get_memory(id)
+                    .await
+                    .map_err(|e| CoreError::Memory(e.to_string()))?;
+                memories.extend(memory);
+            }
+            Ok::<_, CoreError>(memories)
+        }
+        .await;
+        (index, result)
+    });
+    *guard = index;
+    drop(guard);
+
+    match result {
+        Ok(memories) => (*handle).results(&memories),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Property holding the time a memory's content, embedding or properties last
+/// changed, as RFC 3339.
+const MODIFIED_AT_PROPERTY: &str = "modified_at";
+
+/// Maximum number of deletions remembered for `thymos_agent_modified_since`.
+const MAX_TRACKED_DELETIONS: usize = 10_000;
+
+/// Record `at` as the time a memory last changed.
+fn stamp_modified(memory: &mut locai::models::Memory, at: chrono::DateTime<chrono::Utc>) {
+    if !memory.properties.is_object() {
+        memory.properties = serde_json::json!({});
+    }
+    memory
+        .properties
+        .as_object_mut()
+        .unwrap()
+        .insert(MODIFIED_AT_PROPERTY.to_string(), at.to_rfc3339().into());
+}
+
+/// When a memory last changed: its "modified_at" property, or its creation if
+/// it was never changed. Accesses do not count as changes.
+fn modified_at(memory: &locai::models::Memory) -> chrono::DateTime<chrono::Utc> {
+    memory
+        .properties
+        .get(MODIFIED_AT_PROPERTY)
+        .and_then(|v| v.as_str())
+        .and_then(|v| chrono::DateTime::parse_from_rfc3339(v).ok())
+        .map_or(memory.created_at, |at| at.with_timezone(&chrono::Utc))
+}
+
+/// The local memories in modification order, and the ones deleted since the
+/// handle was opened, kept between `thymos_agent_modified_since` calls.
+#[derive(Default)]
+struct ModificationIndex {
+    /// Modification time and ID of each indexed memory, oldest first
+    order: BTreeSet<(chrono::DateTime<chrono::Utc>, String)>,
+    /// Modification time of each indexed memory, by ID
+    modified: HashMap<String, chrono::DateTime<chrono::Utc>>,
+    /// Time each deletion was noticed and the deleted ID, oldest first; at
+    /// most `MAX_TRACKED_DELETIONS` are kept
+    deleted: BTreeSet<(chrono::DateTime<chrono::Utc>, String)>,
+}
+
+impl ModificationIndex {
+    /// Bring the index in line with the IDs of a store snapshot, loading only
+    /// memories not indexed yet and those in `changed`. Indexed memories
+    /// missing from the snapshot are recorded as deleted.
+    async fn sync(
+        &mut self,
+        store: &locai::prelude::Locai,
+        changed: &HashSet<String>,
+    ) -> Result<()> {
+        let snapshot = store
+            .create_snapshot(None, None)
+            .await
+            .map_err(|e| CoreError::Memory(format!("Failed to create snapshot: {}", e)))?;
+        let stored: HashSet<&String> = snapshot.version_map.keys().collect();
+        let now = chrono::Utc::now();
+
+        let Self {
+            order,
+            modified,
+            deleted,
+        } = self;
+        modified.retain(|id, at| {
+            let present = stored.contains(id);
+            if present && !changed.contains(id) {
+                return true;
+            }
+            order.remove(&(*at, id.clone()));
+            if !present {
+                deleted.insert((now, id.clone()));
+            }
+            false
+        });
+        while deleted.len() > MAX_TRACKED_DELETIONS {
+            deleted.pop_first();
+        }
+
+        for id in stored {
+            if modified.contains_key(id) {
+                continue;
+            }
+            let memory = store
+                .manager()
+                .get_memory(id)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+            if let Some(memory) = memory {
+                let at = modified_at(&memory);
+                deleted.retain(|(_, deleted_id)| deleted_id != &memory.id);
+                order.insert((at, memory.id.clone()));
+                modified.insert(memory.id, at);
+            }
+        }
+        Ok(())
+    }
+}
+
+/// Bring the handle's modification index in line with its store and return
+/// it locked, so concurrent calls don't load the same memories.
+///
+/// Each sync compares the index with the IDs in a store snapshot and loads
+/// only memories stored or changed since the last one. On error the changed
+/// IDs are kept for the next sync.
+fn synced_modification_index(
+    handle: &ThymosAgent,
+) -> Result<std::sync::MutexGuard<'_, ModificationIndex>> {
+    let mut guard = handle.modification_index.lock().unwrap();
+    let mut index = std::mem::take(&mut *guard);
+    let changed = std::mem::take(&mut *handle.changed_ids.lock().unwrap());
+    let agent = handle.inner.clone();
+    let (index, changed, result) = block_on_value(async move {
+        let result = async {
+            let store = local_store(&agent)?;
+            index.sync(store, &changed).await
+        }
+        .await;
+        (index, changed, result)
+    });
+    *guard = index;
+
+    if let Err(e) = result {
+        // Not reindexed yet; pick them up on the next call
+        handle.changed_ids.lock().unwrap().extend(changed);
+        return Err(e);
+    }
+    Ok(guard)
+}
+
+/// List memories created or changed at or after `since_ms`, for incremental
+/// sync.
+///
+/// A memory's modification time is its "modified_at" property, stamped when
+/// its content, embedding, properties or TTL change through this API, or its
+/// creation time if it never changed. Accesses do not count: the store writes
+/// a memory's last access time on every search that returns it, so counting
+/// them would report every searched memory as changed, and those writes
+/// bypass this handle, so the index could only see them by reloading every
+/// memory on each call. Memories are ordered by modification time, oldest
+/// first, ties broken by ID, and at most `limit` are returned (0 for no
+/// limit). The bound is inclusive so a caller resuming from the last memory's
+/// time sees it again rather than missing others changed in the same
+/// millisecond. Listing does not count as an access.
+///
+/// Deletions are listed by `thymos_agent_deleted_since`.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_modified_since(
+    handle: *const ThymosAgent,
+    since_ms: i64,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let since = chrono::DateTime::from_timestamp_millis(since_ms)
+        .unwrap_or(chrono::DateTime::<chrono::Utc>::MIN_UTC);
+
+    let ids: Vec<String> = match synced_modification_index(&*handle) {
+        Ok(index) => {
+            let limit = if limit > 0 { limit } else { usize::MAX };
+            index
+                .order
+                .range((since, String::new())..)
+                .take(limit)
+                .map(|(_, id)| id.clone())
+                .collect()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            return ptr::null_mut();
+        }
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let store = local_store(&agent)?;
+        let mut memories = Vec::new();
+        for id in &ids {
+            let memory = store
+                .manager()
+                .get_memory(id)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+            memories.extend(memory);
+        }
+        Ok(memories)
+    }) {
+        Ok(memories) => (*handle).results(&memories),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// List the IDs of memories deleted at or after `since_ms`, oldest deletion
+/// first, for incremental sync alongside `thymos_agent_modified_since`.
+///
+/// Deletions, including forgotten and expired memories, are noticed by this
+/// handle at the first call to either function after they happen, and the
+/// last `MAX_TRACKED_DELETIONS` are remembered; deletions before the handle's
+/// first call are not reported.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned list must be freed with `thymos_free_string_list`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_deleted_since(
+    handle: *const ThymosAgent,
+    since_ms: i64,
+    out_error: *mut ThymosError,
+) -> *mut ThymosStringList {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let since = chrono::DateTime::from_timestamp_millis(since_ms)
+        .unwrap_or(chrono::DateTime::<chrono::Utc>::MIN_UTC);
+
+    match synced_modification_index(&*handle) {
+        Ok(index) => {
+            let deleted = index
+                .deleted
+                .range((since, String::new())..)
+                .map(|(_, id)| id.clone())
+                .collect();
+            Box::into_raw(Box::new(ThymosStringList::from_strings(deleted)))
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Count the memories in the agent's local store.
+///
+/// Reads the size of the store's version index; no memory is loaded.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+///
+/// Returns the number of memories, or -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_memory_count(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> i64 {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move { count_memories(&agent).await }) {
+        Ok(count) => count as i64,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Count the memories of a single type in the agent's local store.
+///
+/// Memory types are not indexed, so unlike `thymos_agent_memory_count` this
+/// loads every memory to inspect its type.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_type` is 0 = episodic, 1 = fact, 2 = conversation.
+///
+/// Returns the number of matching memories, or -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_memory_count_by_type(
+    handle: *const ThymosAgent,
+    memory_type: c_int,
+    out_error: *mut ThymosError,
+) -> i64 {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    let Some(wanted) = memory_type_from_code(memory_type) else {
+        set_error(format!("Invalid memory_type: {}", memory_type));
+        return -1;
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let memories = load_all_memories(&agent).await?;
+        Ok(memories.iter().filter(|m| m.memory_type == wanted).count())
+    }) {
+        Ok(count) => count as i64,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Forgetting
+// ============================================================================
+
+/// Drop forgotten memories whose grace period has elapsed.
+fn purge_expired(forgotten: &mut Vec<ForgottenMemory>, grace: Duration) {
+    forgotten.retain(|f| f.forgotten_at.elapsed() < grace);
+}
+
+/// Delete `memories` from the store, keeping them recoverable for `grace`.
+///
+/// Returns the number of memories actually deleted.
+async fn forget_memories(
+    agent: &Agent,
+    forgotten: &Mutex<Vec<ForgottenMemory>>,
+    grace: Duration,
+    memories: Vec<locai::models::Memory>,
+) -> Result<i64> {
+    let store = local_store(agent)?;
+    let mut count = 0;
+    for memory in memories {
+        let deleted = store
+            .manager()
+            .delete_memory(&memory.id)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?;
+        if !deleted {
+            continue;
+        }
+
+        count += 1;
+        if !grace.is_zero() {
+            forgotten.lock().unwrap().push(ForgottenMemory {
+                memory,
+                forgotten_at: Instant::now(),
+            });
+        }
+    }
+
+    purge_expired(&mut forgotten.lock().unwrap(), grace);
+    Ok(count)
+}
+
+/// Forget memories whose strength has fallen below a threshold.
+///
+/// Forgotten memories are removed from the store but stay recoverable with
+/// `thymos_agent_restore_memory` until the configured grace period elapses.
+/// `threshold` must be between 0 and 1, the range of strengths.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+///
+/// Returns the number of memories forgotten, or -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_prune(
+    handle: *const ThymosAgent,
+    threshold: f64,
+    out_error: *mut ThymosError,
+) -> i64 {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !(0.0..=1.0).contains(&threshold) {
+        set_error("Invalid threshold: must be between 0 and 1");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let agent = (*handle).inner.clone();
+    let forgotten = (*handle).forgotten.clone();
+    let grace = (*handle).options.forget_grace_period;
+
+    let result = block_on(async move {
+        let weak: Vec<_> = load_all_memories(&agent)
+            .await?
+            .into_iter()
+            .filter(|memory| agent.memory().calculate_strength(memory) < threshold)
+            .collect();
+        forget_memories(&agent, &forgotten, grace, weak).await
+    });
+    (*handle).invalidate_query_cache();
+
+    match result {
+        Ok(count) => count,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// List memories that were forgotten but are still within the grace period.
+///
+/// Results are ordered most recently forgotten first.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `limit` - Maximum number of results (0 = no limit)
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_recently_forgotten(
+    handle: *const ThymosAgent,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let mut forgotten = (*handle).forgotten.lock().unwrap();
+    purge_expired(&mut forgotten, (*handle).options.forget_grace_period);
+
+    let mut memories: Vec<locai::models::Memory> =
+        forgotten.iter().rev().map(|f| f.memory.clone()).collect();
+    if limit > 0 {
+        memories.truncate(limit);
+    }
+
+    (*handle).results(&memories)
+}
+
+/// Restore a recently forgotten memory with its original ID and timestamps.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_restore_memory(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return -1;
+    };
+
+    let entry = {
+        let mut forgotten = (*handle).forgotten.lock().unwrap();
+        purge_expired(&mut forgotten, (*handle).options.forget_grace_period);
+        match forgotten.iter().position(|f| f.memory.id == id) {
+            Some(index) => forgotten.remove(index),
+            None => {
+                set_error_with_code(
+                    ERROR_NOT_FOUND,
+                    format!("Memory '{}' is not in recently forgotten memories", id),
+                );
+                return -1;
+            }
+        }
+    };
+
+    let agent = (*handle).inner.clone();
+    let forgotten = (*handle).forgotten.clone();
+    let result = block_on(async move {
+        let store = local_store(&agent)?;
+        match store.manager().store_memory(entry.memory.clone()).await {
+            Ok(_) => Ok(()),
+            Err(e) => {
+                // Keep the memory recoverable if the write failed, in its
+                // place by forget time so its grace period is unchanged
+                let mut forgotten = forgotten.lock().unwrap();
+                let at = forgotten.partition_point(|f| f.forgotten_at <= entry.forgotten_at);
+                forgotten.insert(at, entry);
+                Err(CoreError::Memory(e.to_string()))
+            }
+        }
+    });
+    (*handle).invalidate_query_cache();
+    (*handle).invalidate_tag_index();
+
+    match result {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Drop this handle's records of memories no longer in the store.
+///
+/// The handle keeps access counts, access timelines, TTLs and concept index
+/// entries by memory ID and drops them when it deletes the memory, but
+/// memories deleted some other way, such as by another handle on the same
+/// store, leave theirs behind. Forgotten memories past the grace period are
+/// purged too. Every memory ID in the store is read, so this is meant for
+/// periodic maintenance.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+///
+/// Returns the number of records dropped, or -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_compact(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> i64 {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    let handle = &*handle;
+    // Only records made before the store is read are candidates, so a memory
+    // stored meanwhile keeps its records
+    let counted = map_keys(&handle.access_counts.lock().unwrap());
+    let timed = handle
+        .access_timelines
+        .as_ref()
+        .map(|timelines| map_keys(&timelines.lock().unwrap()))
+        .unwrap_or_default();
+    let expiring = map_keys(&handle.expiries.lock().unwrap());
+    let indexed = map_keys(&handle.concept_index.lock().unwrap());
+
+    let agent = handle.inner.clone();
+    let live: HashSet<String> = match block_on(async move { load_all_memories(&agent).await }) {
+        Ok(memories) => memories.into_iter().map(|m| m.id).collect(),
+        Err(e) => {
+            set_thymos_error(&e);
+            return -1;
+        }
+    };
+
+    let mut dropped = drop_stale(&mut handle.access_counts.lock().unwrap(), counted, &live);
+    if let Some(timelines) = &handle.access_timelines {
+        dropped += drop_stale(&mut timelines.lock().unwrap(), timed, &live);
+    }
+    dropped += drop_stale(&mut handle.expiries.lock().unwrap(), expiring, &live);
+    dropped += drop_stale(&mut handle.concept_index.lock().unwrap(), indexed, &live);
+
+    let mut forgotten = handle.forgotten.lock().unwrap();
+    let before = forgotten.len();
+    purge_expired(&mut forgotten, handle.options.forget_grace_period);
+    dropped += before - forgotten.len();
+
+    dropped as i64
+}
+
+/// The keys of a map of per-memory records.
+fn map_keys<V>(map: &HashMap<String, V>) -> Vec<String> {
+    map.keys().cloned().collect()
+}
+
+/// Remove the `candidates` not in `live` from `map`, returning how many were
+/// removed.
+fn drop_stale<V>(
+    map: &mut HashMap<String, V>,
+    candidates: Vec<String>,
+    live: &HashSet<String>,
+) -> usize {
+    candidates
+        .into_iter()
+        .filter(|id| !live.contains(id) && map.remove(id).is_some())
+        .count()
+}
+
+/// Value at the given percentile (0.0-1.0) of a sorted slice.
+fn percentile(sorted: &[i64], p: f64) -> i64 {
+    let index = ((sorted.len() - 1) as f64 * p).round() as usize;
+    sorted[index]
+}
+
+/// Suggest forgetting-curve parameters from observed re-access intervals.
+///
+/// A re-access interval is the time between a memory's creation and its last
+/// access. The suggested reuse interval is the 75th percentile interval, at
+/// least an hour, and the suggested decay rate (per hour, for
+/// `thymos_memory_config_set_decay_rate`) is the one under which the curve's
+/// age decay halves over that interval, so most memories keep at least half
+/// their strength until they are typically re-used. The retention floor is
+/// the age decay that rate gives at the 90th percentile interval, clamped to
+/// [0.01, 0.5]; memories below it are rarely re-accessed and can be pruned.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The output pointers must be valid.
+///
+/// Returns 0 on success, -1 on error (including too little access history).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_suggest_forgetting(
+    handle: *const ThymosAgent,
+    out_reuse_interval_ms: *mut u64,
+    out_decay_rate: *mut f64,
+    out_retention_floor: *mut f64,
+    out_sample_size: *mut usize,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if out_reuse_interval_ms.is_null()
+        || out_decay_rate.is_null()
+        || out_retention_floor.is_null()
+        || out_sample_size.is_null()
+    {
+        set_error("Output pointers are null");
+        return -1;
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move { load_all_memories(&agent).await }) {
+        Ok(memories) => {
+            let mut intervals: Vec<i64> = memories
+                .iter()
+                .filter_map(|m| m.last_accessed.map(|at| (at - m.created_at).num_milliseconds()))
+                .filter(|ms| *ms > 0)
+                .collect();
+
+            if intervals.is_empty() {
+                set_error("Not enough access history to suggest forgetting parameters");
+                return -1;
+            }
+            intervals.sort_unstable();
+
+            // The age decay is e^(-hours * rate), which halves after
+            // ln 2 / rate hours
+            let reuse_ms = percentile(&intervals, 0.75).max(3_600_000);
+            let decay_rate = std::f64::consts::LN_2 / (reuse_ms as f64 / 3_600_000.0);
+            let p90_hours = percentile(&intervals, 0.90) as f64 / 3_600_000.0;
+            let floor = (-p90_hours * decay_rate).exp();
+
+            *out_reuse_interval_ms = reuse_ms as u64;
+            *out_decay_rate = decay_rate;
+            *out_retention_floor = floor.clamp(0.01, 0.5);
+            *out_sample_size = intervals.len();
+            0
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Project a memory's strength on the forgetting curve to a future time.
+///
+/// Assumes no further access. This is a pure projection: the memory's access
+/// time and the handle's access counts are left untouched.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+/// `out_strength` must be a valid pointer.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_project_retention(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    at_ms: i64,
+    out_strength: *mut f64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if out_strength.is_null() {
+        set_error("Output pointer is null");
+        return -1;
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return -1;
+    };
+
+    let Some(at) = chrono::DateTime::from_timestamp_millis(at_ms) else {
+        set_error("Invalid at: out of range");
+        return -1;
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let memory = agent
+            .get_memory(&id)
+            .await?
+            .ok_or_else(|| CoreError::Memory(format!("Memory '{}' not found", id)))?;
+        Ok(agent.memory().calculate_strength_at(&memory, at))
+    }) {
+        Ok(strength) => {
+            *out_strength = strength;
+            0
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Reinforce a memory, restarting its forgetting curve.
+///
+/// Sets the memory's `last_accessed` to now, as a retrieval would, so its
+/// strength climbs back and it is not pruned while still relevant. Fails with
+/// "memory not found" if no local memory has the ID.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_reinforce(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return -1;
+    };
+
+    let agent = (*handle).inner.clone();
+    let locks = (*handle).memory_locks.clone();
+    let result = block_on(async move {
+        let _guard = locks.lock(&id).await;
+        let store = local_store(&agent)?;
+        let Some(mut memory) = store
+            .manager()
+            .get_memory(&id)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?
+        else {
+            return Ok(false);
+        };
+
+        memory.last_accessed = Some(chrono::Utc::now());
+        store
+            .manager()
+            .update_memory(memory)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?;
+        Ok(true)
+    });
+    (*handle).invalidate_query_cache();
+
+    match result {
+        Ok(true) => 0,
+        Ok(false) => {
+            set_error_with_code(ERROR_NOT_FOUND, "memory not found");
+            -1
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Lifecycle State
+// ============================================================================
+
+/// Serialized lifecycle (retention) state of an agent's memories.
+#[derive(Serialize, Deserialize)]
+struct LifecycleState {
+    version: u32,
+    memories: Vec<LifecycleEntry>,
+}
+
+/// Retention state of a single memory.
+///
+/// `strength` is informational and ignored on import.
+#[derive(Serialize, Deserialize)]
+struct LifecycleEntry {
+    id: String,
+    last_accessed: Option<chrono::DateTime<chrono::Utc>>,
+    #[serde(default)]
+    strength: f64,
+}
+
+const LIFECYCLE_STATE_VERSION: u32 = 1;
+
+/// Export the lifecycle state of all memories as JSON.
+///
+/// Only retention metadata is exported, never memory content.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_export_lifecycle(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let mut memories = load_all_memories(&agent).await?;
+        memories.sort_by(|a, b| a.id.cmp(&b.id));
+
+        let state = LifecycleState {
+            version: LIFECYCLE_STATE_VERSION,
+            memories: memories
+                .iter()
+                .map(|m| LifecycleEntry {
+                    id: m.id.clone(),
+                    last_accessed: m.last_accessed,
+                    strength: agent.memory().calculate_strength(m),
+                })
+                .collect(),
+        };
+        Ok(serde_json::to_string(&state)?)
+    }) {
+        Ok(json) => string_to_cstring(json),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Import lifecycle state previously exported with `thymos_agent_export_lifecycle`.
+///
+/// The state must cover exactly the current set of memories; otherwise nothing
+/// is changed and an error is returned. Memory content is never modified.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `state_json` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_import_lifecycle(
+    handle: *const ThymosAgent,
+    state_json: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(json) = cstr_to_string(state_json) else {
+        set_error("Invalid state_json: not valid UTF-8");
+        return -1;
+    };
+
+    let state: LifecycleState = match serde_json::from_str(&json) {
+        Ok(state) => state,
+        Err(e) => {
+            set_error(format!("Invalid lifecycle state: {}", e));
+            return -1;
+        }
+    };
+
+    if state.version != LIFECYCLE_STATE_VERSION {
+        set_error(format!(
+            "Unsupported lifecycle state version: {}",
+            state.version
+        ));
+        return -1;
+    }
+
+    let agent = (*handle).inner.clone();
+    let result = block_on(async move {
+        let store = local_store(&agent)?;
+        let memories = load_all_memories(&agent).await?;
+
+        let entries: HashMap<&str, &LifecycleEntry> =
+            state.memories.iter().map(|e| (e.id.as_str(), e)).collect();
+        let missing = memories
+            .iter()
+            .filter(|m| !entries.contains_key(m.id.as_str()))
+            .count();
+        let unknown = entries.len() + missing - memories.len();
+        if missing > 0 || unknown > 0 {
+            return Err(CoreError::Memory(format!(
+                "Lifecycle state does not match current memories: {} missing, {} unknown",
+                missing, unknown
+            )));
+        }
+
+        for mut memory in memories {
+            let entry = entries[memory.id.as_str()];
+            if memory.last_accessed == entry.last_accessed {
+                continue;
+            }
+            memory.last_accessed = entry.last_accessed;
+            store
+                .manager()
+                .update_memory(memory)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+        }
+        Ok(())
+    });
+    (*handle).invalidate_query_cache();
+    (*handle).invalidate_tag_index();
+
+    match result {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Store Analytics
+// ============================================================================
+
+/// Resolve the local Locai store for an agent.
+///
+/// Embedded agents use their only store; hybrid agents use the private store.
+/// Server mode has no local store to enumerate.
+fn local_store(agent: &Agent) -> Result<&locai::prelude::Locai> {
+    match agent.memory() {
+        MemorySystem::Hybrid { hybrid, .. } => Ok(hybrid.private_locai()),
+        memory => memory.locai(),
+    }
+}
+
+/// Load every memory in the agent's local store.
+///
+/// Memory IDs are enumerated from a fresh snapshot's version map.
+async fn load_all_memories(agent: &Agent) -> Result<Vec<locai::models::Memory>> {
+    let store = local_store(agent)?;
+    let snapshot = store
+        .create_snapshot(None, None)
+        .await
+        .map_err(|e| CoreError::Memory(format!("Failed to create snapshot: {}", e)))?;
+
+    let mut memories = Vec::with_capacity(snapshot.version_map.len());
+    for id in snapshot.version_map.keys() {
+        let memory = store
+            .manager()
+            .get_memory(id)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?;
+        if let Some(memory) = memory {
+            memories.push(memory);
+        }
+    }
+    Ok(memories)
+}
+
+/// Count memories in the agent's local store without loading them.
+async fn count_memories(agent: &Agent) -> Result<usize> {
+    let store = local_store(agent)?;
+    let snapshot = store
+        .create_snapshot(None, None)
+        .await
+        .map_err(|e| CoreError::Memory(format!("Failed to create snapshot: {}", e)))?;
+    Ok(snapshot.version_map.len())
+}
+
+/// A recorded memory count.
+struct CountSample {
+    at: chrono::DateTime<chrono::Utc>,
+    count: usize,
+}
+
+/// Maximum number of count samples retained per agent.
+const MAX_COUNT_SAMPLES: usize = 10_000;
+
+/// Most points returned by `thymos_agent_count_history`.
+const MAX_COUNT_HISTORY_POINTS: i64 = 10_000;
+
+/// Record the memory count every `interval` until aborted.
+fn start_count_sampler(
+    agent: Agent,
+    samples: Arc<Mutex<VecDeque<CountSample>>>,
+    interval: Duration,
+) -> tokio::task::JoinHandle<()> {
+    let handle = RUNTIME.lock().unwrap().handle().clone();
+    handle.spawn(async move {
+        loop {
+            if let Ok(count) = count_memories(&agent).await {
+                let mut samples = samples.lock().unwrap();
+                if samples.len() >= MAX_COUNT_SAMPLES {
+                    samples.pop_front();
+                }
+                samples.push_back(CountSample {
+                    at: chrono::Utc::now(),
+                    count,
+                });
+            }
+            tokio::time::sleep(interval).await;
+        }
+    })
+}
+
+/// Get the memory count over time from recorded samples.
+///
+/// Returns a JSON array of `{"at": "<rfc3339>", "count": n}` points, one per
+/// `interval_ms` from `since_ms` (Unix milliseconds) to now. Each point holds
+/// the latest sample taken at or before it; points before the first sample
+/// are omitted, and of the rest only the latest `MAX_COUNT_HISTORY_POINTS`
+/// are returned. Fails with "count history not available: sampling is
+/// disabled" unless sampling was enabled with
+/// `thymos_memory_config_set_count_sampling`.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_count_history(
+    handle: *const ThymosAgent,
+    since_ms: i64,
+    interval_ms: i64,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(samples) = &(*handle).count_samples else {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            "count history not available: sampling is disabled",
+        );
+        return ptr::null_mut();
+    };
+
+    if interval_ms <= 0 {
+        set_error("Invalid interval: must be positive");
+        return ptr::null_mut();
+    }
+
+    if chrono::DateTime::from_timestamp_millis(since_ms).is_none() {
+        set_error("Invalid since: out of range");
+        return ptr::null_mut();
+    }
+    let now_ms = chrono::Utc::now().timestamp_millis();
+
+    let samples = samples.lock().unwrap();
+    let mut points = Vec::new();
+    if let Some(first) = samples.front().filter(|_| now_ms >= since_ms) {
+        // Points are at since + k * interval; skip those before the first
+        // sample and keep at most the latest MAX_COUNT_HISTORY_POINTS
+        let first_ms = first.at.timestamp_millis();
+        let first_k = if first_ms > since_ms {
+            (first_ms - since_ms + interval_ms - 1) / interval_ms
+        } else {
+            0
+        };
+        let last_k = (now_ms - since_ms) / interval_ms;
+        let start_k = first_k.max(last_k - (MAX_COUNT_HISTORY_POINTS - 1));
+
+        let mut next = 0;
+        let mut latest: Option<usize> = None;
+        for k in start_k..=last_k {
+            let Some(at) = chrono::DateTime::from_timestamp_millis(since_ms + k * interval_ms)
+            else {
+                break;
+            };
+            while next < samples.len() && samples[next].at <= at {
+                latest = Some(samples[next].count);
+                next += 1;
+            }
+            if let Some(count) = latest {
+                points.push(serde_json::json!({ "at": at.to_rfc3339(), "count": count }));
+            }
+        }
+    }
+
+    match serde_json::to_string(&points) {
+        Ok(json) => string_to_cstring(json),
+        Err(e) => {
+            set_error_with_code(ERROR_INTERNAL, e.to_string());
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Get the times a memory was accessed through this handle, oldest first.
+///
+/// Returns a JSON array of RFC 3339 timestamps; a memory never accessed yields
+/// `[]`. Only the latest 10,000 accesses per memory are kept. Fails with
+/// "access timeline not available: tracking is disabled" unless tracking was
+/// enabled with `thymos_memory_config_set_track_access_timeline`.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_access_timeline(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(timelines) = &(*handle).access_timelines else {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            "access timeline not available: tracking is disabled",
+        );
+        return ptr::null_mut();
+    };
+
+    let timestamps: Vec<String> = timelines
+        .lock()
+        .unwrap()
+        .get(&id)
+        .map(|timeline| timeline.iter().map(|at| at.to_rfc3339()).collect())
+        .unwrap_or_default();
+
+    match serde_json::to_string(&timestamps) {
+        Ok(json) => string_to_cstring(json),
+        Err(e) => {
+            set_error_with_code(ERROR_INTERNAL, e.to_string());
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Count memories by age (time since creation).
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `boundaries_ms` - Ascending bucket upper bounds in milliseconds
+/// * `boundary_count` - Number of boundaries
+/// * `out_counts` - Caller-allocated array of `boundary_count + 1` entries
+///
+/// A memory falls into the first bucket whose boundary exceeds its age. The
+/// final entry counts memories older than the last boundary.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `boundaries_ms` must point to `boundary_count` values (may be null if zero).
+/// `out_counts` must point to `boundary_count + 1` writable values.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_age_histogram(
+    handle: *const ThymosAgent,
+    boundaries_ms: *const i64,
+    boundary_count: usize,
+    out_counts: *mut usize,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if out_counts.is_null() || (boundaries_ms.is_null() && boundary_count > 0) {
+        set_error("Histogram buffers are null");
+        return -1;
+    }
+
+    let boundaries: Vec<i64> = if boundary_count > 0 {
+        std::slice::from_raw_parts(boundaries_ms, boundary_count).to_vec()
+    } else {
+        Vec::new()
+    };
+
+    if boundaries.iter().any(|b| *b < 0) || boundaries.windows(2).any(|w| w[0] >= w[1]) {
+        set_error("Invalid buckets: boundaries must be non-negative and strictly ascending");
+        return -1;
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move { load_all_memories(&agent).await }) {
+        Ok(memories) => {
+            let counts = std::slice::from_raw_parts_mut(out_counts, boundary_count + 1);
+            counts.fill(0);
+
+            let now = chrono::Utc::now();
+            for memory in &memories {
+                let age_ms = (now - memory.created_at).num_milliseconds().max(0);
+                let bucket = boundaries
+                    .iter()
+                    .position(|b| age_ms < *b)
+                    .unwrap_or(boundary_count);
+                counts[bucket] += 1;
+            }
+            0
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Detect the language of a text as an ISO 639-3 code (e.g. "eng").
+///
+/// Returns None when detection is not reliable.
+fn detect_language(text: &str) -> Option<&'static str> {
+    whatlang::detect(text)
+        .filter(|info| info.is_reliable())
+        .map(|info| info.lang().code())
+}
+
+/// Get the most common language across the agent's memories.
+///
+/// Returns the ISO 639-3 code of the dominant language and writes the fraction
+/// of language-detectable memories using it to `out_fraction`. Returns an empty
+/// string and a fraction of 0 when no memory has a detectable language.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `out_fraction` must be a valid pointer.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_dominant_language(
+    handle: *const ThymosAgent,
+    out_fraction: *mut f64,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if out_fraction.is_null() {
+        set_error("out_fraction is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move { load_all_memories(&agent).await }) {
+        Ok(memories) => {
+            let mut counts: HashMap<&'static str, usize> = HashMap::new();
+            for memory in &memories {
+                if let Some(code) = detect_language(&memory.content) {
+                    *counts.entry(code).or_insert(0) += 1;
+                }
+            }
+
+            let detected: usize = counts.values().sum();
+            match counts.into_iter().max_by(|a, b| a.1.cmp(&b.1).then(b.0.cmp(a.0))) {
+                Some((code, n)) => {
+                    *out_fraction = n as f64 / detected as f64;
+                    string_to_cstring(code.to_string())
+                }
+                None => {
+                    *out_fraction = 0.0;
+                    string_to_cstring(String::new())
+                }
+            }
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Split text into lowercase alphanumeric terms.
+fn tokenize(text: &str) -> impl Iterator<Item = String> + '_ {
+    text.split(|c: char| !c.is_alphanumeric())
+        .filter(|term| !term.is_empty())
+        .map(|term| term.to_lowercase())
+}
+
+/// Count raw terms across all of the agent's memories.
+///
+/// Returns a JSON array of `{"term": "...", "count": n}` objects, most frequent
+/// first with ties broken alphabetically. Terms are lowercased alphanumeric
+/// runs; no stemming or stop-word removal is applied.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `top_n` - Maximum number of terms (0 = all)
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_term_frequencies(
+    handle: *const ThymosAgent,
+    top_n: usize,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let mut counts: HashMap<String, usize> = HashMap::new();
+        for memory in load_all_memories(&agent).await? {
+            for term in tokenize(&memory.content) {
+                *counts.entry(term).or_default() += 1;
+            }
+        }
+
+        let mut terms: Vec<(String, usize)> = counts.into_iter().collect();
+        terms.sort_by(|a, b| b.1.cmp(&a.1).then_with(|| a.0.cmp(&b.0)));
+        if top_n > 0 {
+            terms.truncate(top_n);
+        }
+
+        let terms: Vec<_> = terms
+            .into_iter()
+            .map(|(term, count)| serde_json::json!({ "term": term, "count": count }))
+            .collect();
+        Ok(serde_json::to_string(&terms)?)
+    }) {
+        Ok(json) => string_to_cstring(json),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Blob Attachments
+// ============================================================================
+
+/// Check that a memory ID or blob name is a single, safe path component.
+fn validate_path_component(kind: &str, value: &str) -> Result<()> {
+    if value.is_empty() || value == "." || value == ".." || value.contains(['/', '\\', '\0']) {
+        return Err(CoreError::Memory(format!("Invalid {}: '{}'", kind, value)));
+    }
+    Ok(())
+}
+
+/// Path of a memory's blob, validating both components.
+fn blob_path(handle: &ThymosAgent, memory_id: &str, name: &str) -> Result<PathBuf> {
+    validate_path_component("memory ID", memory_id)?;
+    validate_path_component("blob name", name)?;
+
+    let Some(data_dir) = &handle.data_dir else {
+        return Err(CoreError::Configuration(
+            "Blobs require local storage (embedded or hybrid mode)".to_string(),
+        ));
+    };
+    Ok(data_dir.join("blobs").join(memory_id).join(name))
+}
+
+/// Store bytes under a name alongside a memory.
+///
+/// Blobs live under `<data_dir>/blobs/<memory_id>/<name>`; attaching an
+/// existing name replaces it. Blobs larger than the configured maximum (see
+/// `thymos_memory_config_set_max_blob_size`) are rejected.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` and `name` must be valid null-terminated UTF-8 strings.
+/// `data` must point to `len` readable bytes (or be null when `len` is 0).
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_attach_blob(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    name: *const c_char,
+    data: *const u8,
+    len: usize,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    if data.is_null() && len > 0 {
+        set_error("Blob data is null");
+        return -1;
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return -1;
+    };
+
+    let Some(name_str) = cstr_to_string(name) else {
+        set_error("Invalid name: not valid UTF-8");
+        return -1;
+    };
+
+    let max = (*handle).options.max_blob_size;
+    if len > max {
+        set_error(format!(
+            "Blob '{}' is {} bytes, exceeding the {} byte limit",
+            name_str, len, max
+        ));
+        return -1;
+    }
+
+    let path = match blob_path(&*handle, &id, &name_str) {
+        Ok(path) => path,
+        Err(e) => {
+            set_thymos_error(&e);
+            return -1;
+        }
+    };
+
+    let bytes = if len == 0 {
+        Vec::new()
+    } else {
+        std::slice::from_raw_parts(data, len).to_vec()
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        if agent.get_memory(&id).await?.is_none() {
+            return Err(CoreError::Memory(format!("Memory '{}' not found", id)));
+        }
+
+        let dir = path.parent().expect("blob path has a parent");
+        tokio::fs::create_dir_all(dir).await?;
+        let tmp = dir.join(format!(".{}.tmp", name_str));
+        tokio::fs::write(&tmp, &bytes).await?;
+        tokio::fs::rename(&tmp, &path).await?;
+        Ok(())
+    }) {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Read a blob attached to a memory.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` and `name` must be valid null-terminated UTF-8 strings.
+/// `out_len` must be a valid pointer.
+/// The returned buffer must be freed with `thymos_free_bytes`.
+///
+/// Returns the blob bytes (non-null even when empty), or null on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_get_blob(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    name: *const c_char,
+    out_len: *mut usize,
+    out_error: *mut ThymosError,
+) -> *mut u8 {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if out_len.is_null() {
+        set_error("out_len is null");
+        return ptr::null_mut();
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(name_str) = cstr_to_string(name) else {
+        set_error("Invalid name: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let path = match blob_path(&*handle, &id, &name_str) {
+        Ok(path) => path,
+        Err(e) => {
+            set_thymos_error(&e);
+            return ptr::null_mut();
+        }
+    };
+
+    match std::fs::read(&path) {
+        Ok(bytes) => {
+            let bytes = bytes.into_boxed_slice();
+            *out_len = bytes.len();
+            Box::into_raw(bytes) as *mut u8
+        }
+        Err(e) if e.kind() == std::io::ErrorKind::NotFound => {
+            set_error_with_code(
+                ERROR_NOT_FOUND,
+                format!("Blob '{}' not found for memory '{}'", name_str, id),
+            );
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_error_with_code(ERROR_STORAGE, e.to_string());
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Saved Queries
+// ============================================================================
+
+/// Error message set when no saved query has the requested name.
+const SAVED_QUERY_NOT_FOUND: &str = "saved query not found";
+
+/// Serializes access to saved query files.
+static SAVED_QUERIES_LOCK: Lazy<Mutex<()>> = Lazy::new(|| Mutex::new(()));
+
+/// A persisted search definition.
+#[derive(Serialize, Deserialize)]
+struct SavedQuery {
+    text: String,
+    /// Memory type code as for `thymos_agent_search_by_type`; None searches all
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    memory_type: Option<c_int>,
+}
+
+/// Path of the agent's saved query file.
+fn saved_queries_path(handle: &ThymosAgent) -> Result<PathBuf> {
+    let Some(data_dir) = &handle.data_dir else {
+        return Err(CoreError::Configuration(
+            "Saved queries require local storage (embedded or hybrid mode)".to_string(),
+        ));
+    };
+    Ok(data_dir.join("saved_queries.json"))
+}
+
+/// Load the agent's saved queries, empty if none were saved yet.
+fn load_saved_queries(handle: &ThymosAgent) -> Result<BTreeMap<String, SavedQuery>> {
+    let path = saved_queries_path(handle)?;
+    match std::fs::read_to_string(&path) {
+        Ok(data) => Ok(serde_json::from_str(&data)?),
+        Err(e) if e.kind() == std::io::ErrorKind::NotFound => Ok(BTreeMap::new()),
+        Err(e) => Err(e.into()),
+    }
+}
+
+/// Add or replace a saved query, rewriting the file atomically.
+fn store_saved_query(handle: &ThymosAgent, name: String, query: SavedQuery) -> Result<()> {
+    let _guard = SAVED_QUERIES_LOCK.lock().unwrap();
+    let mut queries = load_saved_queries(handle)?;
+    queries.insert(name, query);
+
+    let path = saved_queries_path(handle)?;
+    let dir = path.parent().expect("saved query path has a parent");
+    std::fs::create_dir_all(dir)?;
+    let tmp = dir.join(".saved_queries.json.tmp");
+    std::fs::write(&tmp, serde_json::to_string_pretty(&queries)?)?;
+    std::fs::rename(&tmp, &path)?;
+    Ok(())
+}
+
+/// Save a named query with the agent.
+///
+/// `query_json` is `{"text": "...", "memory_type": 1}`, where `memory_type` is
+/// optional and uses the codes of `thymos_agent_search_by_type`. Queries are
+/// stored in `<data_dir>/saved_queries.json`, so they survive restarts; saving
+/// an existing name replaces it.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `name` and `query_json` must be valid null-terminated UTF-8 strings.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_save_query(
+    handle: *const ThymosAgent,
+    name: *const c_char,
+    query_json: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(name_str) = cstr_to_string(name).filter(|n| !n.is_empty()) else {
+        set_error("Invalid name: empty or not valid UTF-8");
+        return -1;
+    };
+
+    let Some(query_str) = cstr_to_string(query_json) else {
+        set_error("Invalid query_json: not valid UTF-8");
+        return -1;
+    };
+
+    let query: SavedQuery = match serde_json::from_str(&query_str) {
+        Ok(query) => query,
+        Err(e) => {
+            set_error(format!("Invalid query_json: {}", e));
+            return -1;
+        }
+    };
+    if let Some(code) = query.memory_type {
+        if memory_type_from_code(code).is_none() {
+            set_error(format!("Invalid memory_type: {}", code));
+            return -1;
+        }
+    }
+
+    match store_saved_query(&*handle, name_str, query) {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Run a saved query.
+///
+/// Searches like `thymos_agent_search_memories`, or like
+/// `thymos_agent_search_by_type` when the query has a memory type. Fails with
+/// "saved query not found" if no query has the name.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `name` must be a valid null-terminated UTF-8 string.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_run_saved_query(
+    handle: *const ThymosAgent,
+    name: *const c_char,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(name_str) = cstr_to_string(name) else {
+        set_error("Invalid name: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let loaded = {
+        let _guard = SAVED_QUERIES_LOCK.lock().unwrap();
+        load_saved_queries(&*handle)
+    };
+    let query = match loaded.map(|mut queries| queries.remove(&name_str)) {
+        Ok(Some(query)) => query,
+        Ok(None) => {
+            set_error_with_code(ERROR_NOT_FOUND, SAVED_QUERY_NOT_FOUND);
+            return ptr::null_mut();
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            return ptr::null_mut();
+        }
+    };
+
+    let Ok(text) = CString::new(query.text) else {
+        set_error("Invalid saved query: text contains a null byte");
+        return ptr::null_mut();
+    };
+    match query.memory_type {
+        Some(code) => thymos_agent_search_by_type(handle, text.as_ptr(), code, limit, out_error),
+        None => thymos_agent_search_memories(handle, text.as_ptr(), limit, out_error),
+    }
+}
+
+/// List the names of the agent's saved queries, sorted.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned list must be freed with `thymos_free_string_list`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_list_saved_queries(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut ThymosStringList {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let loaded = {
+        let _guard = SAVED_QUERIES_LOCK.lock().unwrap();
+        load_saved_queries(&*handle)
+    };
+    match loaded {
+        Ok(queries) => Box::into_raw(Box::new(ThymosStringList::from_strings(
+            queries.into_keys().collect(),
+        ))),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Embedding Repair
+// ============================================================================
+
+/// Load memories stored without an embedding, oldest first.
+async fn load_unembedded(agent: &Agent) -> Result<Vec<locai::models::Memory>> {
+    let mut memories: Vec<_> = load_all_memories(agent)
+        .await?
+        .into_iter()
+        .filter(|m| m.embedding.is_none())
+        .collect();
+    memories.sort_by_key(|m| m.created_at);
+    Ok(memories)
+}
+
+/// List memories stored without an embedding, oldest first.
+///
+/// Such memories are invisible to vector search, e.g. after an embedder outage
+/// during ingestion. Repair them with `thymos_agent_embed_pending`.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_unembedded(
+    handle: *const ThymosAgent,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let mut memories = load_unembedded(&agent).await?;
+        if limit > 0 {
+            memories.truncate(limit);
+        }
+        Ok(memories)
+    }) {
+        Ok(memories) => (*handle).results(&memories),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Embed every memory stored without an embedding.
+///
+/// Uses the agent's embedding provider. Memories embedded before a failure
+/// stay embedded.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+///
+/// Returns the number of memories embedded, or -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_embed_pending(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> i64 {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let agent = (*handle).inner.clone();
+    let Some(provider) = agent.embedding_provider().cloned() else {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            format!(
+                "Agent '{}' has no embedding provider configured",
+                agent.id()
+            ),
+        );
+        return -1;
+    };
+
+    let locks = (*handle).memory_locks.clone();
+    let changed_ids = (*handle).changed_ids.clone();
+    let result = block_on(async move {
+        let store = local_store(&agent)?;
+        let mut embedded = 0i64;
+        for pending in load_unembedded(&agent).await? {
+            // Reread under the lock so a concurrent update is not overwritten
+            let _guard = locks.lock(&pending.id).await;
+            let Some(mut memory) = store
+                .manager()
+                .get_memory(&pending.id)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?
+                .filter(|m| m.embedding.is_none())
+            else {
+                continue;
+            };
+            memory.embedding = Some(provider.embed(&memory.content).await?);
+            stamp_modified(&mut memory, chrono::Utc::now());
+            store
+                .manager()
+                .update_memory(memory)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+            changed_ids.lock().unwrap().insert(pending.id);
+            embedded += 1;
+        }
+        Ok(embedded)
+    });
+    (*handle).invalidate_query_cache();
+
+    match result {
+        Ok(count) => count,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Contradiction Detection
+// ============================================================================
+
+/// Tokens that negate a statement.
+const NEGATIONS: &[&str] = &[
+    "not", "no", "never", "isn", "aren", "wasn", "weren", "doesn", "don", "didn", "t",
+];
+
+/// Verbs that link a subject to the object a contradiction can differ on.
+const RELATIONS: &[&str] = &[
+    "is", "are", "was", "were", "has", "have", "had", "lives", "live", "located", "born",
+];
+
+/// Longest object (in terms) compared for divergent-object contradictions.
+const MAX_OBJECT_TERMS: usize = 4;
+
+/// Confidence that two tokenized statements contradict each other, if they do.
+///
+/// Two patterns are recognized:
+/// * negation - the statements are identical apart from negation terms
+///   ("Paris is in France" / "Paris is not in France")
+/// * divergent object - the statements share a subject and relation but end
+///   in short, disjoint objects ("Paris is in France" / "Paris is in Italy")
+fn contradiction_confidence(a: &[String], b: &[String]) -> Option<f64> {
+    if a == b {
+        return None;
+    }
+
+    let affirm = |terms: &[String]| -> Vec<String> {
+        terms
+            .iter()
+            .filter(|t| !NEGATIONS.contains(&t.as_str()))
+            .cloned()
+            .collect()
+    };
+    if affirm(a) == affirm(b) {
+        return Some(0.9);
+    }
+
+    let prefix = a.iter().zip(b).take_while(|(x, y)| x == y).count();
+    let (tail_a, tail_b) = (&a[prefix..], &b[prefix..]);
+    let has_relation = a[..prefix].iter().any(|t| RELATIONS.contains(&t.as_str()));
+    let longest = tail_a.len().max(tail_b.len());
+    if prefix < 2
+        || !has_relation
+        || tail_a.is_empty()
+        || tail_b.is_empty()
+        || longest > MAX_OBJECT_TERMS
+        || tail_a.iter().any(|t| tail_b.contains(t))
+    {
+        return None;
+    }
+    Some(prefix as f64 / (prefix + longest) as f64)
+}
+
+/// Find pairs of memories that appear to contradict each other.
+///
+/// Detection is heuristic and lexical (see `contradiction_confidence`); only
+/// memories sharing their first two terms are compared. Pairs are returned
+/// flattened in the results (memories 2i and 2i+1 form pair i), most confident
+/// first, with each pair's confidence in `(*out_confidences)[i]`.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `limit` - Maximum number of pairs (0 = no limit)
+/// * `out_confidences` - Receives the confidence of each pair
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `out_confidences` must be a valid pointer.
+/// The returned results must be freed with `thymos_free_search_results` and
+/// `*out_confidences` with `thymos_free_doubles(*out_confidences, count / 2)`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_contradictions(
+    handle: *const ThymosAgent,
+    limit: usize,
+    out_confidences: *mut *mut f64,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if out_confidences.is_null() {
+        set_error("out_confidences is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let now = chrono::Utc::now();
+        let mut memories = load_all_memories(&agent).await?;
+        memories.retain(|m| !memory_expired(m, now));
+        let terms: Vec<Vec<String>> = memories
+            .iter()
+            .map(|m| tokenize(&m.content).collect())
+            .collect();
+
+        let mut groups: HashMap<&[String], Vec<usize>> = HashMap::new();
+        for (i, t) in terms.iter().enumerate() {
+            if t.len() >= 2 {
+                groups.entry(&t[..2]).or_default().push(i);
+            }
+        }
+
+        let mut pairs: Vec<(f64, usize, usize)> = Vec::new();
+        for members in groups.values() {
+            for (n, &i) in members.iter().enumerate() {
+                for &j in &members[n + 1..] {
+                    if let Some(confidence) = contradiction_confidence(&terms[i], &terms[j]) {
+                        pairs.push((confidence, i, j));
+                    }
+                }
+            }
+        }
+
+        pairs.sort_by(|a, b| b.0.total_cmp(&a.0).then((a.1, a.2).cmp(&(b.1, b.2))));
+        if limit > 0 {
+            pairs.truncate(limit);
+        }
+
+        let confidences: Vec<f64> = pairs.iter().map(|p| p.0).collect();
+        let flattened: Vec<locai::models::Memory> = pairs
+            .iter()
+            .flat_map(|&(_, i, j)| [memories[i].clone(), memories[j].clone()])
+            .collect();
+        Ok((flattened, confidences))
+    }) {
+        Ok((memories, confidences)) => {
+            *out_confidences = Box::into_raw(confidences.into_boxed_slice()) as *mut f64;
+            (*handle).checked_results(&memories, &[])
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Graph Export
+// ============================================================================
+
+/// Extract significant concepts from text.
+///
+/// Uses the agent's configured extractor, falling back to the regex-based
+/// default extractor.
+async fn extract_concepts(agent: &Agent, text: &str) -> Result<Vec<Concept>> {
+    let concepts = match agent.concept_extractor() {
+        Some(extractor) => extractor.extract(text, None).await?,
+        None => {
+            BasicConceptExtractor::new(ConceptExtractionConfig::default())?
+                .extract(text, None)
+                .await?
+        }
+    };
+    Ok(concepts.into_iter().filter(|c| c.is_significant).collect())
+}
+
+/// Stable node ID for an extracted entity.
+fn entity_node_id(concept: &Concept) -> String {
+    format!(
+        "entity:{}:{}",
+        concept.concept_type,
+        concept.text.to_lowercase()
+    )
+}
+
+/// Concepts of `memory`, from its concept index entry when that matches the
+/// memory's current content and extracted otherwise.
+async fn memory_concepts(
+    agent: &Agent,
+    indexed: Option<&IndexedConcepts>,
+    memory: &locai::models::Memory,
+) -> Result<Vec<Concept>> {
+    match indexed {
+        Some(indexed) if indexed.content == memory.content => Ok(indexed.concepts.clone()),
+        _ => extract_concepts(agent, &memory.content).await,
+    }
+}
+
+/// Export the memory/entity graph as JSON.
+///
+/// Format:
+/// ```json
+/// {
+///   "nodes": [{"id": "memory:<id>", "kind": "memory", "label": "<content>"},
+///             {"id": "entity:<type>:<text>", "kind": "entity", "label": "<text>", "entity_type": "<type>"}],
+///   "edges": [{"source": "memory:<id>", "target": "entity:...", "kind": "mentions", "weight": 0.8},
+///             {"source": "entity:...", "target": "entity:...", "kind": "co_occurs", "weight": 2.0}]
+/// }
+/// ```
+///
+/// `mentions` edges carry the concept significance; `co_occurs` edges carry the
+/// number of memories mentioning both entities. Nodes and edges are ordered
+/// deterministically. Concepts come from the concept index when it holds an
+/// entry for the memory's current content, and are extracted otherwise.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_export_graph(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    let index = (*handle).concept_index.lock().unwrap().clone();
+    match block_on(async move {
+        let mut memories = load_all_memories(&agent).await?;
+        memories.sort_by(|a, b| a.created_at.cmp(&b.created_at).then(a.id.cmp(&b.id)));
+
+        let mut nodes = Vec::new();
+        let mut edges = Vec::new();
+        let mut entities: HashSet<String> = HashSet::new();
+        let mut co_occurs: BTreeMap<(String, String), usize> = BTreeMap::new();
+
+        for memory in &memories {
+            let memory_node = format!("memory:{}", memory.id);
+            nodes.push(serde_json::json!({
+                "id": memory_node,
+                "kind": "memory",
+                "label": memory.content,
+            }));
+
+            let mut mentioned: Vec<String> = Vec::new();
+            for concept in memory_concepts(&agent, index.get(&memory.id), memory).await? {
+                let entity_node = entity_node_id(&concept);
+                if mentioned.contains(&entity_node) {
+                    continue;
+                }
+                if entities.insert(entity_node.clone()) {
+                    nodes.push(serde_json::json!({
+                        "id": entity_node,
+                        "kind": "entity",
+                        "label": concept.text,
+                        "entity_type": concept.concept_type,
+                    }));
+                }
+                edges.push(serde_json::json!({
+                    "source": memory_node,
+                    "target": entity_node,
+                    "kind": "mentions",
+                    "weight": concept.significance,
+                }));
+                mentioned.push(entity_node);
+            }
+
+            mentioned.sort();
+            for (i, a) in mentioned.iter().enumerate() {
+                for b in &mentioned[i + 1..] {
+                    *co_occurs.entry((a.clone(), b.clone())).or_insert(0) += 1;
+                }
+            }
+        }
+
+        for ((a, b), count) in co_occurs {
+            edges.push(serde_json::json!({
+                "source": a,
+                "target": b,
+                "kind": "co_occurs",
+                "weight": count as f64,
+            }));
+        }
+
+        Ok(serde_json::to_string(
+            &serde_json::json!({ "nodes": nodes, "edges": edges }),
+        )?)
+    }) {
+        Ok(json) => string_to_cstring(json),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Concept Index
+// ============================================================================
+
+/// Significant concepts extracted from a memory's content.
+#[derive(Clone)]
+struct IndexedConcepts {
+    content: String,
+    concepts: Vec<Concept>,
+}
+
+/// A concept index rebuild that has not finished yet.
+struct ConceptRebuild {
+    /// Memory IDs still to extract, in reverse processing order
+    pending: Vec<String>,
+    total: usize,
+    index: HashMap<String, IndexedConcepts>,
+}
+
+/// Rebuild the concept index from scratch, one batch at a time.
+///
+/// The first call snapshots the IDs of every stored memory; each call then
+/// extracts concepts for up to `batch_size` of them (0 processes all). The
+/// new index replaces the old one only once every memory is processed, so an
+/// interrupted rebuild leaves the previous index in place and resumes where
+/// it stopped on the next call. Memories deleted mid-rebuild are skipped.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `out_done` and `out_total` must be valid pointers or null.
+///
+/// Returns 1 when the rebuild is complete, 0 if memories remain, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_rebuild_concepts(
+    handle: *const ThymosAgent,
+    batch_size: usize,
+    out_done: *mut u64,
+    out_total: *mut u64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    let handle = &*handle;
+    let mut rebuild = handle.concept_rebuild.lock().unwrap();
+    let state = match rebuild.take() {
+        Some(state) => state,
+        None => {
+            let agent = handle.inner.clone();
+            match block_on(async move { load_all_memories(&agent).await }) {
+                Ok(mut memories) => {
+                    memories.sort_by(|a, b| b.created_at.cmp(&a.created_at).then(b.id.cmp(&a.id)));
+                    ConceptRebuild {
+                        total: memories.len(),
+                        pending: memories.into_iter().map(|m| m.id).collect(),
+                        index: HashMap::new(),
+                    }
+                }
+                Err(e) => {
+                    set_thymos_error(&e);
+                    return -1;
+                }
+            }
+        }
+    };
+
+    let agent = handle.inner.clone();
+    let (state, result) = block_on_value(async move {
+        let mut state = state;
+        let result = async {
+            let store = local_store(&agent)?;
+            let mut processed = 0;
+            while let Some(id) = state.pending.last().cloned() {
+                if batch_size > 0 && processed == batch_size {
+                    break;
+                }
+                let memory = store
+                    .manager()
+                    .get_memory(&id)
+                    .await
+                    .map_err(|e| CoreError::Memory(e.to_string()))?;
+                if let Some(memory) = memory {
+                    let concepts = extract_concepts(&agent, &memory.content).await?;
+                    state.index.insert(
+                        id,
+                        IndexedConcepts {
+                            content: memory.content,
+                            concepts,
+                        },
+                    );
+                }
+                state.pending.pop();
+                processed += 1;
+            }
+            Ok::<_, CoreError>(())
+        }
+        .await;
+        (state, result)
+    });
+
+    if !out_done.is_null() {
+        *out_done = (state.total - state.pending.len()) as u64;
+    }
+    if !out_total.is_null() {
+        *out_total = state.total as u64;
+    }
+
+    if let Err(e) = result {
+        *rebuild = Some(state);
+        set_thymos_error(&e);
+        return -1;
+    }
+    if !state.pending.is_empty() {
+        *rebuild = Some(state);
+        return 0;
+    }
+    *handle.concept_index.lock().unwrap() = state.index;
+    1
+}
+
+/// Get the significant concepts extracted from a memory as JSON.
+///
+/// Format: `[{"name": "Paris", "type": "location", "confidence": 0.8}]`,
+/// where `confidence` is the concept's significance score (0.0-1.0).
+/// Concepts come from the concept index when it holds an entry for the
+/// memory's current content, and are extracted otherwise.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+/// The returned string must be freed with `thymos_free_string`.
+///
+/// Returns null on error; unknown IDs fail with `THYMOS_ERROR_NOT_FOUND`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_get_concepts(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let indexed = (*handle).concept_index.lock().unwrap().get(&id).cloned();
+    let result = block_on(async move {
+        let Some(memory) = local_store(&agent)?
+            .manager()
+            .get_memory(&id)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?
+        else {
+            return Ok(None);
+        };
+
+        let concepts: Vec<_> = memory_concepts(&agent, indexed.as_ref(), &memory)
+            .await?
+            .into_iter()
+            .map(|concept| {
+                serde_json::json!({
+                    "name": concept.text,
+                    "type": concept.concept_type,
+                    "confidence": concept.significance,
+                })
+            })
+            .collect();
+        Ok(Some(serde_json::to_string(&concepts)?))
+    });
+
+    match result {
+        Ok(Some(json)) => string_to_cstring(json),
+        Ok(None) => {
+            set_error_with_code(ERROR_NOT_FOUND, "memory not found");
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Find memories that share extracted entities with a memory.
+///
+/// Each related memory's score is its connection strength: the sum, over
+/// shared entities, of the product of the entity's significance in both
+/// memories. Results are ordered strongest first, newest first on ties.
+/// Memories sharing no entity are omitted. Concepts come from the concept
+/// index where current; memories it lacks or holds for older content are
+/// extracted and added to it, so only the first call on an unindexed store
+/// extracts every memory.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `memory_id` - ID of the memory to start from
+/// * `limit` - Maximum number of results (0 = no limit)
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+/// The returned results must be freed with `thymos_free_search_results`.
+///
+/// Returns null on error; unknown IDs fail with `THYMOS_ERROR_NOT_FOUND`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_related_memories(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let handle = &*handle;
+    let agent = handle.inner.clone();
+    let memories = match block_on(async move { load_all_memories(&agent).await }) {
+        Ok(memories) => memories,
+        Err(e) => {
+            set_thymos_error(&e);
+            return ptr::null_mut();
+        }
+    };
+    if !memories.iter().any(|m| m.id == id) {
+        set_error_with_code(ERROR_NOT_FOUND, "memory not found");
+        return ptr::null_mut();
+    }
+
+    // Extract only memories the index lacks or has for older content, and
+    // keep what was extracted so later calls reuse it.
+    let stale: Vec<locai::models::Memory> = {
+        let index = handle.concept_index.lock().unwrap();
+        memories
+            .iter()
+            .filter(|m| {
+                index
+                    .get(&m.id)
+                    .is_none_or(|indexed| indexed.content != m.content)
+            })
+            .cloned()
+            .collect()
+    };
+    if !stale.is_empty() {
+        let agent = handle.inner.clone();
+        let extracted = block_on(async move {
+            let mut extracted = Vec::with_capacity(stale.len());
+            for memory in stale {
+                let concepts = extract_concepts(&agent, &memory.content).await?;
+                extracted.push((
+                    memory.id,
+                    IndexedConcepts {
+                        content: memory.content,
+                        concepts,
+                    },
+                ));
+            }
+            Ok(extracted)
+        });
+        match extracted {
+            Ok(extracted) => handle.concept_index.lock().unwrap().extend(extracted),
+            Err(e) => {
+                set_thymos_error(&e);
+                return ptr::null_mut();
+            }
+        }
+    }
+
+    let mut related = Vec::new();
+    {
+        let index = handle.concept_index.lock().unwrap();
+        let concepts_of = |memory: &locai::models::Memory| {
+            index
+                .get(&memory.id)
+                .filter(|indexed| indexed.content == memory.content)
+                .map(|indexed| indexed.concepts.as_slice())
+                .unwrap_or_default()
+        };
+
+        let mut entities: HashMap<String, f64> = HashMap::new();
+        for memory in memories.iter().filter(|m| m.id == id) {
+            for concept in concepts_of(memory) {
+                let significance = entities.entry(entity_node_id(concept)).or_insert(0.0);
+                *significance = significance.max(concept.significance);
+            }
+        }
+
+        if !entities.is_empty() {
+            for memory in &memories {
+                if memory.id == id {
+                    continue;
+                }
+                let mut shared: HashMap<String, f64> = HashMap::new();
+                for concept in concepts_of(memory) {
+                    let node = entity_node_id(concept);
+                    if let Some(origin_significance) = entities.get(&node) {
+                        let strength = shared.entry(node).or_insert(0.0);
+                        *strength = strength.max(origin_significance * concept.significance);
+                    }
+                }
+                if !shared.is_empty() {
+                    related.push((shared.values().sum::<f64>(), memory.clone()));
+                }
+            }
+        }
+    }
+
+    related.sort_by(|a, b| {
+        b.0.total_cmp(&a.0)
+            .then_with(|| b.1.created_at.cmp(&a.1.created_at))
+            .then_with(|| a.1.id.cmp(&b.1.id))
+    });
+    if limit > 0 {
+        related.truncate(limit);
+    }
+    let (scores, memories): (Vec<f64>, Vec<_>) = related.into_iter().unzip();
+    handle.record_access(&memories);
+    handle.scored_results(&memories, &scores)
+}
+
+// ============================================================================
+// Knowledge Overlap
+// ============================================================================
+
+/// Cosine similarity at or above which two memories count as the same knowledge.
+const OVERLAP_SIMILARITY_THRESHOLD: f32 = 0.85;
+
+/// Cosine similarity between two vectors of equal length.
+fn cosine_similarity(a: &[f32], b: &[f32]) -> f32 {
+    let dot: f32 = a.iter().zip(b).map(|(x, y)| x * y).sum();
+    let norm_a: f32 = a.iter().map(|x| x * x).sum::<f32>().sqrt();
+    let norm_b: f32 = b.iter().map(|x| x * x).sum::<f32>().sqrt();
+    if norm_a == 0.0 || norm_b == 0.0 {
+        0.0
+    } else {
+        dot / (norm_a * norm_b)
+    }
+}
+
+/// Check that two agents embed with the same model, so that their vectors
+/// can be compared. Model names are compared when both handles were built
+/// from a configuration naming one; provider dimensions always are.
+fn check_same_embeddings(a: &ThymosAgent, b: &ThymosAgent) -> Result<()> {
+    if let (Some(model_a), Some(model_b)) = (&a.embedding_model, &b.embedding_model) {
+        if model_a != model_b {
+            return Err(CoreError::Configuration(format!(
+                "Embedding model mismatch: '{}' uses {}, '{}' uses {}",
+                a.inner.id(),
+                model_a,
+                b.inner.id(),
+                model_b
+            )));
+        }
+    }
+
+    let dims = (
+        a.inner.embedding_provider().map(|p| p.dimension()),
+        b.inner.embedding_provider().map(|p| p.dimension()),
+    );
+    if let (Some(dim_a), Some(dim_b)) = dims {
+        if dim_a != dim_b {
+            return Err(CoreError::Configuration(format!(
+                "Embedding dimension mismatch: '{}' uses {}, '{}' uses {}",
+                a.inner.id(),
+                dim_a,
+                b.inner.id(),
+                dim_b
+            )));
+        }
+    }
+    Ok(())
+}
+
+/// Embeddings of every memory in the agent's local store.
+///
+/// Stored embeddings of the provider's dimension are reused; memories
+/// without one are embedded `batch_size` at a time.
+async fn embed_all_memories(agent: &Agent, batch_size: usize) -> Result<Vec<Vec<f32>>> {
+    let Some(provider) = agent.embedding_provider().cloned() else {
+        return Err(CoreError::Configuration(format!(
+            "Agent '{}' has no embedding provider configured",
+            agent.id()
+        )));
+    };
+
+    let dimension = provider.dimension();
+    let mut embeddings = Vec::new();
+    let mut unembedded = Vec::new();
+    for memory in load_all_memories(agent).await? {
+        match memory.embedding {
+            Some(embedding) if embedding.len() == dimension => embeddings.push(embedding),
+            _ => unembedded.push(memory.content),
+        }
+    }
+
+    for chunk in unembedded.chunks(batch_size) {
+        let texts: Vec<&str> = chunk.iter().map(String::as_str).collect();
+        let batch = provider.embed_batch(&texts).await?;
+        if batch.len() != texts.len() {
+            return Err(CoreError::Memory(format!(
+                "Embedding provider returned {} embeddings for {} contents",
+                batch.len(),
+                texts.len()
+            )));
+        }
+        embeddings.extend(batch);
+    }
+    Ok(embeddings)
+}
+
+/// Count embeddings in `from` with a close match in `to`.
+///
+/// Compares every pair, so this takes O(N·M) time for N and M embeddings.
+fn count_matched(from: &[Vec<f32>], to: &[Vec<f32>]) -> usize {
+    from.iter()
+        .filter(|a| {
+            to.iter()
+                .any(|b| cosine_similarity(a, b) >= OVERLAP_SIMILARITY_THRESHOLD)
+        })
+        .count()
+}
+
+/// Compute how much two agents' knowledge overlaps.
+///
+/// Writes a Jaccard-like score in [0, 1] to `out_score`. Memories are matched
+/// across stores by embedding similarity; the intersection is the mean of
+/// matched memories on each side. Both agents must embed with the same model:
+/// configured model names and provider dimensions are compared. Stored
+/// embeddings are reused, and memories without one are embedded in batches of
+/// each agent's embedding batch size. Two empty stores score 0.
+///
+/// Every memory of `a` is compared with every memory of `b`, so the cost is
+/// O(N·M) in the store sizes, with no bound; avoid calling this on large
+/// stores from latency-sensitive paths.
+///
+/// # Safety
+/// `a` and `b` must be valid ThymosAgent handles.
+/// `out_score` must be a valid pointer.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_knowledge_overlap(
+    a: *const ThymosAgent,
+    b: *const ThymosAgent,
+    out_score: *mut f64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if a.is_null() || b.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if out_score.is_null() {
+        set_error("out_score is null");
+        return -1;
+    }
+
+    if let Err(e) = check_same_embeddings(&*a, &*b) {
+        set_thymos_error(&e);
+        return -1;
+    }
+
+    let agent_a = (*a).inner.clone();
+    let agent_b = (*b).inner.clone();
+    let batch_a = (*a).options.embedding_batch_size;
+    let batch_b = (*b).options.embedding_batch_size;
+
+    match block_on(async move {
+        let embeddings_a = embed_all_memories(&agent_a, batch_a).await?;
+        let embeddings_b = embed_all_memories(&agent_b, batch_b).await?;
+
+        if embeddings_a.is_empty() && embeddings_b.is_empty() {
+            return Ok(0.0);
+        }
+
+        let matched_a = count_matched(&embeddings_a, &embeddings_b) as f64;
+        let matched_b = count_matched(&embeddings_b, &embeddings_a) as f64;
+        let intersection = (matched_a + matched_b) / 2.0;
+        let union = (embeddings_a.len() + embeddings_b.len()) as f64 - intersection;
+
+        Ok((intersection / union).clamp(0.0, 1.0))
+    }) {
+        Ok(score) => {
+            *out_score = score;
+            0
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Embedding Outliers
+// ============================================================================
+
+/// Find memories whose embeddings lie farthest from the store centroid.
+///
+/// The centroid is the mean of the stored embeddings of the most common
+/// dimension; distance is cosine distance (1 - cosine similarity) to it.
+/// Memories without a stored embedding, or with another dimension, are
+/// skipped (see `thymos_agent_embed_pending`). Fewer than two embeddings
+/// yield no results. Results are ordered farthest first.
+///
+/// # Arguments
+/// * `handle` - Agent handle
+/// * `limit` - Maximum number of results (0 = no limit)
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_embedding_outliers(
+    handle: *const ThymosAgent,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let memories = load_all_memories(&agent).await?;
+
+        let mut dimensions: HashMap<usize, usize> = HashMap::new();
+        for embedding in memories.iter().filter_map(|m| m.embedding.as_ref()) {
+            *dimensions.entry(embedding.len()).or_default() += 1;
+        }
+        let Some(dimension) = dimensions
+            .into_iter()
+            .max_by_key(|&(dim, count)| (count, dim))
+            .map(|(dim, _)| dim)
+        else {
+            return Ok(Vec::new());
+        };
+
+        let embedded: Vec<locai::models::Memory> = memories
+            .into_iter()
+            .filter(|m| m.embedding.as_ref().is_some_and(|e| e.len() == dimension))
+            .collect();
+        if embedded.len() < 2 {
+            return Ok(Vec::new());
+        }
+
+        let mut centroid = vec![0.0f32; dimension];
+        for memory in &embedded {
+            for (sum, x) in centroid.iter_mut().zip(memory.embedding.iter().flatten()) {
+                *sum += x;
+            }
+        }
+        let n = embedded.len() as f32;
+        centroid.iter_mut().for_each(|x| *x /= n);
+
+        let mut scored: Vec<(f32, locai::models::Memory)> = embedded
+            .into_iter()
+            .map(|memory| {
+                let embedding = memory.embedding.as_deref().unwrap_or_default();
+                (1.0 - cosine_similarity(embedding, &centroid), memory)
+            })
+            .collect();
+        scored.sort_by(|a, b| b.0.total_cmp(&a.0).then_with(|| a.1.id.cmp(&b.1.id)));
+        if limit > 0 {
+            scored.truncate(limit);
+        }
+        Ok(scored.into_iter().map(|(_, memory)| memory).collect())
+    }) {
+        Ok(memories) => (*handle).results(&memories),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Agent Merge
+// ============================================================================
+
+/// Copy every memory from `src` into `dst`, preserving memory IDs.
+///
+/// ID collisions are resolved by `on_conflict`: 0 keeps dst's memory, 1
+/// overwrites it with src's, 2 keeps whichever was created later. A stored
+/// embedding is reused when both agents embed with the same model, compared
+/// as by `thymos_knowledge_overlap`, and it matches the dimension of dst's
+/// provider; otherwise the content is re-embedded with dst's provider.
+/// Without a dst provider, embeddings are copied as-is. `src` is not
+/// modified.
+///
+/// # Safety
+/// `dst` and `src` must be valid ThymosAgent handles.
+///
+/// Returns the number of memories written to dst, or -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_merge_agents(
+    dst: *const ThymosAgent,
+    src: *const ThymosAgent,
+    on_conflict: c_int,
+    out_error: *mut ThymosError,
+) -> i64 {
+    let _error_out = ErrorOut::new(out_error);
+    if dst.is_null() || src.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if ptr::eq(dst, src) {
+        set_error("Cannot merge an agent into itself");
+        return -1;
+    }
+
+    if !check_writable(&*dst) {
+        return -1;
+    }
+
+    if !(0..=2).contains(&on_conflict) {
+        set_error(format!("Invalid conflict policy: {}", on_conflict));
+        return -1;
+    }
+
+    let same_model = check_same_embeddings(&*dst, &*src).is_ok();
+    let dst_agent = (*dst).inner.clone();
+    let src_agent = (*src).inner.clone();
+    let changed_ids = (*dst).changed_ids.clone();
+
+    let result = block_on(async move {
+        let store = local_store(&dst_agent)?;
+        let provider = dst_agent.embedding_provider().cloned();
+        let dimension = provider.as_ref().map(|p| p.dimension());
+
+        let mut merged = 0i64;
+        for mut memory in load_all_memories(&src_agent).await? {
+            let existing = store
+                .manager()
+                .get_memory(&memory.id)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+
+            if let Some(existing) = &existing {
+                let take_src = match on_conflict {
+                    1 => true,
+                    2 => memory.created_at > existing.created_at,
+                    _ => false,
+                };
+                if !take_src {
+                    continue;
+                }
+            }
+
+            memory.embedding = match (memory.embedding.take(), &provider) {
+                (Some(embedding), Some(_)) if same_model && Some(embedding.len()) == dimension => {
+                    Some(embedding)
+                }
+                (_, Some(provider)) => Some(provider.embed(&memory.content).await?),
+                (embedding, None) => embedding,
+            };
+
+            stamp_modified(&mut memory, chrono::Utc::now());
+            let id = memory.id.clone();
+            let result = if existing.is_some() {
+                store.manager().update_memory(memory).await.map(|_| ())
+            } else {
+                store.manager().store_memory(memory).await.map(|_| ())
+            };
+            result.map_err(|e| CoreError::Memory(e.to_string()))?;
+            changed_ids.lock().unwrap().insert(id);
+            merged += 1;
+        }
+        Ok(merged)
+    });
+    (*dst).invalidate_query_cache();
+    (*dst).invalidate_tag_index();
+    (*dst).enforce_retention(None);
+
+    match result {
+        Ok(count) => count,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Shared Batches
+// ============================================================================
+
+/// Commit a batch of shared-backend writes across hybrid agents.
+///
+/// This is not a transaction: the shared backend cannot make several writes
+/// visible at once. Writes are applied one at a time, in order, and are
+/// visible to other agents as soon as each is applied. If one fails, the memories already written by
+/// this call are deleted again before its error is returned. The rollback is
+/// best-effort: a memory whose deletion fails is left in the shared backend,
+/// and the error then reports how many.
+///
+/// # Arguments
+/// * `handles` - Agent handles, one per write
+/// * `contents` - Memory contents, one per write
+/// * `count` - Number of writes
+///
+/// # Safety
+/// `handles` and `contents` must each point to `count` valid entries.
+/// The returned list must be freed with `thymos_free_string_list`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_shared_batch_commit(
+    handles: *const *const ThymosAgent,
+    contents: *const *const c_char,
+    count: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosStringList {
+    let _error_out = ErrorOut::new(out_error);
+    if count > 0 && (handles.is_null() || contents.is_null()) {
+        set_error("Batch buffers are null");
+        return ptr::null_mut();
+    }
+
+    let mut writes = Vec::with_capacity(count);
+    let mut writers = Vec::with_capacity(count);
+    for i in 0..count {
+        let handle = *handles.add(i);
+        if handle.is_null() {
+            set_error("Agent handle is null");
+            return ptr::null_mut();
+        }
+
+        if !check_writable(&*handle) {
+            return ptr::null_mut();
+        }
+
+        let Some(content_str) = cstr_to_string(*contents.add(i)) else {
+            set_error("Invalid content: not valid UTF-8");
+            return ptr::null_mut();
+        };
+
+        let agent = (*handle).inner.clone();
+        if !agent.memory().is_hybrid() {
+            set_error_with_code(
+                ERROR_NOT_HYBRID,
+                "shared batches only available in hybrid mode",
+            );
+            return ptr::null_mut();
+        }
+        writes.push((agent, content_str));
+        writers.push(handle);
+    }
+
+    let result = block_on(async move {
+        let mut written: Vec<(Agent, String)> = Vec::with_capacity(writes.len());
+        for (agent, content) in writes {
+            match agent.remember_shared(content).await {
+                Ok(id) => written.push((agent, id)),
+                Err(e) => {
+                    let mut failed = 0;
+                    for (agent, id) in written.iter().rev() {
+                        if let MemorySystem::Hybrid { hybrid, .. } = agent.memory() {
+                            if !matches!(hybrid.delete_shared(id).await, Ok(true)) {
+                                failed += 1;
+                            }
+                        }
+                    }
+                    if failed > 0 {
+                        return Err(CoreError::Memory(format!(
+                            "{}; rollback failed for {} memories",
+                            e, failed
+                        )));
+                    }
+                    return Err(e);
+                }
+            }
+        }
+        Ok(written.into_iter().map(|(_, id)| id).collect::<Vec<_>>())
+    });
+    for handle in writers {
+        (*handle).invalidate_query_cache();
+    }
+
+    match result {
+        Ok(ids) => Box::into_raw(Box::new(ThymosStringList::from_strings(ids))),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Ingest Benchmark
+// ============================================================================
+
+/// Embed and store each content, recording the IDs stored in `ids`.
+///
+/// Returns the total elapsed time and the per-memory embedding latencies in
+/// microseconds (empty without an embedding provider).
+async fn ingest_samples(
+    agent: &Agent,
+    contents: Vec<String>,
+    ids: &mut Vec<String>,
+) -> Result<(Duration, Vec<i64>)> {
+    let provider = agent.embedding_provider().cloned();
+    let mut embed_micros = Vec::with_capacity(contents.len());
+    let started = Instant::now();
+    for content in contents {
+        let mut options = RememberOptions::new();
+        if let Some(provider) = &provider {
+            let embed_started = Instant::now();
+            let embedding = provider.embed(&content).await?;
+            embed_micros.push(embed_started.elapsed().as_micros() as i64);
+            options = options.with_embedding(embedding);
+        }
+        ids.push(agent.remember_with_options(content, options).await?);
+    }
+    Ok((started.elapsed(), embed_micros))
+}
+
+/// Measure ingestion throughput by storing sample contents, then deleting them.
+///
+/// Each content is embedded with the agent's embedding provider (if any) and
+/// stored as a regular memory. The result is a JSON object with
+/// `memories_per_second` over the whole run and the 50th/99th percentile
+/// embedding latency as `p50_embed_micros`/`p99_embed_micros` (0 without a
+/// provider). Every memory the benchmark stored is deleted again, even when
+/// ingestion fails part-way.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `contents` must point to `count` valid null-terminated UTF-8 strings.
+///
+/// Returns a JSON string (must free with `thymos_free_string`), or null on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_benchmark_ingest(
+    handle: *const ThymosAgent,
+    contents: *const *const c_char,
+    count: usize,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    if count == 0 {
+        set_error("No sample contents to ingest");
+        return ptr::null_mut();
+    }
+
+    let Some(contents) = cstr_array_to_vec(contents, count) else {
+        set_error("Invalid contents: null or not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let result = block_on(async move {
+        let mut ids = Vec::with_capacity(contents.len());
+        let ingested = ingest_samples(&agent, contents, &mut ids).await;
+
+        let mut cleanup = Ok(false);
+        for id in &ids {
+            let deleted = agent.forget(id).await;
+            if cleanup.is_ok() {
+                cleanup = deleted;
+            }
+        }
+
+        let (elapsed, mut embed_micros) = ingested?;
+        cleanup?;
+
+        embed_micros.sort_unstable();
+        let (p50, p99) = if embed_micros.is_empty() {
+            (0, 0)
+        } else {
+            (
+                percentile(&embed_micros, 0.5),
+                percentile(&embed_micros, 0.99),
+            )
+        };
+        let seconds = elapsed.as_secs_f64();
+        let per_second = if seconds > 0.0 {
+            ids.len() as f64 / seconds
+        } else {
+            0.0
+        };
+        Ok(serde_json::to_string(&serde_json::json!({
+            "memories_per_second": per_second,
+            "p50_embed_micros": p50,
+            "p99_embed_micros": p99,
+        }))?)
+    });
+    (*handle).invalidate_query_cache();
+
+    match result {
+        Ok(json) => string_to_cstring(json),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Pub/Sub
+// ============================================================================
+
+/// Content published on a broker topic.
+///
+/// The core pub/sub layer only delivers message content, so the sender is
+/// carried alongside the payload.
+#[derive(Serialize, Deserialize)]
+struct BrokerEnvelope {
+    sender: String,
+    payload: String,
+    sent_at: i64,
+}
+
+/// A message delivered to a subscription, serialized for the caller.
+#[derive(Serialize)]
+struct BrokerMessage {
+    /// ID of the topic subscription, from `thymos_subscription_add`
+    subscription: u64,
+    sender: String,
+    topic: String,
+    payload: String,
+    sent_at: i64,
+}
+
+/// Opaque handle for an in-process pub/sub broker.
+pub struct ThymosBroker {
+    pubsub: Arc<PubSubInstance>,
+}
+
+/// Opaque handle for a subscription to any number of topics.
+///
+/// Messages on all of its topics queue on one channel, so a single reader
+/// can wait for all of them.
+pub struct ThymosSubscription {
+    messages: Mutex<mpsc::Receiver<BrokerMessage>>,
+    sender: mpsc::Sender<BrokerMessage>,
+    /// Topic subscriptions by ID
+    topics: Mutex<HashMap<u64, SubscriptionHandle>>,
+    next_id: std::sync::atomic::AtomicU64,
+}
+
+/// Create an in-process pub/sub broker.
+///
+/// Returns a handle to the broker, or null on error.
+/// Must be freed with `thymos_broker_free`.
+///
+/// # Safety
+/// `out_error` must be null or point to a writable `ThymosError`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_broker_new(out_error: *mut ThymosError) -> *mut ThymosBroker {
+    let _error_out = ErrorOut::new(out_error);
+    match block_on(async { PubSubBuilder::new().local().build().await }) {
+        Ok(pubsub) => Box::into_raw(Box::new(ThymosBroker {
+            pubsub: Arc::new(pubsub),
+        })),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Publish a message to every subscription on `topic`.
+///
+/// # Arguments
+/// * `broker` - Broker handle
+/// * `sender` - ID of the publishing agent (null or empty for none)
+/// * `topic` - Topic name (must not be empty)
+/// * `message` - Message payload
+///
+/// # Safety
+/// `broker` must be a valid ThymosBroker handle.
+/// `sender`, if not null, `topic` and `message` must be valid null-terminated
+/// UTF-8 strings.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_broker_publish(
+    broker: *const ThymosBroker,
+    sender: *const c_char,
+    topic: *const c_char,
+    message: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if broker.is_null() {
+        set_error("Broker handle is null");
+        return -1;
+    }
+
+    let sender = if sender.is_null() {
+        String::new()
+    } else {
+        let Some(sender) = cstr_to_string(sender) else {
+            set_error("Invalid sender: not valid UTF-8");
+            return -1;
+        };
+        sender
+    };
+
+    let Some(topic) = cstr_to_string(topic) else {
+        set_error("Invalid topic: not valid UTF-8");
+        return -1;
+    };
+
+    let Some(payload) = cstr_to_string(message) else {
+        set_error("Invalid message: not valid UTF-8");
+        return -1;
+    };
+
+    let envelope = BrokerEnvelope {
+        sender,
+        payload,
+        sent_at: chrono::Utc::now().timestamp_millis(),
+    };
+    let pubsub = (*broker).pubsub.clone();
+    match block_on(async move { pubsub.publish(&topic, envelope).await }) {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Subscribe to messages published on `topic`.
+///
+/// Same as `thymos_subscription_new` followed by `thymos_subscription_add`.
+///
+/// # Safety
+/// `broker` must be a valid ThymosBroker handle.
+/// `topic` must be a valid null-terminated UTF-8 string.
+///
+/// Returns a subscription handle, or null on error.
+/// Must be freed with `thymos_subscription_free`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_broker_subscribe(
+    broker: *const ThymosBroker,
+    topic: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSubscription {
+    let _error_out = ErrorOut::new(out_error);
+    let subscription = thymos_subscription_new();
+    if thymos_subscription_add(subscription, broker, topic, out_error) == 0 {
+        thymos_subscription_free(subscription);
+        return ptr::null_mut();
+    }
+    subscription
+}
+
+/// Create a subscription with no topics yet.
+///
+/// Add topics with `thymos_subscription_add`; messages on all of them are
+/// queued on the subscription until read with `thymos_subscription_next`.
+///
+/// Must be freed with `thymos_subscription_free`.
+#[unsafe(no_mangle)]
+pub extern "C" fn thymos_subscription_new() -> *mut ThymosSubscription {
+    let (sender, messages) = mpsc::channel();
+    Box::into_raw(Box::new(ThymosSubscription {
+        messages: Mutex::new(messages),
+        sender,
+        topics: Mutex::new(HashMap::new()),
+        next_id: std::sync::atomic::AtomicU64::new(1),
+    }))
+}
+
+/// Add messages published on `topic` through `broker` to a subscription.
+///
+/// The messages carry the returned ID in their `subscription` field. A topic
+/// can be added more than once, each time with its own ID.
+///
+/// # Safety
+/// `subscription` must be a valid ThymosSubscription handle.
+/// `broker` must be a valid ThymosBroker handle.
+/// `topic` must be a valid null-terminated UTF-8 string.
+///
+/// Returns the topic subscription's ID, or 0 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_subscription_add(
+    subscription: *const ThymosSubscription,
+    broker: *const ThymosBroker,
+    topic: *const c_char,
+    out_error: *mut ThymosError,
+) -> u64 {
+    let _error_out = ErrorOut::new(out_error);
+    if subscription.is_null() || broker.is_null() {
+        set_error("Subscription or broker handle is null");
+        return 0;
+    }
+
+    let Some(topic) = cstr_to_string(topic) else {
+        set_error("Invalid topic: not valid UTF-8");
+        return 0;
+    };
+
+    let subscription = &*subscription;
+    let id = subscription
+        .next_id
+        .fetch_add(1, std::sync::atomic::Ordering::Relaxed);
+    let tx = subscription.sender.clone();
+    let pubsub = (*broker).pubsub.clone();
+    let result = block_on(async move {
+        let topic_name = topic.clone();
+        pubsub
+            .subscribe(&topic, move |envelope: BrokerEnvelope| {
+                // A freed subscription drops its receiver; later messages
+                // are discarded.
+                let _ = tx.send(BrokerMessage {
+                    subscription: id,
+                    sender: envelope.sender,
+                    topic: topic_name.clone(),
+                    payload: envelope.payload,
+                    sent_at: envelope.sent_at,
+                });
+                Box::pin(async { Ok(()) })
+                    as std::pin::Pin<Box<dyn std::future::Future<Output = Result<()>> + Send>>
+            })
+            .await
+    });
+
+    match result {
+        Ok(handle) => {
+            subscription.topics.lock().unwrap().insert(id, handle);
+            id
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            0
+        }
+    }
+}
+
+/// Stop delivering a topic to a subscription.
+///
+/// Messages already queued for the topic are still read by
+/// `thymos_subscription_next`.
+///
+/// # Safety
+/// `subscription` must be a valid ThymosSubscription handle.
+///
+/// Returns 0 on success, -1 on error (including an unknown ID).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_subscription_remove(
+    subscription: *const ThymosSubscription,
+    id: u64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if subscription.is_null() {
+        set_error("Subscription handle is null");
+        return -1;
+    }
+
+    let Some(handle) = (*subscription).topics.lock().unwrap().remove(&id) else {
+        set_error_with_code(ERROR_NOT_FOUND, format!("No topic subscription {}", id));
+        return -1;
+    };
+    match block_on(async move { handle.unsubscribe().await }) {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+/// Free a broker.
+///
+/// Existing subscriptions stay valid but receive no further messages from
+/// this broker.
+///
+/// # Safety
+/// `broker` must have been created by `thymos_broker_new`, or be null.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_broker_free(broker: *mut ThymosBroker) {
+    if !broker.is_null() {
+        drop(Box::from_raw(broker));
+    }
+}
+
+/// Wait up to `timeout_ms` for the next message on a subscription.
+///
+/// On success `*out_json` receives a JSON object with `subscription` (the
+/// topic subscription's ID), `sender`, `topic`, `payload` and `sent_at` (Unix
+/// milliseconds), which must be freed with `thymos_free_string`.
+///
+/// # Safety
+/// `subscription` must be a valid ThymosSubscription handle.
+/// `out_json` must be a valid pointer.
+///
+/// Returns 1 when a message was received, 0 on timeout, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_subscription_next(
+    subscription: *const ThymosSubscription,
+    timeout_ms: u64,
+    out_json: *mut *mut c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if subscription.is_null() || out_json.is_null() {
+        set_error("Subscription handle or output pointer is null");
+        return -1;
+    }
+
+    let received = (*subscription)
+        .messages
+        .lock()
+        .unwrap()
+        .recv_timeout(Duration::from_millis(timeout_ms));
+    let message = match received {
+        Ok(message) => message,
+        Err(mpsc::RecvTimeoutError::Timeout) => return 0,
+        Err(mpsc::RecvTimeoutError::Disconnected) => {
+            set_error_with_code(ERROR_INTERNAL, "Subscription closed");
+            return -1;
+        }
+    };
+
+    match serde_json::to_string(&message) {
+        Ok(json) => {
+            *out_json = string_to_cstring(json);
+            1
+        }
+        Err(e) => {
+            set_thymos_error(&e.into());
+            -1
+        }
+    }
+}
+
+/// Unsubscribe and free a subscription.
+///
+/// # Safety
+/// `subscription` must have been created by `thymos_broker_subscribe`, or be
+/// null, and no `thymos_subscription_next` call may still be using it.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_subscription_free(subscription: *mut ThymosSubscription) {
+    if subscription.is_null() {
+        return;
+    }
+
+    let subscription = Box::from_raw(subscription);
+    let topics = std::mem::take(&mut *subscription.topics.lock().unwrap());
+    for handle in topics.into_values() {
+        let _ = block_on(async move { handle.unsubscribe().await });
+    }
+}
+
+// ============================================================================
+// Memory Import
+// ============================================================================
+
+/// A memory in the format read by `thymos_agent_import_memories`.
+#[derive(Deserialize)]
+struct MemoryRecord {
+    content: String,
+    /// Memory type code; codes without a type import as episodic
+    #[serde(default)]
+    memory_type: c_int,
+    #[serde(default)]
+    properties: serde_json::Value,
+    #[serde(default)]
+    created_at: Option<String>,
+    #[serde(default)]
+    last_accessed: Option<String>,
+    #[serde(default)]
+    tags: Vec<String>,
+}
+
+/// Parse an optional RFC 3339 timestamp from an import record.
+fn parse_record_time(
+    field: &str,
+    value: Option<&str>,
+) -> Result<Option<chrono::DateTime<chrono::Utc>>> {
+    value
+        .filter(|v| !v.is_empty())
+        .map(|v| {
+            chrono::DateTime::parse_from_rfc3339(v)
+                .map(|t| t.with_timezone(&chrono::Utc))
+                .map_err(|e| CoreError::InvalidContext(format!("Invalid {}: {}", field, e)))
+        })
+        .transpose()
+}
+
+/// Build an unsaved memory from an import record, with a new ID.
+fn record_to_memory(record: MemoryRecord) -> Result<locai::models::Memory> {
+    let created_at = parse_record_time("created_at", record.created_at.as_deref())?;
+    let last_accessed = parse_record_time("last_accessed", record.last_accessed.as_deref())?;
+
+    let mut memory = locai::models::MemoryBuilder::new_with_content(record.content).build();
+    memory.memory_type =
+        memory_type_from_code(record.memory_type).unwrap_or(locai::models::MemoryType::Episodic);
+    if !record.properties.is_null() {
+        memory.properties = record.properties;
+    }
+    if let Some(created_at) = created_at {
+        memory.created_at = created_at;
+    }
+    memory.last_accessed = last_accessed;
+    memory.tags = record.tags;
+    Ok(memory)
+}
+
+/// Import memories from a JSON array of records.
+///
+/// Each record is `{"content", "memory_type", "properties", "created_at",
+/// "last_accessed", "tags"}`; only `content` is required, and timestamps are
+/// RFC 3339. Memories get new IDs, keep their type, properties, tags and
+/// timestamps, and are re-embedded with the agent's embedding provider. Every
+/// record is validated before anything is stored, but a failure while storing
+/// leaves the memories stored before it in place.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `records_json` must be a valid null-terminated UTF-8 string.
+/// `out_imported` must be a valid pointer; it receives the number of memories
+/// stored, on failure as well as on success.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_import_memories(
+    handle: *const ThymosAgent,
+    records_json: *const c_char,
+    out_imported: *mut usize,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if out_imported.is_null() {
+        set_error("out_imported is null");
+        return -1;
+    }
+    *out_imported = 0;
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(json) = cstr_to_string(records_json) else {
+        set_error("Invalid records_json: not valid UTF-8");
+        return -1;
+    };
+
+    let records: Vec<MemoryRecord> = match serde_json::from_str(&json) {
+        Ok(records) => records,
+        Err(e) => {
+            set_error(format!("Invalid records JSON: {}", e));
+            return -1;
+        }
+    };
+
+    let memories = match records
+        .into_iter()
+        .map(record_to_memory)
+        .collect::<Result<Vec<_>>>()
+    {
+        Ok(memories) => memories,
+        Err(e) => {
+            set_thymos_error(&e);
+            return -1;
+        }
+    };
+
+    let agent = (*handle).inner.clone();
+    let (imported, result) = block_on_value(async move {
+        let mut imported = 0;
+        let result = async {
+            let store = local_store(&agent)?;
+            let provider = agent.embedding_provider().cloned();
+            for mut memory in memories {
+                if let Some(provider) = &provider {
+                    memory.embedding = Some(provider.embed(&memory.content).await?);
+                }
+                store
+                    .manager()
+                    .store_memory(memory)
+                    .await
+                    .map_err(|e| CoreError::Memory(e.to_string()))?;
+                imported += 1;
+            }
+            Ok::<_, CoreError>(())
+        }
+        .await;
+        (imported, result)
+    });
+    *out_imported = imported;
+    (*handle).invalidate_query_cache();
+    (*handle).invalidate_tag_index();
+    (*handle).enforce_retention(Some(imported));
+
+    match result {
+        Ok(()) => 0,
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Memory Export
+// ============================================================================
+
+/// Opaque handle for a fixed set of an agent's local memories, read in pages.
+pub struct ThymosMemoryCursor {
+    /// IDs of the memories stored when the cursor was opened
+    ids: Vec<String>,
+    /// Index in `ids` of the next memory to read
+    next: usize,
+}
+
+/// Open a cursor over the memories in the agent's local store.
+///
+/// The set of memories is fixed by one store snapshot when the cursor is
+/// opened, and only their IDs are read; read the memories in pages with
+/// `thymos_agent_memory_cursor_next`. Memories stored later are not included.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned cursor must be freed with `thymos_memory_cursor_free`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_memory_cursor(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut ThymosMemoryCursor {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let store = local_store(&agent)?;
+        let snapshot = store
+            .create_snapshot(None, None)
+            .await
+            .map_err(|e| CoreError::Memory(format!("Failed to create snapshot: {}", e)))?;
+        Ok::<_, CoreError>(snapshot.version_map.keys().cloned().collect::<Vec<_>>())
+    }) {
+        Ok(ids) => Box::into_raw(Box::new(ThymosMemoryCursor { ids, next: 0 })),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Read the next page of up to `limit` memories from a cursor.
+///
+/// Memories forgotten or expired since the cursor was opened are skipped.
+/// Pages are full until the cursor runs out; an empty page means it has.
+/// Reading does not count as an access.
+///
+/// # Safety
+/// `handle` must be the valid ThymosAgent handle the cursor was opened on.
+/// `cursor` must be a valid cursor, not used by another call at the same time.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_memory_cursor_next(
+    handle: *const ThymosAgent,
+    cursor: *mut ThymosMemoryCursor,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if cursor.is_null() {
+        set_error("Cursor is null");
+        return ptr::null_mut();
+    }
+
+    if limit == 0 {
+        set_error("Invalid limit: must be positive");
+        return ptr::null_mut();
+    }
+
+    let cursor = &mut *cursor;
+    let ids = std::mem::take(&mut cursor.ids);
+    let mut next = cursor.next;
+    let agent = (*handle).inner.clone();
+    let (ids, next, result) = block_on_value(async move {
+        let mut memories = Vec::with_capacity(limit.min(ids.len() - next));
+        let result = async {
+            let store = local_store(&agent)?;
+            let now = chrono::Utc::now();
+            while memories.len() < limit && next < ids.len() {
+                let memory = store
+                    .manager()
+                    .get_memory(&ids[next])
+                    .await
+                    .map_err(|e| CoreError::Memory(e.to_string()))?;
+                next += 1;
+                memories.extend(memory.filter(|m| !memory_expired(m, now)));
+            }
+            Ok::<_, CoreError>(())
+        }
+        .await;
+        (ids, next, result.map(|()| memories))
+    });
+    cursor.ids = ids;
+    cursor.next = next;
+
+    match result {
+        Ok(memories) => (*handle).checked_results(&memories, &[]),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Free a memory cursor.
+///
+/// # Safety
+/// `cursor` must be a valid cursor or null, and not used afterwards.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_memory_cursor_free(cursor: *mut ThymosMemoryCursor) {
+    if !cursor.is_null() {
+        drop(Box::from_raw(cursor));
+    }
+}
+
+// ============================================================================
+// Conversations
+// ============================================================================
+
+/// Property holding the conversation a memory belongs to.
+const CONVERSATION_ID_PROPERTY: &str = "conversation_id";
+
+/// Property holding a memory's turn number within its conversation, from 1.
+const CONVERSATION_TURN_PROPERTY: &str = "conversation_turn";
+
+/// Disambiguates conversation IDs generated within the same nanosecond.
+static CONVERSATION_SEQ: std::sync::atomic::AtomicU64 = std::sync::atomic::AtomicU64::new(0);
+
+/// The conversation ID and turn stored on a memory, if it belongs to one.
+fn conversation_turn(memory: &locai::models::Memory) -> Option<(&str, u64)> {
+    let id = memory.properties.get(CONVERSATION_ID_PROPERTY)?.as_str()?;
+    let turn = memory
+        .properties
+        .get(CONVERSATION_TURN_PROPERTY)
+        .and_then(|t| t.as_u64())
+        .unwrap_or(0);
+    Some((id, turn))
+}
+
+/// Start a new conversation.
+///
+/// Conversation IDs are opaque and unique per agent; they only group the
+/// memories stored with `thymos_agent_remember_in_conversation`.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_start_conversation(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let seed = format!(
+        "{}:{}:{}",
+        (*handle).inner.id(),
+        chrono::Utc::now().timestamp_nanos_opt().unwrap_or_default(),
+        CONVERSATION_SEQ.fetch_add(1, std::sync::atomic::Ordering::Relaxed)
+    );
+    let digest = format!("{:x}", Sha256::digest(seed.as_bytes()));
+    let id = format!("conv-{}", &digest[..32]);
+
+    (*handle)
+        .conversation_turns
+        .lock()
+        .unwrap()
+        .insert(id.clone(), 0);
+    string_to_cstring(id)
+}
+
+/// Store a conversation memory as the next turn of a conversation.
+///
+/// The memory is stored like `thymos_agent_remember_conversation`, with its
+/// conversation ID and turn number as properties. Conversations not started
+/// by this handle (for example, from an earlier process) continue after the
+/// highest turn already stored. Memories go to the local store (the private
+/// backend in hybrid mode).
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `conversation_id` and `content` must be valid null-terminated UTF-8 strings.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_in_conversation(
+    handle: *const ThymosAgent,
+    conversation_id: *const c_char,
+    content: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    let Some(conversation_id) = cstr_to_string(conversation_id).filter(|id| !id.is_empty()) else {
+        set_error("Invalid conversation_id: empty or not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    // Held across the store so concurrent turns are numbered in order.
+    let mut turns = (*handle).conversation_turns.lock().unwrap();
+    let known = turns.get(&conversation_id).copied();
+    let agent = (*handle).inner.clone();
+    let id = conversation_id.clone();
+    let result = block_on(async move {
+        let last = match known {
+            Some(turn) => turn,
+            None => load_all_memories(&agent)
+                .await?
+                .iter()
+                .filter_map(conversation_turn)
+                .filter(|(conv, _)| *conv == id)
+                .map(|(_, turn)| turn)
+                .max()
+                .unwrap_or(0),
+        };
+        let turn = last + 1;
+
+        let properties = serde_json::json!({
+            CONVERSATION_ID_PROPERTY: id,
+            CONVERSATION_TURN_PROPERTY: turn,
+        });
+        let memory_id = store_embedded(
+            &agent,
+            content_str,
+            locai::models::MemoryType::Conversation,
+            properties,
+        )
+        .await?;
+        Ok((memory_id, turn))
+    });
+
+    match result {
+        Ok((memory_id, turn)) => {
+            turns.insert(conversation_id, turn);
+            drop(turns);
+            (*handle).invalidate_query_cache();
+            (*handle).enforce_retention(Some(1));
+            string_to_cstring(memory_id)
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Get the memories of a conversation in turn order.
+///
+/// Unknown conversations have no history and return empty results.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `conversation_id` must be a valid null-terminated UTF-8 string.
+/// The returned results must be freed with `thymos_free_search_results`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_conversation_history(
+    handle: *const ThymosAgent,
+    conversation_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(conversation_id) = cstr_to_string(conversation_id) else {
+        set_error("Invalid conversation_id: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    match block_on(async move {
+        let mut history: Vec<_> = load_all_memories(&agent)
+            .await?
+            .into_iter()
+            .filter(|m| conversation_turn(m).is_some_and(|(id, _)| id == conversation_id))
+            .collect();
+        history.sort_by_key(|m| (conversation_turn(m).map(|(_, turn)| turn), m.created_at));
+        Ok(history)
+    }) {
+        Ok(history) => (*handle).results(&history),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+// ============================================================================
+// Agent Stats
+// ============================================================================
+
+/// Total size in bytes of the files under a directory.
+fn dir_size(path: &std::path::Path) -> std::io::Result<u64> {
+    let mut size = 0;
+    for entry in std::fs::read_dir(path)? {
+        let entry = entry?;
+        let metadata = entry.metadata()?;
+        size += if metadata.is_dir() {
+            dir_size(&entry.path())?
+        } else {
+            metadata.len()
+        };
+    }
+    Ok(size)
+}
+
+/// Get storage and lifecycle metrics for the agent as JSON.
+///
+/// The object holds `total_memories`, `counts_by_type` (memory type code to
+/// count, only for types present), `average_strength` (0 with no memories),
+/// `oldest` and `newest` creation times as RFC 3339 strings (null with no
+/// memories), and `disk_bytes`, the size of the local data directory (0 in
+/// server mode). Every memory is loaded to compute these.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_stats(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let agent = (*handle).inner.clone();
+    let memories = match block_on(async move { load_all_memories(&agent).await }) {
+        Ok(memories) => memories,
+        Err(e) => {
+            set_thymos_error(&e);
+            return ptr::null_mut();
+        }
+    };
+
+    let disk_bytes = match &(*handle).data_dir {
+        Some(dir) if dir.exists() => match dir_size(dir) {
+            Ok(size) => size,
+            Err(e) => {
+                set_thymos_error(&e.into());
+                return ptr::null_mut();
+            }
+        },
+        _ => 0,
+    };
+
+    let mut counts_by_type: BTreeMap<c_int, usize> = BTreeMap::new();
+    let mut total_strength = 0.0;
+    for memory in &memories {
+        *counts_by_type
+            .entry(memory_type_code(&memory.memory_type))
+            .or_insert(0) += 1;
+        total_strength += (*handle).inner.memory().calculate_strength(memory);
+    }
+    let average_strength = if memories.is_empty() {
+        0.0
+    } else {
+        total_strength / memories.len() as f64
+    };
+
+    let stats = serde_json::json!({
+        "total_memories": memories.len(),
+        "counts_by_type": counts_by_type,
+        "average_strength": average_strength,
+        "oldest": memories.iter().map(|m| m.created_at).min().map(|t| t.to_rfc3339()),
+        "newest": memories.iter().map(|m| m.created_at).max().map(|t| t.to_rfc3339()),
+        "disk_bytes": disk_bytes,
+    });
+    string_to_cstring(stats.to_string())
+}
+
+// ============================================================================
+// Memory Expiry
+// ============================================================================
+
+/// Property holding a memory's expiry time as RFC 3339.
+const EXPIRES_AT_PROPERTY: &str = "expires_at";
+
+/// How often expired memories are deleted.
+const EXPIRY_SWEEP_INTERVAL: Duration = Duration::from_secs(1);
+
+/// The time a memory expires, if it has a TTL.
+fn memory_expiry(memory: &locai::models::Memory) -> Option<chrono::DateTime<chrono::Utc>> {
+    let value = memory.properties.get(EXPIRES_AT_PROPERTY)?.as_str()?;
+    chrono::DateTime::parse_from_rfc3339(value)
+        .ok()
+        .map(|t| t.with_timezone(&chrono::Utc))
+}
+
+/// Whether a memory's TTL has passed as of `now`.
+fn memory_expired(memory: &locai::models::Memory, now: chrono::DateTime<chrono::Utc>) -> bool {
+    memory_expiry(memory).is_some_and(|at| at <= now)
+}
+
+/// Expiry time for a TTL in milliseconds from now.
+fn expiry_after(ttl_ms: u64) -> Option<chrono::DateTime<chrono::Utc>> {
+    let ttl = chrono::Duration::try_milliseconds(i64::try_from(ttl_ms).ok()?)?;
+    chrono::Utc::now().checked_add_signed(ttl)
+}
+
+/// Start a task that hard-deletes memories once their TTL passes.
+///
+/// The task first loads expiry times persisted by earlier processes, then
+/// checks `expiries` every `EXPIRY_SWEEP_INTERVAL`. It is started by
+/// `ThymosAgent::ensure_expiry_sweeper`. Expired memories are
+/// deleted outright, skipping the forget grace period. Until the sweep
+/// reaches them they are filtered out of results.
+fn start_expiry_sweeper(
+    agent: Agent,
+    expiries: Arc<Mutex<HashMap<String, chrono::DateTime<chrono::Utc>>>>,
+    access_counts: Arc<Mutex<HashMap<String, u64>>>,
+) -> tokio::task::JoinHandle<()> {
+    let handle = RUNTIME.lock().unwrap().handle().clone();
+    handle.spawn(async move {
+        if let Ok(memories) = load_all_memories(&agent).await {
+            let mut expiries = expiries.lock().unwrap();
+            for memory in &memories {
+                if let Some(at) = memory_expiry(memory) {
+                    expiries.entry(memory.id.clone()).or_insert(at);
+                }
+            }
+        }
+
+        loop {
+            let now = chrono::Utc::now();
+            let due: Vec<String> = expiries
+                .lock()
+                .unwrap()
+                .iter()
+                .filter(|(_, at)| **at <= now)
+                .map(|(id, _)| id.clone())
+                .collect();
+            for id in due {
+                // Ok(false) means the memory is already gone
+                if agent.forget(&id).await.is_ok() {
+                    expiries.lock().unwrap().remove(&id);
+                    access_counts.lock().unwrap().remove(&id);
+                }
+            }
+            tokio::time::sleep(EXPIRY_SWEEP_INTERVAL).await;
+        }
+    })
+}
+
+/// Store a memory that is deleted once `ttl_ms` milliseconds have passed.
+///
+/// The memory is stored like `thymos_agent_remember`, with its expiry time in
+/// the `expires_at` property. It is hard-deleted shortly after it expires,
+/// regardless of its strength, and never appears in results once expired.
+/// Memories go to the local store (the private backend in hybrid mode).
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `content` must be a valid null-terminated UTF-8 string.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_with_ttl(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    ttl_ms: u64,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    thymos_agent_remember_with_ttl_cancelable(handle, content, ttl_ms, ptr::null(), out_error)
+}
+
+/// Like `thymos_agent_remember_with_ttl`, abandoning the write if `token` is
+/// canceled.
+///
+/// See `block_on_cancelable` for what a canceled write leaves behind.
+///
+/// # Safety
+/// Same as `thymos_agent_remember_with_ttl`; `token` must be a valid token or
+/// null (not cancelable).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_with_ttl_cancelable(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    ttl_ms: u64,
+    token: *const ThymosCancelToken,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    if ttl_ms == 0 {
+        set_error("Invalid ttl_ms: must be positive");
+        return ptr::null_mut();
+    }
+    let Some(expires_at) = expiry_after(ttl_ms) else {
+        set_error(format!("Invalid ttl_ms: {} is too large", ttl_ms));
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let properties = serde_json::json!({ EXPIRES_AT_PROPERTY: expires_at.to_rfc3339() });
+    match block_on_cancelable(token, async move {
+        store_embedded(
+            &agent,
+            content_str,
+            locai::models::MemoryType::Episodic,
+            properties,
+        )
+        .await
+    }) {
+        Ok(Some(id)) => {
+            let handle = &*handle;
+            handle
+                .expiries
+                .lock()
+                .unwrap()
+                .insert(id.clone(), expires_at);
+            handle.ensure_expiry_sweeper();
+            handle.invalidate_query_cache();
+            handle.enforce_retention(Some(1));
+            string_to_cstring(id)
+        }
+        Ok(None) => {
+            set_error_with_code(ERROR_CANCELED, CANCELED);
+            ptr::null_mut()
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
+}
+
+/// Set or clear the TTL of an existing memory.
+///
+/// The memory expires `ttl_ms` milliseconds from now and is then deleted as
+/// described for `thymos_agent_remember_with_ttl`. A `ttl_ms` of 0 clears the
+/// TTL. Fails with "memory not found" if no memory has the ID.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+///
+/// Returns 0 on success, -1 on error.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_set_memory_ttl(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    ttl_ms: u64,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return -1;
+    }
+
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return -1;
+    };
+
+    let expires_at = if ttl_ms == 0 {
+        None
+    } else {
+        match expiry_after(ttl_ms) {
+            Some(at) => Some(at),
+            None => {
+                set_error(format!("Invalid ttl_ms: {} is too large", ttl_ms));
+                return -1;
+            }
+        }
+    };
+
+    let agent = (*handle).inner.clone();
+    let memory_id = id.clone();
+    let locks = (*handle).memory_locks.clone();
+    let result = block_on(async move {
+        let _guard = locks.lock(&memory_id).await;
+        let store = local_store(&agent)?;
+        let Some(mut memory) = store
+            .manager()
+            .get_memory(&memory_id)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?
+        else {
+            return Ok(false);
+        };
+        if memory_expired(&memory, chrono::Utc::now()) {
+            return Ok(false);
+        }
+
+        if !memory.properties.is_object() {
+            memory.properties = serde_json::json!({});
+        }
+        let properties = memory.properties.as_object_mut().unwrap();
+        match expires_at {
+            Some(at) => {
+                properties.insert(EXPIRES_AT_PROPERTY.to_string(), at.to_rfc3339().into());
+            }
+            None => {
+                properties.remove(EXPIRES_AT_PROPERTY);
+            }
+        }
+        stamp_modified(&mut memory, chrono::Utc::now());
+        store
+            .manager()
+            .update_memory(memory)
+            .await
+            .map_err(|e| CoreError::Memory(e.to_string()))?;
+        Ok(true)
+    });
+
+    match result {
+        Ok(true) => {
+            let handle = &*handle;
+            let mut expiries = handle.expiries.lock().unwrap();
+            match expires_at {
+                Some(at) => expiries.insert(id.clone(), at),
+                None => expiries.remove(&id),
+            };
+            drop(expiries);
+            if expires_at.is_some() {
+                handle.ensure_expiry_sweeper();
+            }
+            handle.changed_ids.lock().unwrap().insert(id);
+            handle.invalidate_query_cache();
+            0
+        }
+        Ok(false) => {
+            set_error_with_code(ERROR_NOT_FOUND, "memory not found");
+            -1
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            -1
+        }
+    }
+}
+
+// ============================================================================
+// Tags
+// ============================================================================
+
+/// Read an array of tags, rejecting empty ones.
+unsafe fn read_tags(tags: *const *const c_char, count: usize) -> Option<Vec<String>> {
+    cstr_array_to_vec(tags, count).filter(|tags| tags.iter().all(|t| !t.is_empty()))
+}
+
+/// Store a memory with tags.
+///
+/// The memory is stored like `thymos_agent_remember`, with `tags` in its
+/// first-class tag list rather than its properties, in every memory mode.
+/// Tags are matched exactly, so "project:alpha" and "Project:Alpha" are
+/// different tags.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// `content` must be a valid null-terminated UTF-8 string.
+/// `tags` must point to `count` valid null-terminated UTF-8 strings.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_with_tags(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    tags: *const *const c_char,
+    count: usize,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    thymos_agent_remember_with_tags_cancelable(handle, content, tags, count, ptr::null(), out_error)
+}
+
+/// Like `thymos_agent_remember_with_tags`, abandoning the write if `token` is
+/// canceled.
+///
+/// See `block_on_cancelable` for what a canceled write leaves behind.
+///
+/// # Safety
+/// Same as `thymos_agent_remember_with_tags`; `token` must be a valid token or
+/// null (not cancelable).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_remember_with_tags_cancelable(
+    handle: *const ThymosAgent,
+    content: *const c_char,
+    tags: *const *const c_char,
+    count: usize,
+    token: *const ThymosCancelToken,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
         return ptr::null_mut();
-    };
+    }
 
-    if config.is_null() {
-        set_error("Memory config is null");
+    if !check_writable(&*handle) {
         return ptr::null_mut();
     }
 
-    let memory_config = (*config).inner.clone();
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
+        return ptr::null_mut();
+    };
 
-    match block_on(async move {
-        Agent::builder()
-            .id(id)
-            .with_memory_config(memory_config)
-            .build()
-            .await
+    let Some(tags) = read_tags(tags, count) else {
+        set_error("Invalid tags: null, empty or not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let options = RememberOptions::new().with_tags(tags.clone());
+    match block_on_cancelable(token, async move {
+        agent.remember_with_options(content_str, options).await
     }) {
-        Ok(agent) => Box::into_raw(Box::new(ThymosAgent { inner: agent })),
+        Ok(Some(id)) => {
+            if let Some(index) = (*handle).tag_index.lock().unwrap().as_mut() {
+                for tag in tags {
+                    index.entry(tag).or_default().insert(id.clone());
+                }
+            }
+            (*handle).invalidate_query_cache();
+            (*handle).enforce_retention(Some(1));
+            string_to_cstring(id)
+        }
+        Ok(None) => {
+            set_error_with_code(ERROR_CANCELED, CANCELED);
+            ptr::null_mut()
+        }
         Err(e) => {
-            set_error(e.to_string());
+            set_thymos_error(&e);
             ptr::null_mut()
         }
     }
 }
 
-/// Create a new agent with full Thymos configuration.
+/// Load the tag index from every memory in the local store.
+async fn load_tag_index(agent: &Agent) -> Result<HashMap<String, HashSet<String>>> {
+    let mut index: HashMap<String, HashSet<String>> = HashMap::new();
+    for memory in load_all_memories(agent).await? {
+        for tag in memory.tags {
+            index.entry(tag).or_default().insert(memory.id.clone());
+        }
+    }
+    Ok(index)
+}
+
+/// Find memories by tag.
+///
+/// With `match_all`, a memory must carry every tag in `tags`; otherwise any
+/// one of them is enough. Results are newest first and count as accesses.
+/// An empty `tags` matches nothing.
+///
+/// Only the local store is searched (the private backend in hybrid mode);
+/// server mode fails with ERROR_CONFIGURATION. Matches are found through an
+/// index of tags to memory IDs that is loaded by the first call and kept up
+/// to date by later writes, so only matching memories are read.
 ///
 /// # Safety
-/// `agent_id` must be a valid null-terminated UTF-8 string.
-/// `config` must be a valid ThymosConfigHandle.
+/// `handle` must be a valid ThymosAgent handle.
+/// `tags` must point to `count` valid null-terminated UTF-8 strings.
+/// The returned results must be freed with `thymos_free_search_results`.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_new_with_config(
-    agent_id: *const c_char,
-    config: *const ThymosConfigHandle,
-) -> *mut ThymosAgent {
-    let Some(id) = cstr_to_string(agent_id) else {
-        set_error("Invalid agent_id: not valid UTF-8");
+pub unsafe extern "C" fn thymos_agent_search_by_tags(
+    handle: *const ThymosAgent,
+    tags: *const *const c_char,
+    count: usize,
+    match_all: bool,
+    limit: usize,
+    out_error: *mut ThymosError,
+) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(wanted) = read_tags(tags, count) else {
+        set_error("Invalid tags: null, empty or not valid UTF-8");
         return ptr::null_mut();
     };
 
-    if config.is_null() {
-        set_error("Config is null");
+    let handle = &*handle;
+    if handle.data_dir.is_none() {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            "Tag search requires local storage (embedded or hybrid mode)",
+        );
         return ptr::null_mut();
     }
+    if wanted.is_empty() {
+        return handle.results(&[]);
+    }
 
-    let thymos_config = (*config).inner.clone();
+    // Candidate IDs from the index, loading it first if needed
+    let candidates: Vec<String> = {
+        let mut index = handle.tag_index.lock().unwrap();
+        if index.is_none() {
+            let agent = handle.inner.clone();
+            match block_on(async move { load_tag_index(&agent).await }) {
+                Ok(loaded) => *index = Some(loaded),
+                Err(e) => {
+                    set_thymos_error(&e);
+                    return ptr::null_mut();
+                }
+            }
+        }
+        let index = index.as_ref().unwrap();
+        let empty = HashSet::new();
+        let sets: Vec<&HashSet<String>> = wanted
+            .iter()
+            .map(|tag| index.get(tag).unwrap_or(&empty))
+            .collect();
+        let mut ids: HashSet<&String> = sets[0].iter().collect();
+        for set in &sets[1..] {
+            if match_all {
+                ids.retain(|id| set.contains(*id));
+            } else {
+                ids.extend(set.iter());
+            }
+        }
+        ids.into_iter().cloned().collect()
+    };
 
+    let agent = handle.inner.clone();
+    let query_tags = wanted.clone();
+    let matches = move |memory: &locai::models::Memory| {
+        let has = |tag: &String| memory.tags.contains(tag);
+        if match_all {
+            query_tags.iter().all(has)
+        } else {
+            query_tags.iter().any(has)
+        }
+    };
     match block_on(async move {
-        Agent::builder().id(id).config(thymos_config).build().await
+        let store = local_store(&agent)?;
+        let mut memories = Vec::with_capacity(candidates.len());
+        let mut stale = Vec::new();
+        for id in candidates {
+            let memory = store
+                .manager()
+                .get_memory(&id)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+            match memory {
+                Some(memory) if matches(&memory) => memories.push(memory),
+                Some(memory) => stale.push((id, memory.tags)),
+                None => stale.push((id, Vec::new())),
+            }
+        }
+        memories.sort_by(|a, b| b.created_at.cmp(&a.created_at).then(a.id.cmp(&b.id)));
+        if limit > 0 {
+            memories.truncate(limit);
+        }
+        Ok((memories, stale))
     }) {
-        Ok(agent) => Box::into_raw(Box::new(ThymosAgent { inner: agent })),
+        Ok((memories, stale)) => {
+            // Drop index entries for memories deleted or retagged since
+            if let Some(index) = handle.tag_index.lock().unwrap().as_mut() {
+                for (id, tags) in stale {
+                    for tag in wanted.iter().filter(|tag| !tags.contains(tag)) {
+                        if let Some(ids) = index.get_mut(tag) {
+                            ids.remove(&id);
+                        }
+                    }
+                }
+            }
+            handle.record_access(&memories);
+            handle.results(&memories)
+        }
         Err(e) => {
-            set_error(e.to_string());
+            set_thymos_error(&e);
             ptr::null_mut()
         }
     }
 }
 
 // ============================================================================
-// Agent Properties
+// Summarization
 // ============================================================================
 
-/// Get the agent ID.
+/// Error message when the agent has no LLM provider to summarize with.
+const SUMMARIZATION_UNAVAILABLE: &str = "summarization not available: no LLM provider configured";
+
+/// Summarize the memories that best match a query.
+///
+/// Gathers up to `max_memories` top matches (0 means no limit), skipping
+/// expired ones, and has thymos-core's consolidation engine summarize them with
+/// the agent's LLM provider, in the voice of the agent's persona if it has one.
+/// With `store`, the summary is also remembered as a fact whose properties
+/// record the query ("summary_of") and the summarized IDs ("source_ids").
+/// No matches gives an empty summary without calling the LLM or storing
+/// anything.
+///
+/// Returns JSON: {"summary": "...", "memory_id": "..." or null}. Fails with
+/// ERROR_NO_LLM if the agent has no LLM provider.
 ///
 /// # Safety
 /// `handle` must be a valid ThymosAgent handle.
+/// `query` must be a valid null-terminated UTF-8 string.
 /// The returned string must be freed with `thymos_free_string`.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_id(handle: *const ThymosAgent) -> *mut c_char {
+pub unsafe extern "C" fn thymos_agent_summarize(
+    handle: *const ThymosAgent,
+    query: *const c_char,
+    max_memories: usize,
+    store: bool,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
     if handle.is_null() {
         set_error("Agent handle is null");
         return ptr::null_mut();
     }
 
-    string_to_cstring((*handle).inner.id().to_string())
-}
-
-/// Get the agent description.
-///
-/// # Safety
-/// `handle` must be a valid ThymosAgent handle.
-/// The returned string must be freed with `thymos_free_string`.
-#[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_description(handle: *const ThymosAgent) -> *mut c_char {
-    if handle.is_null() {
-        set_error("Agent handle is null");
+    if store && !check_writable(&*handle) {
         return ptr::null_mut();
     }
 
-    string_to_cstring((*handle).inner.description().to_string())
+    let Some(query_str) = cstr_to_string(query) else {
+        set_error("Invalid query: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = (*handle).inner.clone();
+    let Some(provider) = agent.llm_provider().cloned() else {
+        set_error_with_code(ERROR_NO_LLM, SUMMARIZATION_UNAVAILABLE);
+        return ptr::null_mut();
+    };
+
+    match block_on(async move {
+        let now = chrono::Utc::now();
+        let mut memories: Vec<_> = agent
+            .search_memories(&query_str)
+            .await?
+            .into_iter()
+            .filter(|m| !memory_expired(m, now))
+            .collect();
+        if max_memories > 0 {
+            memories.truncate(max_memories);
+        }
+        if memories.is_empty() {
+            return Ok((memories, String::new(), None));
+        }
+
+        let engine =
+            ConsolidationEngine::new(agent.memory_arc(), provider, ConsolidationConfig::default());
+        let persona = agent.persona().await;
+        let summary = engine
+            .summarize(&query_str, &memories, persona.as_deref())
+            .await?;
+
+        let memory_id = if store {
+            let source_ids: Vec<&str> = memories.iter().map(|m| m.id.as_str()).collect();
+            let properties = serde_json::json!({
+                "summary_of": query_str,
+                "source_ids": source_ids,
+            });
+            Some(
+                store_embedded(
+                    &agent,
+                    summary.clone(),
+                    locai::models::MemoryType::Fact,
+                    properties,
+                )
+                .await?,
+            )
+        } else {
+            None
+        };
+        Ok((memories, summary, memory_id))
+    }) {
+        Ok((memories, summary, memory_id)) => {
+            (*handle).record_access(&memories);
+            if memory_id.is_some() {
+                (*handle).invalidate_query_cache();
+                (*handle).enforce_retention(Some(1));
+            }
+            let result = serde_json::json!({
+                "summary": summary,
+                "memory_id": memory_id,
+            });
+            string_to_cstring(result.to_string())
+        }
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
 }
 
 // ============================================================================
-// Agent Status
+// Embeddings
 // ============================================================================
 
-/// Get agent status as a string.
-///
-/// Returns one of: "Active", "Listening", "Dormant", "Archived"
-///
-/// # Safety
-/// `handle` must be a valid ThymosAgent handle.
-/// The returned string must be freed with `thymos_free_string`.
-#[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_status(handle: *const ThymosAgent) -> *mut c_char {
-    if handle.is_null() {
-        set_error("Agent handle is null");
-        return ptr::null_mut();
-    }
-
-    let agent = (*handle).inner.clone();
-    let status = block_on_value(async move { agent.status().await });
-    string_to_cstring(format!("{:?}", status))
+/// Move an embedding into a buffer for `thymos_free_floats`.
+unsafe fn embedding_to_buffer(embedding: Vec<f32>, out_len: *mut usize) -> *mut f32 {
+    let embedding = embedding.into_boxed_slice();
+    *out_len = embedding.len();
+    Box::into_raw(embedding) as *mut f32
 }
 
-/// Set agent status.
+/// Get the embedding stored with a memory.
 ///
-/// Valid statuses: "active", "listening", "dormant", "archived" (case-insensitive)
+/// The vector has the embedding model's dimension. Returns null with no error
+/// set if the memory was stored without an embedding (see
+/// `thymos_agent_unembedded`), and fails with ERROR_NOT_FOUND if there is no
+/// such memory. Does not count as an access.
 ///
 /// # Safety
 /// `handle` must be a valid ThymosAgent handle.
-/// `status` must be a valid null-terminated UTF-8 string.
-///
-/// Returns 0 on success, -1 on error.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
+/// `out_len` must be a valid pointer.
+/// The returned buffer must be freed with `thymos_free_floats(data, *out_len)`.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_set_status(
+pub unsafe extern "C" fn thymos_agent_get_embedding(
     handle: *const ThymosAgent,
-    status: *const c_char,
-) -> c_int {
+    memory_id: *const c_char,
+    out_len: *mut usize,
+    out_error: *mut ThymosError,
+) -> *mut f32 {
+    let _error_out = ErrorOut::new(out_error);
     if handle.is_null() {
         set_error("Agent handle is null");
-        return -1;
+        return ptr::null_mut();
     }
 
-    let Some(status_str) = cstr_to_string(status) else {
-        set_error("Invalid status: not valid UTF-8");
-        return -1;
-    };
+    if out_len.is_null() {
+        set_error("out_len is null");
+        return ptr::null_mut();
+    }
 
-    let agent_status = match status_str.to_lowercase().as_str() {
-        "active" => AgentStatus::Active,
-        "listening" => AgentStatus::Listening,
-        "dormant" => AgentStatus::Dormant,
-        "archived" => AgentStatus::Archived,
-        _ => {
-            set_error(format!(
-                "Invalid status: {}. Valid values: active, listening, dormant, archived",
-                status_str
-            ));
-            return -1;
-        }
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return ptr::null_mut();
     };
 
     let agent = (*handle).inner.clone();
-    match block_on(async move { agent.set_status(agent_status).await }) {
-        Ok(_) => 0,
+    match block_on(async move { agent.get_memory(&id).await }) {
+        Ok(Some(memory)) if !memory_expired(&memory, chrono::Utc::now()) => {
+            match memory.embedding {
+                Some(embedding) => embedding_to_buffer(embedding, out_len),
+                None => ptr::null_mut(),
+            }
+        }
+        Ok(_) => {
+            set_error_with_code(ERROR_NOT_FOUND, "memory not found");
+            ptr::null_mut()
+        }
         Err(e) => {
-            set_error(e.to_string());
-            -1
+            set_thymos_error(&e);
+            ptr::null_mut()
         }
     }
 }
 
-/// Get full agent state.
+/// Embed text with the agent's embedding provider without storing it.
+///
+/// The vector has the embedding model's dimension and matches what a memory
+/// with the same content would be stored with. Fails with
+/// ERROR_CONFIGURATION if the agent has no embedding provider.
 ///
 /// # Safety
 /// `handle` must be a valid ThymosAgent handle.
-/// The returned state must be freed with `thymos_free_agent_state`.
+/// `text` must be a valid null-terminated UTF-8 string.
+/// `out_len` must be a valid pointer.
+/// The returned buffer must be freed with `thymos_free_floats(data, *out_len)`.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_state(handle: *const ThymosAgent) -> *mut ThymosAgentState {
+pub unsafe extern "C" fn thymos_agent_embed_text(
+    handle: *const ThymosAgent,
+    text: *const c_char,
+    out_len: *mut usize,
+    out_error: *mut ThymosError,
+) -> *mut f32 {
+    let _error_out = ErrorOut::new(out_error);
     if handle.is_null() {
         set_error("Agent handle is null");
         return ptr::null_mut();
     }
 
-    let agent = (*handle).inner.clone();
-    let state = block_on_value(async move { agent.state().await });
+    if out_len.is_null() {
+        set_error("out_len is null");
+        return ptr::null_mut();
+    }
 
-    Box::into_raw(Box::new(ThymosAgentState::from_state(&state)))
+    let Some(text_str) = cstr_to_string(text) else {
+        set_error("Invalid text: not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let agent = &(*handle).inner;
+    let Some(provider) = agent.embedding_provider().cloned() else {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            format!(
+                "Agent '{}' has no embedding provider configured",
+                agent.id()
+            ),
+        );
+        return ptr::null_mut();
+    };
+
+    match block_on(async move { provider.embed(&text_str).await }) {
+        Ok(embedding) => embedding_to_buffer(embedding, out_len),
+        Err(e) => {
+            set_thymos_error(&e);
+            ptr::null_mut()
+        }
+    }
 }
 
 // ============================================================================
-// Memory Operations
+// Clearing
 // ============================================================================
 
-/// Store a memory.
+/// Delete every memory in the agent's local store.
 ///
-/// Returns the memory ID on success, or null on error.
+/// The agent keeps its configuration, status, description, persona and saved
+/// queries, and the store stays open for new memories. Cleared memories
+/// cannot be restored with `thymos_agent_restore_memory`, and this handle's
+/// records of them (forgotten memories, access counts and timelines, TTLs,
+/// conversation turns and the concept index) are reset. If a deletion fails,
+/// the memories already deleted are stored again under their IDs and the
+/// error is reported. This is best-effort: if storing some of them again also
+/// fails, they are lost, and the error reports how many.
 ///
 /// # Safety
 /// `handle` must be a valid ThymosAgent handle.
-/// `content` must be a valid null-terminated UTF-8 string.
-/// The returned string must be freed with `thymos_free_string`.
+///
+/// Returns the number of memories deleted, or -1 on error.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_remember(
+pub unsafe extern "C" fn thymos_agent_clear(
     handle: *const ThymosAgent,
-    content: *const c_char,
-) -> *mut c_char {
+    out_error: *mut ThymosError,
+) -> i64 {
+    let _error_out = ErrorOut::new(out_error);
     if handle.is_null() {
         set_error("Agent handle is null");
-        return ptr::null_mut();
+        return -1;
     }
 
-    let Some(content_str) = cstr_to_string(content) else {
-        set_error("Invalid content: not valid UTF-8");
-        return ptr::null_mut();
-    };
+    if !check_writable(&*handle) {
+        return -1;
+    }
 
     let agent = (*handle).inner.clone();
-    match block_on(async move { agent.remember(content_str).await }) {
-        Ok(id) => string_to_cstring(id),
+    let result = block_on(async move {
+        let store = local_store(&agent)?;
+        let memories = load_all_memories(&agent).await?;
+        let mut deleted = Vec::with_capacity(memories.len());
+        for memory in memories {
+            match store.manager().delete_memory(&memory.id).await {
+                Ok(true) => deleted.push(memory),
+                Ok(false) => {}
+                Err(e) => {
+                    let total = deleted.len();
+                    let mut lost = 0;
+                    for memory in deleted {
+                        if store.manager().store_memory(memory).await.is_err() {
+                            lost += 1;
+                        }
+                    }
+                    if lost > 0 {
+                        return Err(CoreError::Memory(format!(
+                            "{e}; {lost} of {total} deleted memories could not be stored again"
+                        )));
+                    }
+                    return Err(CoreError::Memory(e.to_string()));
+                }
+            }
+        }
+        Ok(deleted.len() as i64)
+    });
+
+    let handle = &*handle;
+    handle.invalidate_query_cache();
+    handle.invalidate_tag_index();
+    match result {
+        Ok(count) => {
+            handle.forgotten.lock().unwrap().clear();
+            handle.access_counts.lock().unwrap().clear();
+            if let Some(timelines) = &handle.access_timelines {
+                timelines.lock().unwrap().clear();
+            }
+            handle.expiries.lock().unwrap().clear();
+            handle.conversation_turns.lock().unwrap().clear();
+            *handle.idempotency_keys.lock().unwrap() = None;
+            handle.concept_index.lock().unwrap().clear();
+            count
+        }
         Err(e) => {
-            set_error(e.to_string());
-            ptr::null_mut()
+            set_thymos_error(&e);
+            -1
         }
     }
 }
 
-/// Store a fact memory (durable, context-independent knowledge).
-///
-/// Facts are intended for knowledge like "Paris is the capital of France".
-///
-/// # Safety
-/// Same as `thymos_agent_remember`.
-#[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_remember_fact(
-    handle: *const ThymosAgent,
-    content: *const c_char,
-) -> *mut c_char {
-    if handle.is_null() {
-        set_error("Agent handle is null");
-        return ptr::null_mut();
+// ============================================================================
+// Logging
+// ============================================================================
+
+/// Receives native log records: `level` is 0 = error, 1 = warn, 2 = info,
+/// 3 = debug, 4 = trace, and `message` is only valid during the call.
+type LogCallback = extern "C" fn(level: c_int, message: *const c_char);
+
+/// Callback that log records are forwarded to, if any.
+static LOG_CALLBACK: Mutex<Option<LogCallback>> = Mutex::new(None);
+
+/// Outcome of installing the forwarding subscriber, which happens once.
+static LOG_SUBSCRIBER: Lazy<std::result::Result<(), String>> = Lazy::new(|| {
+    use tracing_subscriber::layer::SubscriberExt;
+
+    let filter = tracing_subscriber::EnvFilter::try_from_env("THYMOS_LOG").unwrap_or_else(|_| {
+        tracing_subscriber::EnvFilter::new("warn,thymos_core=debug,thymos_go=debug,locai=info")
+    });
+    let subscriber = tracing_subscriber::registry()
+        .with(filter)
+        .with(CallbackLayer);
+    tracing::subscriber::set_global_default(subscriber).map_err(|e| e.to_string())
+});
+
+/// Tracing layer that forwards every event to `LOG_CALLBACK`.
+struct CallbackLayer;
+
+impl<S: tracing::Subscriber> tracing_subscriber::Layer<S> for CallbackLayer {
+    fn on_event(
+        &self,
+        event: &tracing::Event<'_>,
+        _ctx: tracing_subscriber::layer::Context<'_, S>,
+    ) {
+        // Copy the callback out so a callback that logs can't deadlock
+        let Some(callback) = *LOG_CALLBACK.lock().unwrap() else {
+            return;
+        };
+
+        let metadata = event.metadata();
+        let mut message = format!("{}:", metadata.target());
+        event.record(&mut LogFields(&mut message));
+        let level = match *metadata.level() {
+            tracing::Level::ERROR => 0,
+            tracing::Level::WARN => 1,
+            tracing::Level::INFO => 2,
+            tracing::Level::DEBUG => 3,
+            tracing::Level::TRACE => 4,
+        };
+        if let Ok(message) = CString::new(message) {
+            callback(level, message.as_ptr());
+        }
     }
+}
 
-    let Some(content_str) = cstr_to_string(content) else {
-        set_error("Invalid content: not valid UTF-8");
-        return ptr::null_mut();
-    };
+/// Formats an event's fields as " message key=value ...".
+struct LogFields<'a>(&'a mut String);
 
-    let agent = (*handle).inner.clone();
-    match block_on(async move { agent.remember_fact(content_str).await }) {
-        Ok(id) => string_to_cstring(id),
-        Err(e) => {
-            set_error(e.to_string());
-            ptr::null_mut()
+impl tracing::field::Visit for LogFields<'_> {
+    fn record_debug(&mut self, field: &tracing::field::Field, value: &dyn std::fmt::Debug) {
+        use std::fmt::Write;
+        if field.name() == "message" {
+            let _ = write!(self.0, " {:?}", value);
+        } else {
+            let _ = write!(self.0, " {}={:?}", field.name(), value);
         }
     }
 }
 
-/// Store a conversation memory (dialogue context).
+/// Forward the library's internal logs to a callback.
+///
+/// Records from thymos at debug level and above, and warnings from other
+/// crates, are passed to `callback`; set the `THYMOS_LOG` environment variable
+/// to an `EnvFilter` directive (such as "info" or "locai=debug") to change
+/// that. A null callback stops forwarding. The callback may run on any thread,
+/// including library worker threads, and must not call back into Thymos.
 ///
-/// Conversation memories are intended for dialogue history and ephemeral context.
+/// The first non-null callback installs a global tracing subscriber; fails
+/// with ERROR_CONFIGURATION if the process already has one.
+///
+/// Returns 0 on success, -1 on error.
 ///
 /// # Safety
-/// Same as `thymos_agent_remember`.
+/// `out_error` must be null or point to a writable `ThymosError`.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_remember_conversation(
-    handle: *const ThymosAgent,
-    content: *const c_char,
-) -> *mut c_char {
-    if handle.is_null() {
-        set_error("Agent handle is null");
-        return ptr::null_mut();
+pub unsafe extern "C" fn thymos_set_log_callback(
+    callback: Option<LogCallback>,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    if callback.is_some()
+        && let Err(e) = &*LOG_SUBSCRIBER
+    {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            format!("cannot forward native logs: {}", e),
+        );
+        return -1;
     }
 
-    let Some(content_str) = cstr_to_string(content) else {
-        set_error("Invalid content: not valid UTF-8");
-        return ptr::null_mut();
+    *LOG_CALLBACK.lock().unwrap() = callback;
+    0
+}
+
+// ============================================================================
+// Scope Changes
+// ============================================================================
+
+/// Move a memory to the backend `to` (`SCOPE_PRIVATE` or `SCOPE_SHARED`).
+///
+/// Returns false if neither backend has the memory. A memory already in `to`
+/// is left alone. The memory keeps its ID either way, and promoting carries
+/// its tags, properties (including its expiry) and embedding over. If
+/// removing the original fails, the copy is deleted again.
+async fn move_memory(agent: &Agent, id: &str, to: c_int) -> Result<bool> {
+    let MemorySystem::Hybrid { hybrid, .. } = agent.memory() else {
+        return Err(CoreError::NotHybrid(
+            "scope changes only available in hybrid mode".to_string(),
+        ));
     };
+    let store = hybrid.private_locai();
+    let private = store
+        .manager()
+        .get_memory(id)
+        .await
+        .map_err(|e| CoreError::Memory(e.to_string()))?;
 
-    let agent = (*handle).inner.clone();
-    match block_on(async move { agent.remember_conversation(content_str).await }) {
-        Ok(id) => string_to_cstring(id),
-        Err(e) => {
-            set_error(e.to_string());
-            ptr::null_mut()
+    match (private, to) {
+        (Some(memory), SCOPE_SHARED) => {
+            let shared_id = hybrid.copy_to_shared(&memory).await?;
+            if shared_id != id {
+                let _ = hybrid.delete_shared(&shared_id).await;
+                return Err(CoreError::Memory(format!(
+                    "shared backend stored memory '{}' as '{}'",
+                    id, shared_id
+                )));
+            }
+            if let Err(e) = store.manager().delete_memory(id).await {
+                let _ = hybrid.delete_shared(id).await;
+                return Err(CoreError::Memory(e.to_string()));
+            }
+            Ok(true)
+        }
+        (Some(_), _) => Ok(true),
+        (None, _) => {
+            // Not private, so any memory found is the shared one
+            let Some(memory) = hybrid.get_memory(id).await? else {
+                return Ok(false);
+            };
+            if to == SCOPE_SHARED {
+                return Ok(true);
+            }
+            store
+                .manager()
+                .store_memory(memory)
+                .await
+                .map_err(|e| CoreError::Memory(e.to_string()))?;
+            if let Err(e) = hybrid.delete_shared(id).await {
+                let _ = store.manager().delete_memory(id).await;
+                return Err(e);
+            }
+            Ok(true)
         }
     }
 }
 
-/// Store a memory in the private backend (hybrid mode only).
-///
-/// # Safety
-/// Same as `thymos_agent_remember`.
-#[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_remember_private(
+/// Run `move_memory` for an FFI call.
+unsafe fn move_memory_ffi(
     handle: *const ThymosAgent,
-    content: *const c_char,
-) -> *mut c_char {
+    memory_id: *const c_char,
+    to: c_int,
+) -> c_int {
     if handle.is_null() {
         set_error("Agent handle is null");
-        return ptr::null_mut();
+        return -1;
     }
 
-    let Some(content_str) = cstr_to_string(content) else {
-        set_error("Invalid content: not valid UTF-8");
-        return ptr::null_mut();
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    let Some(id) = cstr_to_string(memory_id) else {
+        set_error("Invalid memory_id: not valid UTF-8");
+        return -1;
     };
 
     let agent = (*handle).inner.clone();
-    match block_on(async move { agent.remember_private(content_str).await }) {
-        Ok(id) => string_to_cstring(id),
+    let moved = id.clone();
+    let result = block_on(async move { move_memory(&agent, &id, to).await });
+
+    let handle = &*handle;
+    handle.invalidate_query_cache();
+    handle.invalidate_tag_index();
+    match result {
+        Ok(true) => {
+            let mut shared_ids = handle.shared_ids.lock().unwrap();
+            if to == SCOPE_SHARED {
+                shared_ids.insert(moved);
+            } else {
+                shared_ids.remove(&moved);
+            }
+            0
+        }
+        Ok(false) => {
+            set_error_with_code(ERROR_NOT_FOUND, "memory not found");
+            -1
+        }
         Err(e) => {
-            set_error(e.to_string());
-            ptr::null_mut()
+            set_thymos_error(&e);
+            -1
         }
     }
 }
 
-/// Store a memory in the shared backend (hybrid mode only).
+/// Move a private memory to the shared backend (hybrid mode only).
+///
+/// The memory keeps its ID, tags, properties and embedding, so a TTL set on
+/// it still applies. A memory that is already shared is left alone. Fails
+/// with ERROR_NOT_FOUND if neither backend has the memory.
 ///
 /// # Safety
-/// Same as `thymos_agent_remember`.
+/// `handle` must be a valid ThymosAgent handle.
+/// `memory_id` must be a valid null-terminated UTF-8 string.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_remember_shared(
+pub unsafe extern "C" fn thymos_agent_promote_to_shared(
     handle: *const ThymosAgent,
-    content: *const c_char,
-) -> *mut c_char {
-    if handle.is_null() {
-        set_error("Agent handle is null");
-        return ptr::null_mut();
-    }
-
-    let Some(content_str) = cstr_to_string(content) else {
-        set_error("Invalid content: not valid UTF-8");
-        return ptr::null_mut();
-    };
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    move_memory_ffi(handle, memory_id, SCOPE_SHARED)
+}
 
-    let agent = (*handle).inner.clone();
-    match block_on(async move { agent.remember_shared(content_str).await }) {
-        Ok(id) => string_to_cstring(id),
-        Err(e) => {
-            set_error(e.to_string());
-            ptr::null_mut()
-        }
-    }
+/// Move a shared memory to the private backend (hybrid mode only).
+///
+/// The memory keeps its ID. A memory that is already private is left alone.
+/// Fails with ERROR_NOT_FOUND if neither backend has the memory.
+///
+/// # Safety
+/// Same as `thymos_agent_promote_to_shared`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_demote_to_private(
+    handle: *const ThymosAgent,
+    memory_id: *const c_char,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
+    move_memory_ffi(handle, memory_id, SCOPE_PRIVATE)
 }
 
 // ============================================================================
-// Memory Search
+// Idempotent Writes
 // ============================================================================
 
-/// Search memories.
+/// Property holding the idempotency key a memory was stored under.
+const IDEMPOTENCY_KEY_PROPERTY: &str = "idempotency_key";
+
+/// Store a general memory once per idempotency key.
 ///
-/// # Arguments
-/// * `handle` - Agent handle
-/// * `query` - Search query string
-/// * `limit` - Maximum number of results (0 = no limit)
+/// If a memory stored under `key` still exists its ID is returned and
+/// `content` is ignored; otherwise the memory is stored like
+/// `thymos_agent_remember`, with the key as a property, and its ID returned.
+/// Keys stored by earlier processes are found by scanning the local store
+/// once, on first use. Memories go to the local store (the private backend in
+/// hybrid mode).
 ///
 /// # Safety
 /// `handle` must be a valid ThymosAgent handle.
-/// `query` must be a valid null-terminated UTF-8 string.
-/// The returned results must be freed with `thymos_free_search_results`.
+/// `key` and `content` must be valid null-terminated UTF-8 strings.
+/// The returned string must be freed with `thymos_free_string`.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_search_memories(
+pub unsafe extern "C" fn thymos_agent_remember_idempotent(
     handle: *const ThymosAgent,
-    query: *const c_char,
-    limit: usize,
-) -> *mut ThymosSearchResults {
+    key: *const c_char,
+    content: *const c_char,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
     if handle.is_null() {
         set_error("Agent handle is null");
         return ptr::null_mut();
     }
 
-    let Some(query_str) = cstr_to_string(query) else {
-        set_error("Invalid query: not valid UTF-8");
+    if !check_writable(&*handle) {
+        return ptr::null_mut();
+    }
+
+    let Some(key) = cstr_to_string(key).filter(|k| !k.is_empty()) else {
+        set_error("Invalid key: empty or not valid UTF-8");
+        return ptr::null_mut();
+    };
+
+    let Some(content_str) = cstr_to_string(content) else {
+        set_error("Invalid content: not valid UTF-8");
         return ptr::null_mut();
     };
 
+    // Held across the lookup and store so a key is never stored twice.
+    let mut keys = (*handle).idempotency_keys.lock().unwrap();
+    let known = keys.as_ref().map(|keys| keys.get(&key).cloned());
     let agent = (*handle).inner.clone();
-    match block_on(async move {
-        let mut memories = agent.search_memories(&query_str).await?;
-        if limit > 0 {
-            memories.truncate(limit);
+    let stored_key = key.clone();
+    let result = block_on(async move {
+        let (loaded, existing) = match known {
+            Some(existing) => (None, existing),
+            None => {
+                let loaded: HashMap<String, String> = load_all_memories(&agent)
+                    .await?
+                    .into_iter()
+                    .filter_map(|m| {
+                        let key = m.properties.get(IDEMPOTENCY_KEY_PROPERTY)?.as_str()?;
+                        Some((key.to_string(), m.id))
+                    })
+                    .collect();
+                let existing = loaded.get(&stored_key).cloned();
+                (Some(loaded), existing)
+            }
+        };
+
+        // A memory forgotten since its key was stored no longer counts
+        if let Some(id) = existing {
+            if agent.get_memory(&id).await?.is_some() {
+                return Ok((loaded, id, false));
+            }
         }
-        Ok(memories)
-    }) {
-        Ok(memories) => {
-            let mut results: Vec<ThymosMemory> = memories
-                .iter()
-                .map(|m| ThymosMemory::from_locai(m))
-                .collect();
 
-            let count = results.len();
-            let capacity = results.capacity();
-            let ptr = if count > 0 {
-                let p = results.as_mut_ptr();
-                std::mem::forget(results);
-                p
-            } else {
-                ptr::null_mut()
-            };
+        let properties = serde_json::json!({ IDEMPOTENCY_KEY_PROPERTY: stored_key });
+        let id = store_embedded(
+            &agent,
+            content_str,
+            locai::models::MemoryType::Episodic,
+            properties,
+        )
+        .await?;
+        Ok((loaded, id, true))
+    });
 
-            Box::into_raw(Box::new(ThymosSearchResults {
-                memories: ptr,
-                count,
-                capacity,
-            }))
+    match result {
+        Ok((loaded, id, created)) => {
+            if let Some(loaded) = loaded {
+                *keys = Some(loaded);
+            }
+            if let Some(keys) = keys.as_mut() {
+                keys.insert(key, id.clone());
+            }
+            drop(keys);
+            if created {
+                (*handle).invalidate_query_cache();
+                (*handle).enforce_retention(Some(1));
+            }
+            string_to_cstring(id)
         }
         Err(e) => {
-            set_error(e.to_string());
+            set_thymos_error(&e);
             ptr::null_mut()
         }
     }
 }
 
-/// Search private memories (hybrid mode only).
-///
-/// # Safety
-/// Same as `thymos_agent_search_memories`.
-#[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_search_private(
-    handle: *const ThymosAgent,
-    query: *const c_char,
-    limit: usize,
-) -> *mut ThymosSearchResults {
-    if handle.is_null() {
-        set_error("Agent handle is null");
-        return ptr::null_mut();
+// ============================================================================
+// Deduplication
+// ============================================================================
+
+/// Similarity of two memories in [0, 1]: cosine similarity of their stored
+/// embeddings when both have one of the same dimension, otherwise Jaccard
+/// similarity of their term sets.
+fn memory_similarity(
+    a: &locai::models::Memory,
+    b: &locai::models::Memory,
+    terms_a: &HashSet<String>,
+    terms_b: &HashSet<String>,
+) -> f64 {
+    if let (Some(x), Some(y)) = (&a.embedding, &b.embedding) {
+        if x.len() == y.len() {
+            return cosine_similarity(x, y).max(0.0) as f64;
+        }
     }
+    let union = terms_a.union(terms_b).count();
+    if union == 0 {
+        return if a.content == b.content { 1.0 } else { 0.0 };
+    }
+    terms_a.intersection(terms_b).count() as f64 / union as f64
+}
 
-    let Some(query_str) = cstr_to_string(query) else {
-        set_error("Invalid query: not valid UTF-8");
-        return ptr::null_mut();
-    };
+/// Group the agent's memories into clusters of near duplicates.
+///
+/// Memories are visited oldest first and each joins the first cluster whose
+/// members are all at least `threshold` similar to it (see
+/// `memory_similarity`), so every pair within a cluster clears the threshold.
+/// Only clusters of two or more are returned, largest first, each oldest
+/// first. Compares every pair, so cost grows with the square of the store.
+async fn duplicate_clusters(
+    agent: &Agent,
+    threshold: f64,
+) -> Result<Vec<Vec<locai::models::Memory>>> {
+    let now = chrono::Utc::now();
+    let mut memories = load_all_memories(agent).await?;
+    memories.retain(|m| !memory_expired(m, now));
+    memories.sort_by(|a, b| a.created_at.cmp(&b.created_at).then(a.id.cmp(&b.id)));
+    let terms: Vec<HashSet<String>> = memories
+        .iter()
+        .map(|m| tokenize(&m.content).collect())
+        .collect();
 
-    let agent = (*handle).inner.clone();
-    match block_on(async move {
-        let mut memories = agent.search_private(&query_str).await?;
-        if limit > 0 {
-            memories.truncate(limit);
+    let mut clusters: Vec<Vec<usize>> = Vec::new();
+    for i in 0..memories.len() {
+        let joined = clusters.iter_mut().find(|cluster| {
+            cluster.iter().all(|&j| {
+                memory_similarity(&memories[i], &memories[j], &terms[i], &terms[j]) >= threshold
+            })
+        });
+        match joined {
+            Some(cluster) => cluster.push(i),
+            None => clusters.push(vec![i]),
         }
-        Ok(memories)
-    }) {
-        Ok(memories) => {
-            let mut results: Vec<ThymosMemory> = memories
-                .iter()
-                .map(|m| ThymosMemory::from_locai(m))
-                .collect();
+    }
 
-            let count = results.len();
-            let capacity = results.capacity();
-            let ptr = if count > 0 {
-                let p = results.as_mut_ptr();
-                std::mem::forget(results);
-                p
-            } else {
-                ptr::null_mut()
-            };
+    clusters.retain(|cluster| cluster.len() > 1);
+    // Stable, so equal sizes keep the oldest cluster first
+    clusters.sort_by_key(|cluster| std::cmp::Reverse(cluster.len()));
+    Ok(clusters
+        .into_iter()
+        .map(|cluster| cluster.into_iter().map(|i| memories[i].clone()).collect())
+        .collect())
+}
 
-            Box::into_raw(Box::new(ThymosSearchResults {
-                memories: ptr,
-                count,
-                capacity,
-            }))
-        }
-        Err(e) => {
-            set_error(e.to_string());
-            ptr::null_mut()
-        }
+/// Check a duplicate similarity threshold, setting the error if invalid.
+fn check_duplicate_threshold(threshold: f64) -> bool {
+    if !(threshold > 0.0 && threshold <= 1.0) {
+        set_error_with_code(
+            ERROR_INVALID_ARGUMENT,
+            "Invalid threshold: must be greater than 0 and at most 1",
+        );
+        return false;
     }
+    true
 }
 
-/// Search shared memories (hybrid mode only).
+/// Find clusters of near-duplicate memories.
+///
+/// Clusters are built as described in `duplicate_clusters` and returned
+/// flattened, with `(*out_keys)[i]` holding the ID of the oldest memory in
+/// result i's cluster, so consecutive results sharing a key form one cluster.
+/// At most `limit` clusters are returned (0 = no limit).
 ///
 /// # Safety
-/// Same as `thymos_agent_search_memories`.
+/// `handle` must be a valid ThymosAgent handle.
+/// `out_keys` must be a valid pointer.
+/// The returned results must be freed with `thymos_free_search_results` and
+/// `*out_keys` with `thymos_free_string_list`.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_search_shared(
+pub unsafe extern "C" fn thymos_agent_find_duplicates(
     handle: *const ThymosAgent,
-    query: *const c_char,
+    threshold: f64,
     limit: usize,
+    out_keys: *mut *mut ThymosStringList,
+    out_error: *mut ThymosError,
 ) -> *mut ThymosSearchResults {
+    let _error_out = ErrorOut::new(out_error);
     if handle.is_null() {
         set_error("Agent handle is null");
         return ptr::null_mut();
     }
 
-    let Some(query_str) = cstr_to_string(query) else {
-        set_error("Invalid query: not valid UTF-8");
+    if out_keys.is_null() {
+        set_error("out_keys is null");
         return ptr::null_mut();
-    };
+    }
+
+    if !check_duplicate_threshold(threshold) {
+        return ptr::null_mut();
+    }
 
     let agent = (*handle).inner.clone();
     match block_on(async move {
-        let mut memories = agent.search_shared(&query_str).await?;
+        let mut clusters = duplicate_clusters(&agent, threshold).await?;
         if limit > 0 {
-            memories.truncate(limit);
+            clusters.truncate(limit);
         }
-        Ok(memories)
+        Ok(clusters)
     }) {
-        Ok(memories) => {
-            let mut results: Vec<ThymosMemory> = memories
-                .iter()
-                .map(|m| ThymosMemory::from_locai(m))
-                .collect();
-
-            let count = results.len();
-            let capacity = results.capacity();
-            let ptr = if count > 0 {
-                let p = results.as_mut_ptr();
-                std::mem::forget(results);
-                p
-            } else {
-                ptr::null_mut()
-            };
-
-            Box::into_raw(Box::new(ThymosSearchResults {
-                memories: ptr,
-                count,
-                capacity,
-            }))
+        Ok(clusters) => {
+            let mut keys = Vec::new();
+            let mut memories = Vec::new();
+            for cluster in clusters {
+                let key = cluster[0].id.clone();
+                keys.extend(std::iter::repeat_n(key, cluster.len()));
+                memories.extend(cluster);
+            }
+            *out_keys = Box::into_raw(Box::new(ThymosStringList::from_strings(keys)));
+            (*handle).checked_results(&memories, &[])
         }
         Err(e) => {
-            set_error(e.to_string());
+            set_thymos_error(&e);
             ptr::null_mut()
         }
     }
 }
 
-/// Get a memory by ID.
+/// Forget all but the oldest memory of each near-duplicate cluster.
 ///
-/// Returns the memory on success, or null if not found or on error.
+/// Clusters are found as by `thymos_agent_find_duplicates`. Forgotten
+/// memories stay recoverable with `thymos_agent_restore_memory` until the
+/// configured grace period elapses.
 ///
 /// # Safety
 /// `handle` must be a valid ThymosAgent handle.
-/// `memory_id` must be a valid null-terminated UTF-8 string.
-/// The returned memory must be freed with `thymos_free_memory`.
+///
+/// Returns the number of memories forgotten, or -1 on error.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_get_memory(
+pub unsafe extern "C" fn thymos_agent_deduplicate(
     handle: *const ThymosAgent,
-    memory_id: *const c_char,
-) -> *mut ThymosMemory {
+    threshold: f64,
+    out_error: *mut ThymosError,
+) -> i64 {
+    let _error_out = ErrorOut::new(out_error);
     if handle.is_null() {
         set_error("Agent handle is null");
-        return ptr::null_mut();
+        return -1;
     }
 
-    let Some(id) = cstr_to_string(memory_id) else {
-        set_error("Invalid memory_id: not valid UTF-8");
-        return ptr::null_mut();
-    };
+    if !check_writable(&*handle) {
+        return -1;
+    }
+
+    if !check_duplicate_threshold(threshold) {
+        return -1;
+    }
 
     let agent = (*handle).inner.clone();
-    match block_on(async move { agent.get_memory(&id).await }) {
-        Ok(Some(memory)) => Box::into_raw(Box::new(ThymosMemory::from_locai(&memory))),
-        Ok(None) => ptr::null_mut(),
+    let forgotten = (*handle).forgotten.clone();
+    let grace = (*handle).options.forget_grace_period;
+
+    let result = block_on(async move {
+        let duplicates: Vec<_> = duplicate_clusters(&agent, threshold)
+            .await?
+            .into_iter()
+            .flat_map(|cluster| cluster.into_iter().skip(1))
+            .collect();
+        forget_memories(&agent, &forgotten, grace, duplicates).await
+    });
+    (*handle).invalidate_query_cache();
+
+    match result {
+        Ok(count) => count,
         Err(e) => {
-            set_error(e.to_string());
-            ptr::null_mut()
+            set_thymos_error(&e);
+            -1
         }
     }
 }
@@ -1098,7 +10990,11 @@ pub unsafe extern "C" fn thymos_version() -> *mut c_char {
 /// # Safety
 /// `handle` must be a valid ThymosAgent handle.
 #[unsafe(no_mangle)]
-pub unsafe extern "C" fn thymos_agent_is_hybrid(handle: *const ThymosAgent) -> c_int {
+pub unsafe extern "C" fn thymos_agent_is_hybrid(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> c_int {
+    let _error_out = ErrorOut::new(out_error);
     if handle.is_null() {
         set_error("Agent handle is null");
         return -1;
@@ -1110,3 +11006,44 @@ pub unsafe extern "C" fn thymos_agent_is_hybrid(handle: *const ThymosAgent) -> c
         0
     }
 }
+
+/// Get the directory where the agent stores memories locally.
+///
+/// The path is made absolute against the current directory, so agents built
+/// from the default configuration (relative "./data/memory") report where
+/// their data actually landed. In hybrid mode this is the private store.
+/// Fails with ERROR_CONFIGURATION in server mode, which has no local store.
+///
+/// # Safety
+/// `handle` must be a valid ThymosAgent handle.
+/// The returned string must be freed with `thymos_free_string`.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn thymos_agent_data_dir(
+    handle: *const ThymosAgent,
+    out_error: *mut ThymosError,
+) -> *mut c_char {
+    let _error_out = ErrorOut::new(out_error);
+    if handle.is_null() {
+        set_error("Agent handle is null");
+        return ptr::null_mut();
+    }
+
+    let Some(dir) = &(*handle).data_dir else {
+        set_error_with_code(
+            ERROR_CONFIGURATION,
+            "no local data directory: agent uses server mode",
+        );
+        return ptr::null_mut();
+    };
+
+    match std::path::absolute(dir) {
+        Ok(path) => string_to_cstring(path.to_string_lossy().into_owned()),
+        Err(e) => {
+            set_error_with_code(
+                ERROR_STORAGE,
+                format!("Failed to resolve data directory: {e}"),
+            );
+            ptr::null_mut()
+        }
+    }
+}